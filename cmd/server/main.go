@@ -3,19 +3,33 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
 	handler "github.com/julio-pupim/lojaestoque/internal/handler"
 	myMiddleware "github.com/julio-pupim/lojaestoque/internal/middleware"
 	"github.com/julio-pupim/lojaestoque/internal/repository"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "usuario" {
+		runUsuario(os.Args[2:])
+		return
+	}
+
 	db := database.InitDB()
+	if _, err := repository.NewOrcamentoRepository(db).ExpirarOrcamentos(time.Now()); err != nil {
+		log.Printf("Erro ao expirar orçamentos vencidos: %v", err)
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
@@ -28,15 +42,19 @@ func main() {
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
+	// depois do CORS, para que o 401 de token inválido chegue ao navegador
+	r.Use(myMiddleware.Usuario(repository.NewUsuarioRepository(db)))
 
 	r.Handle("/*", http.FileServer(http.Dir("./frontend")))
 
+	crr := repository.NewContasReceberRepository(db)
+	dr := repository.NewDevolucaoRepository(db)
 	r.Route("/clientes", func(r chi.Router) {
 		cr := repository.NewClienteRepository(db)
 		r.Post("/", handler.CriarCliente(cr))
@@ -44,19 +62,126 @@ func main() {
 		r.Delete("/{id}", handler.DeleteCliente(cr))
 		r.Patch("/{id}", handler.UpdateCliente(cr))
 		r.Get("/{id}", handler.GetClienteById(cr))
+		r.Get("/{id}/extrato", handler.ExtratoCliente(crr))
+		r.Get("/{id}/credito", handler.GetCreditoCliente(cr))
+		r.With(myMiddleware.RequerPerfil(domain.PerfilGerente)).Put("/{id}/credito", handler.UpdateCreditoCliente(cr))
+		r.Get("/{id}/credito-loja", handler.CreditoLojaCliente(dr))
+	})
+	fr := repository.NewFornecedorRepository(db)
+	dfr := repository.NewDevolucaoFornecedorRepository(db)
+	r.Route("/fornecedores", func(r chi.Router) {
+		r.Post("/", handler.CriarFornecedor(fr))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarFornecedores(fr))
+		r.Get("/{id}", handler.GetFornecedorById(fr))
+		r.Patch("/{id}", handler.UpdateFornecedor(fr))
+		r.Delete("/{id}", handler.DeleteFornecedor(fr))
+		r.Get("/{id}/creditos", handler.CreditosFornecedor(dfr))
+	})
+	dpr := repository.NewDepositoRepository(db)
+	r.Route("/depositos", func(r chi.Router) {
+		r.Post("/", handler.CriarDeposito(dpr))
+		r.Get("/", handler.BuscarDepositos(dpr))
+		r.Patch("/{id}", handler.UpdateDeposito(dpr))
+		r.With(myMiddleware.Pagination).Get("/{id}/estoque", handler.EstoqueDeposito(dpr))
+	})
+	r.Route("/transferencias", func(r chi.Router) {
+		tr := repository.NewTransferenciaRepository(db)
+		r.Post("/", handler.CriarTransferencia(tr))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarTransferencias(tr))
+		r.Get("/{id}", handler.GetTransferenciaById(tr))
 	})
 	r.Route("/produtos", func(r chi.Router) {
 		pr := repository.NewProdutoRepository(db)
-		r.Post("/", handler.CreateOrAddProduto(pr))
+		mr := repository.NewMovimentoRepository(db)
+		lr := repository.NewLoteRepository(db)
+		r.Post("/", handler.CreateOrAddProduto(pr, fr))
 		r.With(myMiddleware.Pagination).Get("/", handler.SearchProdutos(pr))
 		r.Delete("/{id}", handler.DeleteProduto(pr))
 		r.Patch("/{id}", handler.UpdateProduto(pr))
+		r.Get("/consistencia", handler.VerificarConsistenciaEstoque(mr))
+		r.Get("/avaliacao", handler.AvaliacaoEstoque(mr))
+		r.Get("/ajustes", handler.RelatorioAjustes(mr))
+		r.Get("/vencimentos", handler.RelatorioVencimentos(lr))
+		r.Get("/barcode/{codigo}", handler.GetProdutoPorCodigoBarras(pr))
+		r.Post("/codigo-barras/gerar", handler.GerarCodigosBarrasFaltantes(pr))
+		r.With(myMiddleware.Pagination).Get("/abaixo-minimo", handler.ProdutosAbaixoDoMinimo(pr))
+		r.Get("/sugestao-reposicao", handler.SugestaoReposicao(pr))
+		r.With(myMiddleware.Pagination).Get("/{id}/movimentos", handler.BuscarMovimentos(mr))
+		r.Get("/{id}/preco-sugerido", handler.PrecoSugerido(pr))
+		r.Get("/{id}/estoque", handler.EstoqueProdutoPorDeposito(dpr))
+		r.Get("/{id}/lotes", handler.BuscarLotesProduto(lr))
+		r.Post("/{id}/ajustes", handler.AjustarEstoque(mr))
+		r.Post("/{id}/codigo-barras", handler.GerarCodigoBarras(pr))
+	})
+	r.Route("/compras", func(r chi.Router) {
+		cr := repository.NewCompraRepository(db)
+		r.Post("/", handler.CriarCompra(cr))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarCompras(cr))
+		r.Get("/{id}", handler.GetCompraById(cr))
+		r.Post("/{id}/receber", handler.ReceberCompra(cr))
+		r.Post("/{id}/cancelar", handler.CancelarCompra(cr))
+		r.Get("/{id}/devolucoes", handler.BuscarDevolucoesFornecedor(dfr))
+		r.Post("/{id}/devolucoes", handler.RegistrarDevolucaoFornecedor(dfr))
+		r.Post("/{id}/devolucoes/{devolucaoId}/credito", handler.BaixarCreditoFornecedor(dfr))
+	})
+	r.Route("/etiquetas", func(r chi.Router) {
+		r.Get("/", handler.GerarEtiquetas(repository.NewProdutoRepository(db), repository.NewCompraRepository(db)))
 	})
 	r.Route("/vendas", func(r chi.Router) {
 		vr := repository.NewVendasRepository(db)
 		r.Post("/", handler.CriarVenda(vr))
 		r.With(myMiddleware.Pagination).Get("/", handler.BuscarVenda(vr))
-		r.Delete("/{id}", handler.DeletarVenda(vr))
+		r.Get("/{id}", handler.GetVendaById(vr))
+		r.Patch("/{id}", handler.UpdateVenda(vr))
+		r.Post("/{id}/cancelar", handler.CancelarVenda(vr))
+
+		pgr := repository.NewPagamentoRepository(db)
+		r.Get("/{id}/pagamentos", handler.BuscarPagamentos(pgr))
+		r.Post("/{id}/pagamentos", handler.RegistrarPagamento(pgr))
+		r.Put("/{id}/parcelas", handler.DefinirParcelas(pgr))
+
+		r.Get("/{id}/devolucoes", handler.BuscarDevolucoes(dr))
+		r.Post("/{id}/devolucoes", handler.RegistrarDevolucao(dr))
+	})
+
+	r.Route("/orcamentos", func(r chi.Router) {
+		orc := repository.NewOrcamentoRepository(db)
+		r.Post("/", handler.CriarOrcamento(orc))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarOrcamentos(orc))
+		r.Get("/{id}", handler.GetOrcamentoById(orc))
+		r.Post("/{id}/aprovar", handler.AprovarOrcamento(orc))
+		r.Post("/{id}/converter", handler.ConverterOrcamento(orc))
+	})
+
+	r.Route("/inventarios", func(r chi.Router) {
+		ir := repository.NewInventarioRepository(db)
+		r.Post("/", handler.AbrirInventario(ir))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarInventarios(ir))
+		r.Get("/{id}", handler.GetInventarioById(ir))
+		r.Get("/{id}/divergencias", handler.DivergenciasInventario(ir))
+		r.Post("/{id}/contagens", handler.RegistrarContagens(ir))
+		r.Post("/{id}/aprovar", handler.AprovarInventario(ir))
+		r.Post("/{id}/cancelar", handler.CancelarInventario(ir))
+	})
+
+	r.Route("/promocoes", func(r chi.Router) {
+		pmr := repository.NewPromocaoRepository(db)
+		r.Post("/", handler.CriarPromocao(pmr))
+		r.With(myMiddleware.Pagination).Get("/", handler.BuscarPromocoes(pmr))
+		r.Get("/{id}", handler.GetPromocaoById(pmr))
+		r.Delete("/{id}", handler.DeletePromocao(pmr))
+		r.Post("/{id}/encerrar", handler.EncerrarPromocao(pmr))
+	})
+
+	r.Route("/descontos", func(r chi.Router) {
+		dsr := repository.NewDescontoRepository(db)
+		r.Get("/limites", handler.BuscarLimitesDesconto(dsr))
+		r.With(myMiddleware.RequerPerfil(domain.PerfilGerente)).Put("/limites/{perfil}", handler.DefinirLimiteDesconto(dsr))
+	})
+
+	r.Route("/receber", func(r chi.Router) {
+		r.Get("/saldos", handler.SaldosReceber(crr))
+		r.Get("/vencidos", handler.ClientesVencidos(crr))
 	})
 
 	log.Println("Servidor rodando em http://localhost:8080")
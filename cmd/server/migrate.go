@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/migrations"
+)
+
+const usoMigrate = `uso: server migrate [up | down [N] | status | baseline N]
+
+  up          aplica todas as migrações pendentes (padrão)
+  down N      reverte as últimas N migrações aplicadas (padrão 1)
+  status      lista as migrações e se já foram aplicadas
+  baseline N  registra como aplicadas, sem executar, as migrações até a N,
+              para bancos criados antes do controle de versões
+
+O banco usado é o de DB_PATH (padrão ` + database.CaminhoPadrao + `).`
+
+// runMigrate executa o subcomando "migrate" sem subir o servidor HTTP
+func runMigrate(args []string) {
+	db, err := database.Open(database.CaminhoBanco())
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco: %v", err)
+	}
+	defer db.Close()
+
+	migracoes, err := database.CarregarMigracoes(migrations.FS)
+	if err != nil {
+		log.Fatalf("Erro ao carregar migrações: %v", err)
+	}
+
+	acao := "up"
+	if len(args) > 0 {
+		acao = args[0]
+	}
+
+	switch acao {
+	case "up":
+		novas, err := database.AplicarMigracoes(db, migracoes)
+		for _, m := range novas {
+			fmt.Printf("aplicada  %03d_%s\n", m.Versao, m.Nome)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(novas) == 0 {
+			fmt.Println("banco já está atualizado")
+		}
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				log.Fatalf("quantidade inválida: %s", args[1])
+			}
+		}
+		revertidas, err := database.ReverterMigracoes(db, migracoes, n)
+		for _, m := range revertidas {
+			fmt.Printf("revertida %03d_%s\n", m.Versao, m.Nome)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "baseline":
+		if len(args) < 2 {
+			log.Fatal(usoMigrate)
+		}
+		ate, err := strconv.Atoi(args[1])
+		if err != nil || ate < 1 {
+			log.Fatalf("versão inválida: %s", args[1])
+		}
+		marcadas, err := database.MarcarMigracoes(db, migracoes, ate)
+		for _, m := range marcadas {
+			fmt.Printf("marcada   %03d_%s\n", m.Versao, m.Nome)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		status, err := database.StatusMigracoes(db, migracoes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range status {
+			situacao := "pendente"
+			if s.Aplicada {
+				situacao = "aplicada em " + s.AplicadaEm.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d_%-30s %s\n", s.Versao, s.Nome, situacao)
+		}
+	default:
+		log.Fatal(usoMigrate)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+const usoUsuario = `uso: server usuario NOME PERFIL
+
+Cadastra o usuário e mostra o token de acesso, enviado pelo cliente em
+"Authorization: Bearer <token>". O token não fica guardado e não pode ser
+exibido de novo. O perfil ` + domain.PerfilGerente + ` libera crédito, autoriza preço abaixo
+da tabela e altera limites de desconto.`
+
+// runUsuario executa o subcomando "usuario" sem subir o servidor HTTP
+func runUsuario(args []string) {
+	if len(args) != 2 {
+		log.Fatal(usoUsuario)
+	}
+	u := domain.Usuario{Nome: args[0], Perfil: args[1]}
+	if err := u.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	db := database.InitDB()
+	defer db.Close()
+	token, err := repository.NewUsuarioRepository(db).CriarUsuario(&u)
+	if err != nil {
+		log.Fatalf("Erro ao cadastrar usuário: %v", err)
+	}
+	fmt.Printf("usuário %s (%s) cadastrado\ntoken: %s\n", u.Nome, u.Perfil, token)
+}
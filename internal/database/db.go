@@ -3,19 +3,74 @@ package database
 import (
 	"database/sql"
 	"log"
+	"os"
 
+	"github.com/julio-pupim/lojaestoque/migrations"
 	_ "modernc.org/sqlite"
 )
 
-func InitDB() *sql.DB {
-	db, err := sql.Open("sqlite", "../../migrations/estoque.db")
+// CaminhoPadrao é usado quando a variável de ambiente DB_PATH não está definida
+const CaminhoPadrao = "estoque.db"
+
+// Open abre o banco SQLite em path sem aplicar migrações.
+//
+// _txlock=immediate faz cada transação reservar a escrita já no BEGIN, então
+// vendas concorrentes são serializadas em vez de falharem com SQLITE_BUSY.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+
+		"?_time_format=sqlite&_txlock=immediate"+
+		"&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// caminhoAntigo é onde o banco ficava antes de CaminhoPadrao, relativo a
+// cmd/server, de onde o servidor era executado
+const caminhoAntigo = "../../migrations/estoque.db"
+
+// CaminhoBanco devolve o arquivo do banco configurado em DB_PATH. Sem ele usa
+// CaminhoPadrao, a menos que só exista o banco do caminho antigo: nesse caso
+// segue com ele, em vez de subir com um banco vazio e deixar os dados para trás.
+func CaminhoBanco() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	if !existe(CaminhoPadrao) && existe(caminhoAntigo) {
+		log.Printf("Usando o banco em %s; defina DB_PATH ou mova o arquivo para %s", caminhoAntigo, CaminhoPadrao)
+		return caminhoAntigo
+	}
+	return CaminhoPadrao
+}
 
+func existe(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// InitDB abre o banco e aplica as migrações pendentes, criando o esquema
+// completo num checkout novo
+func InitDB() *sql.DB {
+	db, err := Open(CaminhoBanco())
 	if err != nil {
 		log.Fatalf("Erro ao conectar ao banco: %v", err)
 	}
 
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Erro ao testar conexão com o banco: %v", err)
+	migracoes, err := CarregarMigracoes(migrations.FS)
+	if err != nil {
+		log.Fatalf("Erro ao carregar migrações: %v", err)
+	}
+	novas, err := AplicarMigracoes(db, migracoes)
+	if err != nil {
+		log.Fatalf("Erro ao aplicar migrações: %v", err)
+	}
+	for _, m := range novas {
+		log.Printf("Migração aplicada: %03d_%s", m.Versao, m.Nome)
 	}
 
 	return db
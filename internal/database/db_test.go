@@ -0,0 +1,50 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaminhoBancoAntigo(t *testing.T) {
+	raiz := t.TempDir()
+	servidor := filepath.Join(raiz, "cmd", "server")
+	if err := os.MkdirAll(servidor, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	anterior, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(servidor); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(anterior) })
+	t.Setenv("DB_PATH", "")
+
+	if got := CaminhoBanco(); got != CaminhoPadrao {
+		t.Errorf("checkout novo: %s", got)
+	}
+
+	// só o banco antigo existe: os dados dele não ficam para trás
+	if err := os.MkdirAll(filepath.Join(raiz, "migrations"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(raiz, "migrations", "estoque.db"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := CaminhoBanco(); got != caminhoAntigo {
+		t.Errorf("com banco antigo: %s", got)
+	}
+
+	if err := os.WriteFile(CaminhoPadrao, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := CaminhoBanco(); got != CaminhoPadrao {
+		t.Errorf("com os dois bancos: %s", got)
+	}
+	t.Setenv("DB_PATH", "outro.db")
+	if got := CaminhoBanco(); got != "outro.db" {
+		t.Errorf("com DB_PATH: %s", got)
+	}
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migracao é uma versão do esquema com seus scripts de subida e de reversão
+type Migracao struct {
+	Versao   int
+	Nome     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// StatusMigracao informa se uma versão já foi aplicada e quando
+type StatusMigracao struct {
+	Versao     int
+	Nome       string
+	Aplicada   bool
+	AplicadaEm *time.Time
+}
+
+// ErrBancoSemVersoes indica banco com tabelas criadas pelos scripts avulsos de
+// antes do controle de versões: aplicar a 001 por cima não corrigiria tabelas
+// com outro esquema, então é preciso dizer até onde o esquema já chegou
+var ErrBancoSemVersoes = errors.New("o banco já tem tabelas mas nenhuma migração registrada; " +
+	"faça um backup e registre até qual versão o esquema já está com \"migrate baseline N\"")
+
+var arquivoMigracao = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// CarregarMigracoes lê os pares NNN_nome.up.sql / NNN_nome.down.sql de fsys,
+// em ordem de versão. Toda versão precisa dos dois arquivos.
+func CarregarMigracoes(fsys fs.FS) ([]Migracao, error) {
+	arquivos, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	porVersao := make(map[int]*Migracao)
+	for _, arquivo := range arquivos {
+		partes := arquivoMigracao.FindStringSubmatch(arquivo)
+		if partes == nil {
+			return nil, fmt.Errorf("nome de migração inválido: %s", arquivo)
+		}
+		versao, _ := strconv.Atoi(partes[1])
+		conteudo, err := fs.ReadFile(fsys, arquivo)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := porVersao[versao]
+		if !ok {
+			m = &Migracao{Versao: versao, Nome: partes[2]}
+			porVersao[versao] = m
+		} else if m.Nome != partes[2] {
+			return nil, fmt.Errorf("versão %d usada por %q e %q", versao, m.Nome, partes[2])
+		}
+		if partes[3] == "up" {
+			m.Up = string(conteudo)
+			soma := sha256.Sum256(conteudo)
+			m.Checksum = hex.EncodeToString(soma[:])
+		} else {
+			m.Down = string(conteudo)
+		}
+	}
+
+	migracoes := make([]Migracao, 0, len(porVersao))
+	for _, m := range porVersao {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migração %03d_%s precisa dos arquivos up e down", m.Versao, m.Nome)
+		}
+		migracoes = append(migracoes, *m)
+	}
+	sort.Slice(migracoes, func(i, j int) bool { return migracoes[i].Versao < migracoes[j].Versao })
+	return migracoes, nil
+}
+
+// AplicarMigracoes aplica, cada uma em sua própria transação, as versões
+// ainda não registradas em schema_migrations. Antes confere o checksum das
+// versões já aplicadas, recusando scripts alterados depois de aplicados.
+func AplicarMigracoes(db *sql.DB, migracoes []Migracao) ([]Migracao, error) {
+	aplicadas, err := migracoesAplicadas(db, migracoes)
+	if err != nil {
+		return nil, err
+	}
+	if len(aplicadas) == 0 {
+		if err := conferirBancoVazio(db); err != nil {
+			return nil, err
+		}
+	}
+
+	var novas []Migracao
+	for _, m := range migracoes {
+		if _, ok := aplicadas[m.Versao]; ok {
+			continue
+		}
+		err := executarMigracao(db, m.Up,
+			`INSERT INTO schema_migrations (versao, nome, checksum, aplicada_em) VALUES (?, ?, ?, ?)`,
+			m.Versao, m.Nome, m.Checksum, time.Now())
+		if err != nil {
+			return novas, fmt.Errorf("migração %03d_%s: %w", m.Versao, m.Nome, err)
+		}
+		novas = append(novas, m)
+	}
+	return novas, nil
+}
+
+// MarcarMigracoes registra como aplicadas, sem executá-las, as versões até
+// ate, para bancos cujo esquema já foi criado fora do controle de versões
+func MarcarMigracoes(db *sql.DB, migracoes []Migracao, ate int) ([]Migracao, error) {
+	aplicadas, err := migracoesAplicadas(db, migracoes)
+	if err != nil {
+		return nil, err
+	}
+
+	var marcadas []Migracao
+	for _, m := range migracoes {
+		if _, ok := aplicadas[m.Versao]; ok || m.Versao > ate {
+			continue
+		}
+		if _, err := db.Exec(
+			`INSERT INTO schema_migrations (versao, nome, checksum, aplicada_em) VALUES (?, ?, ?, ?)`,
+			m.Versao, m.Nome, m.Checksum, time.Now()); err != nil {
+			return marcadas, fmt.Errorf("marcando %03d_%s: %w", m.Versao, m.Nome, err)
+		}
+		marcadas = append(marcadas, m)
+	}
+	return marcadas, nil
+}
+
+// ReverterMigracoes executa o script down das últimas n versões aplicadas
+func ReverterMigracoes(db *sql.DB, migracoes []Migracao, n int) ([]Migracao, error) {
+	aplicadas, err := migracoesAplicadas(db, migracoes)
+	if err != nil {
+		return nil, err
+	}
+
+	var revertidas []Migracao
+	for i := len(migracoes) - 1; i >= 0 && len(revertidas) < n; i-- {
+		m := migracoes[i]
+		if _, ok := aplicadas[m.Versao]; !ok {
+			continue
+		}
+		err := executarMigracao(db, m.Down, `DELETE FROM schema_migrations WHERE versao = ?`, m.Versao)
+		if err != nil {
+			return revertidas, fmt.Errorf("reversão %03d_%s: %w", m.Versao, m.Nome, err)
+		}
+		revertidas = append(revertidas, m)
+	}
+	return revertidas, nil
+}
+
+// StatusMigracoes lista todas as versões conhecidas e se já foram aplicadas
+func StatusMigracoes(db *sql.DB, migracoes []Migracao) ([]StatusMigracao, error) {
+	aplicadas, err := migracoesAplicadas(db, migracoes)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]StatusMigracao, 0, len(migracoes))
+	for _, m := range migracoes {
+		s := StatusMigracao{Versao: m.Versao, Nome: m.Nome}
+		if em, ok := aplicadas[m.Versao]; ok {
+			s.Aplicada = true
+			s.AplicadaEm = &em
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// migracoesAplicadas cria schema_migrations se preciso e devolve a data de
+// aplicação de cada versão, validando checksums contra os arquivos atuais
+func migracoesAplicadas(db *sql.DB, migracoes []Migracao) (map[int]time.Time, error) {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		    versao INTEGER PRIMARY KEY,
+		    nome TEXT NOT NULL,
+		    checksum TEXT NOT NULL,
+		    aplicada_em DATETIME NOT NULL
+		)`); err != nil {
+		return nil, err
+	}
+
+	conhecidas := make(map[int]Migracao, len(migracoes))
+	for _, m := range migracoes {
+		conhecidas[m.Versao] = m
+	}
+
+	rows, err := db.Query(`SELECT versao, nome, checksum, aplicada_em FROM schema_migrations ORDER BY versao`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aplicadas := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			versao         int
+			nome, checksum string
+			aplicadaEm     time.Time
+		)
+		if err := rows.Scan(&versao, &nome, &checksum, &aplicadaEm); err != nil {
+			return nil, err
+		}
+		m, ok := conhecidas[versao]
+		if !ok {
+			return nil, fmt.Errorf("versão %03d_%s aplicada no banco não existe nos arquivos de migração", versao, nome)
+		}
+		if m.Checksum != checksum {
+			return nil, fmt.Errorf("migração %03d_%s foi alterada depois de aplicada (checksum divergente)", versao, nome)
+		}
+		aplicadas[versao] = aplicadaEm
+	}
+	return aplicadas, rows.Err()
+}
+
+// conferirBancoVazio recusa com ErrBancoSemVersoes o banco sem migrações
+// registradas que já tenha outras tabelas além de schema_migrations
+func conferirBancoVazio(db *sql.DB) error {
+	var tabelas int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master
+	                     WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name <> 'schema_migrations'`).Scan(&tabelas)
+	if err != nil {
+		return err
+	}
+	if tabelas > 0 {
+		return ErrBancoSemVersoes
+	}
+	return nil
+}
+
+// executarMigracao roda o script e o registro em schema_migrations na mesma transação
+func executarMigracao(db *sql.DB, script, registro string, args ...any) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(registro, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/julio-pupim/lojaestoque/migrations"
+)
+
+func TestMigracoesSobemEDescem(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migracoes, err := CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatalf("CarregarMigracoes: %v", err)
+	}
+
+	novas, err := AplicarMigracoes(db, migracoes)
+	if err != nil {
+		t.Fatalf("AplicarMigracoes: %v", err)
+	}
+	if len(novas) != len(migracoes) {
+		t.Errorf("aplicadas %d de %d migrações", len(novas), len(migracoes))
+	}
+	if novas, _ = AplicarMigracoes(db, migracoes); len(novas) != 0 {
+		t.Errorf("segunda aplicação repetiu %d migrações", len(novas))
+	}
+
+	revertidas, err := ReverterMigracoes(db, migracoes, len(migracoes))
+	if err != nil {
+		t.Fatalf("ReverterMigracoes: %v", err)
+	}
+	if len(revertidas) != len(migracoes) {
+		t.Errorf("revertidas %d de %d migrações", len(revertidas), len(migracoes))
+	}
+	if _, err := AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatalf("reaplicando após reverter: %v", err)
+	}
+
+	status, err := StatusMigracoes(db, migracoes)
+	if err != nil {
+		t.Fatalf("StatusMigracoes: %v", err)
+	}
+	for _, s := range status {
+		if !s.Aplicada {
+			t.Errorf("migração %03d_%s não aplicada", s.Versao, s.Nome)
+		}
+	}
+}
+
+func TestMigracaoAlteradaDepoisDeAplicada(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"001_teste.up.sql":   {Data: []byte("CREATE TABLE t (id INTEGER);")},
+		"001_teste.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+	migracoes, err := CarregarMigracoes(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["001_teste.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE t (id INTEGER, nome TEXT);")}
+	if migracoes, err = CarregarMigracoes(fsys); err != nil {
+		t.Fatal(err)
+	}
+	_, err = AplicarMigracoes(db, migracoes)
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("err = %v, esperado erro de checksum", err)
+	}
+}
+
+func TestCarregarMigracoesExigeUpEDown(t *testing.T) {
+	fsys := fstest.MapFS{"002_sem_down.up.sql": {Data: []byte("SELECT 1;")}}
+	if _, err := CarregarMigracoes(fsys); err == nil {
+		t.Error("migração sem arquivo down deveria ser recusada")
+	}
+}
+
+// Bancos criados pelos scripts avulsos, antes de schema_migrations, não
+// recebem a 001 por cima: o operador registra até onde o esquema chegou
+func TestBancoCriadoAntesDasMigracoes(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migracoes, err := CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migracoes[:6] {
+		if _, err := db.Exec(m.Up); err != nil {
+			t.Fatalf("script %03d: %v", m.Versao, err)
+		}
+	}
+
+	if _, err := AplicarMigracoes(db, migracoes); !errors.Is(err, ErrBancoSemVersoes) {
+		t.Fatalf("banco sem versões: err = %v", err)
+	}
+	marcadas, err := MarcarMigracoes(db, migracoes, 6)
+	if err != nil || len(marcadas) != 6 {
+		t.Fatalf("MarcarMigracoes = %d, %v", len(marcadas), err)
+	}
+	novas, err := AplicarMigracoes(db, migracoes)
+	if err != nil {
+		t.Fatalf("AplicarMigracoes após baseline: %v", err)
+	}
+	if len(novas) != len(migracoes)-6 || novas[0].Versao != 7 {
+		t.Errorf("aplicadas %d migrações a partir de %d", len(novas), novas[0].Versao)
+	}
+}
+
+// Produtos que repetem código de barras param a 018 com a lista de códigos e
+// produtos, sem perder nenhum código; resolvidos, a migração segue
+func TestCodigoBarrasRepetidoParaMigracao(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migracoes, err := CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AplicarMigracoes(db, migracoes[:17]); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO fornecedores (id, nome) VALUES (1, 'F');
+		INSERT INTO produtos (id, nome, fornecedor_id, codigo_fornecedor, codigo_barras) VALUES
+			(1, 'A', 1, 'A', '7891000100103'), (2, 'B', 1, 'B', '7896004000015'),
+			(3, 'C', 1, 'C', '7891000100103'), (4, 'D', 1, 'D', ' '), (5, 'E', 1, 'E', '7896004000015'),
+			(6, 'F', 1, 'F', '7891000100103'), (7, 'G', 1, 'G', '7898357410015')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = AplicarMigracoes(db, migracoes)
+	if err == nil {
+		t.Fatal("migração aplicada com códigos de barras repetidos")
+	}
+	for _, trecho := range []string{"7891000100103 (produtos 1, 3, 6)", "7896004000015 (produtos 2, 5)"} {
+		if !strings.Contains(err.Error(), trecho) {
+			t.Errorf("erro %q não aponta %q", err, trecho)
+		}
+	}
+	if strings.Contains(err.Error(), "7898357410015") {
+		t.Errorf("erro %q aponta código que não se repete", err)
+	}
+	var comCodigo int
+	if err := db.QueryRow("SELECT COUNT(*) FROM produtos WHERE codigo_barras IS NOT NULL").Scan(&comCodigo); err != nil || comCodigo != 7 {
+		t.Errorf("produtos com código de barras = %d, err %v; a migração não pode apagar códigos", comCodigo, err)
+	}
+
+	if _, err := db.Exec("UPDATE produtos SET codigo_barras = NULL WHERE id IN (3, 5, 6)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatalf("AplicarMigracoes após resolver os repetidos: %v", err)
+	}
+}
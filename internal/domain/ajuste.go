@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MotivoAjuste classifica um ajuste manual de estoque
+type MotivoAjuste string
+
+const (
+	AjustePerda      MotivoAjuste = "PERDA"
+	AjusteQuebra     MotivoAjuste = "QUEBRA"
+	AjusteFurto      MotivoAjuste = "FURTO"
+	AjusteVencimento MotivoAjuste = "VENCIMENTO"
+	// AjusteCorrecao acerta erro de lançamento e é o único que aceita entrada
+	AjusteCorrecao MotivoAjuste = "CORRECAO"
+)
+
+// Quebra indica motivo que representa perda de mercadoria
+func (m MotivoAjuste) Quebra() bool {
+	return m == AjustePerda || m == AjusteQuebra || m == AjusteFurto || m == AjusteVencimento
+}
+
+// AjusteEstoque é uma alteração manual do estoque de um produto. Quantidade
+// é a diferença a aplicar: negativa tira unidades, positiva acrescenta.
+// LoteID aplica o ajuste a um lote do depósito, ex.: baixar um lote vencido;
+// sem ele, saídas consomem os lotes pela validade mais próxima.
+type AjusteEstoque struct {
+	ProdutoID  int64        `json:"produto_id"`
+	DepositoID int64        `json:"deposito_id,omitempty"` // padrão: DepositoPrincipal
+	LoteID     int64        `json:"lote_id,omitempty"`
+	Quantidade int64        `json:"quantidade"`
+	Motivo     MotivoAjuste `json:"motivo"`
+	Observacao string       `json:"observacao,omitempty"`
+}
+
+// Validate exige motivo conhecido e quantidade diferente de zero; perdas,
+// quebras, furtos e vencimentos só tiram do estoque
+func (a AjusteEstoque) Validate() error {
+	if !a.Motivo.Quebra() && a.Motivo != AjusteCorrecao {
+		return fmt.Errorf("motivo deve ser %s, %s, %s, %s ou %s",
+			AjustePerda, AjusteQuebra, AjusteFurto, AjusteVencimento, AjusteCorrecao)
+	}
+	if a.Quantidade == 0 {
+		return errors.New("quantidade do ajuste não pode ser zero")
+	}
+	if a.Motivo.Quebra() && a.Quantidade > 0 {
+		return fmt.Errorf("ajuste por %s deve ter quantidade negativa", a.Motivo)
+	}
+	return nil
+}
+
+// ResumoAjuste soma os ajustes de um motivo; Valor é a quantidade pelo custo
+// médio atual de cada produto, negativo nas saídas
+type ResumoAjuste struct {
+	Motivo     MotivoAjuste `json:"motivo"`
+	Ajustes    int          `json:"ajustes"`
+	Quantidade int64        `json:"quantidade"`
+	Valor      Decimal      `json:"valor"`
+}
+
+// RelatorioAjustes resume os ajustes manuais de um período por motivo. Quebra
+// soma só as perdas de mercadoria, sem as correções.
+type RelatorioAjustes struct {
+	DataInicio       string         `json:"data_inicio,omitempty"`
+	DataFim          string         `json:"data_fim,omitempty"`
+	Motivos          []ResumoAjuste `json:"motivos"`
+	QuantidadeQuebra int64          `json:"quantidade_quebra"`
+	ValorQuebra      Decimal        `json:"valor_quebra"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "testing"
+
+func TestAjusteEstoqueValidate(t *testing.T) {
+	casos := []struct {
+		ajuste AjusteEstoque
+		valido bool
+	}{
+		{AjusteEstoque{Quantidade: -2, Motivo: AjusteQuebra}, true},
+		{AjusteEstoque{Quantidade: -1, Motivo: AjusteVencimento}, true},
+		{AjusteEstoque{Quantidade: 3, Motivo: AjusteCorrecao}, true},
+		{AjusteEstoque{Quantidade: -3, Motivo: AjusteCorrecao}, true},
+		{AjusteEstoque{Quantidade: 2, Motivo: AjusteFurto}, false},
+		{AjusteEstoque{Quantidade: 0, Motivo: AjustePerda}, false},
+		{AjusteEstoque{Quantidade: -1, Motivo: "SUMIU"}, false},
+		{AjusteEstoque{Quantidade: -1}, false},
+	}
+	for _, c := range casos {
+		if err := c.ajuste.Validate(); (err == nil) != c.valido {
+			t.Errorf("Validate(%+v) = %v", c.ajuste, err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MetodoAvaliacao define como o custo das saídas é apurado na avaliação do estoque
+type MetodoAvaliacao string
+
+const (
+	// AvaliacaoCustoMedio valoriza o estoque pelo custo médio ponderado das entradas
+	AvaliacaoCustoMedio MetodoAvaliacao = "CUSTO_MEDIO"
+	// AvaliacaoPEPS (primeiro a entrar, primeiro a sair) consome as camadas de
+	// compra mais antigas primeiro; o estoque fica com as mais recentes
+	AvaliacaoPEPS MetodoAvaliacao = "PEPS"
+)
+
+// Validate confere se o método é conhecido
+func (m MetodoAvaliacao) Validate() error {
+	if m != AvaliacaoCustoMedio && m != AvaliacaoPEPS {
+		return fmt.Errorf("método de avaliação deve ser %s ou %s", AvaliacaoCustoMedio, AvaliacaoPEPS)
+	}
+	return nil
+}
+
+// ProdutoAvaliacao identifica o produto avaliado. CustoPadrao, o custo
+// cadastrado, vale para entradas sem custo (saldo inicial, ajustes) quando o
+// razão ainda não tem custo para o produto.
+type ProdutoAvaliacao struct {
+	ProdutoID    int64
+	Nome         string
+	FornecedorID int64
+	Fornecedor   string
+	CustoPadrao  Decimal
+}
+
+// MovimentoCusteado é um movimento do razão com o custo unitário da compra
+// que o originou; Custo é nil nos demais movimentos
+type MovimentoCusteado struct {
+	ProdutoID  int64
+	Quantidade int64
+	Custo      *Decimal
+}
+
+// ItemAvaliacao é o estoque de um produto e seu valor
+type ItemAvaliacao struct {
+	ProdutoID     int64   `json:"produto_id"`
+	Nome          string  `json:"nome"`
+	FornecedorID  int64   `json:"fornecedor_id"`
+	Fornecedor    string  `json:"fornecedor"`
+	Quantidade    int64   `json:"quantidade"`
+	CustoUnitario Decimal `json:"custo_unitario"`
+	Valor         Decimal `json:"valor"`
+}
+
+// FornecedorAvaliacao soma o estoque avaliado dos produtos de um fornecedor
+type FornecedorAvaliacao struct {
+	FornecedorID int64   `json:"fornecedor_id"`
+	Nome         string  `json:"nome"`
+	Quantidade   int64   `json:"quantidade"`
+	Valor        Decimal `json:"valor"`
+}
+
+// AvaliacaoEstoque é o valor do estoque vendável numa data, por produto e por fornecedor
+type AvaliacaoEstoque struct {
+	Data         string                `json:"data"`
+	Metodo       MetodoAvaliacao       `json:"metodo"`
+	Produtos     []ItemAvaliacao       `json:"produtos"`
+	Fornecedores []FornecedorAvaliacao `json:"fornecedores"`
+	Total        Decimal               `json:"total"`
+}
+
+// camadaCusto é um lote de entrada ainda em estoque no método PEPS
+type camadaCusto struct {
+	quantidade int64
+	custo      Decimal
+}
+
+// AvaliarEstoque refaz o razão de cada produto, com os movimentos em ordem
+// cronológica até data, e valoriza o saldo pelo método. Entradas sem custo
+// (cancelamentos, devoluções, ajustes) entram pelo custo médio corrente ou,
+// no PEPS, pelo custo da camada mais recente. Produtos sem saldo ficam de fora.
+func AvaliarEstoque(data string, metodo MetodoAvaliacao, produtos []ProdutoAvaliacao, movimentos []MovimentoCusteado) AvaliacaoEstoque {
+	porProduto := make(map[int64][]MovimentoCusteado)
+	for _, m := range movimentos {
+		porProduto[m.ProdutoID] = append(porProduto[m.ProdutoID], m)
+	}
+
+	a := AvaliacaoEstoque{Data: data, Metodo: metodo, Produtos: []ItemAvaliacao{},
+		Fornecedores: []FornecedorAvaliacao{}, Total: DecimalFromInt(0)}
+	fornecedores := make(map[int64]*FornecedorAvaliacao)
+	for _, p := range produtos {
+		var quantidade int64
+		var valor Decimal
+		if metodo == AvaliacaoPEPS {
+			quantidade, valor = avaliarPEPS(p, porProduto[p.ProdutoID])
+		} else {
+			quantidade, valor = avaliarCustoMedio(p, porProduto[p.ProdutoID])
+		}
+		if quantidade <= 0 {
+			continue
+		}
+
+		a.Produtos = append(a.Produtos, ItemAvaliacao{
+			ProdutoID:     p.ProdutoID,
+			Nome:          p.Nome,
+			FornecedorID:  p.FornecedorID,
+			Fornecedor:    p.Fornecedor,
+			Quantidade:    quantidade,
+			CustoUnitario: valor.Quo(DecimalFromInt(quantidade)).RoundTo(2),
+			Valor:         valor,
+		})
+		f, ok := fornecedores[p.FornecedorID]
+		if !ok {
+			f = &FornecedorAvaliacao{FornecedorID: p.FornecedorID, Nome: p.Fornecedor, Valor: DecimalFromInt(0)}
+			fornecedores[p.FornecedorID] = f
+		}
+		f.Quantidade += quantidade
+		f.Valor = f.Valor.Add(valor)
+		a.Total = a.Total.Add(valor)
+	}
+
+	for _, f := range fornecedores {
+		a.Fornecedores = append(a.Fornecedores, *f)
+	}
+	sort.Slice(a.Fornecedores, func(i, j int) bool { return a.Fornecedores[i].Nome < a.Fornecedores[j].Nome })
+	return a
+}
+
+func avaliarCustoMedio(p ProdutoAvaliacao, movimentos []MovimentoCusteado) (int64, Decimal) {
+	var quantidade int64
+	medio := DecimalFromInt(0)
+	for _, m := range movimentos {
+		if m.Quantidade > 0 {
+			custo := medio
+			if m.Custo != nil {
+				custo = *m.Custo
+			} else if medio.Sign() == 0 {
+				custo = p.CustoPadrao
+			}
+			medio = CustoMedio(quantidade, medio, m.Quantidade, custo)
+		}
+		quantidade += m.Quantidade
+	}
+	return quantidade, medio.MulInt(quantidade).RoundTo(2)
+}
+
+func avaliarPEPS(p ProdutoAvaliacao, movimentos []MovimentoCusteado) (int64, Decimal) {
+	var camadas []camadaCusto
+	for _, m := range movimentos {
+		if m.Quantidade > 0 {
+			custo := p.CustoPadrao
+			if m.Custo != nil {
+				custo = *m.Custo
+			} else if len(camadas) > 0 {
+				custo = camadas[len(camadas)-1].custo
+			}
+			camadas = append(camadas, camadaCusto{m.Quantidade, custo})
+			continue
+		}
+		saida := -m.Quantidade
+		for saida > 0 && len(camadas) > 0 {
+			consumo := min(saida, camadas[0].quantidade)
+			camadas[0].quantidade -= consumo
+			saida -= consumo
+			if camadas[0].quantidade == 0 {
+				camadas = camadas[1:]
+			}
+		}
+	}
+
+	var quantidade int64
+	valor := DecimalFromInt(0)
+	for _, c := range camadas {
+		quantidade += c.quantidade
+		valor = valor.Add(c.custo.MulInt(c.quantidade))
+	}
+	return quantidade, valor.RoundTo(2)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "testing"
+
+func TestAvaliarEstoque(t *testing.T) {
+	custo := func(s string) *Decimal { d := dec(s); return &d }
+	produtos := []ProdutoAvaliacao{
+		{ProdutoID: 1, Nome: "Caneta", FornecedorID: 2, Fornecedor: "Papelaria", CustoPadrao: dec("1.00")},
+		{ProdutoID: 2, Nome: "Lápis", FornecedorID: 1, Fornecedor: "Atacado", CustoPadrao: dec("0.50")},
+		{ProdutoID: 3, Nome: "Borracha", FornecedorID: 1, Fornecedor: "Atacado", CustoPadrao: dec("0.30")},
+	}
+	movimentos := []MovimentoCusteado{
+		{ProdutoID: 1, Quantidade: 10},                       // saldo inicial ao custo padrão
+		{ProdutoID: 1, Quantidade: 10, Custo: custo("2.00")}, // compra
+		{ProdutoID: 1, Quantidade: -12},                      // venda
+		{ProdutoID: 1, Quantidade: 2},                        // devolução sem custo
+		{ProdutoID: 2, Quantidade: 4, Custo: custo("0.60")},
+		{ProdutoID: 3, Quantidade: 5},
+		{ProdutoID: 3, Quantidade: -5},
+	}
+
+	medio := AvaliarEstoque("2026-10-17", AvaliacaoCustoMedio, produtos, movimentos)
+	if len(medio.Produtos) != 2 {
+		t.Fatalf("produtos avaliados = %+v", medio.Produtos)
+	}
+	caneta := medio.Produtos[0]
+	if caneta.Quantidade != 10 || !caneta.CustoUnitario.Equal(dec("1.50")) || !caneta.Valor.Equal(dec("15.00")) {
+		t.Errorf("caneta pelo custo médio = %+v", caneta)
+	}
+	if !medio.Total.Equal(dec("17.40")) {
+		t.Errorf("total pelo custo médio = %s", medio.Total)
+	}
+	if len(medio.Fornecedores) != 2 || medio.Fornecedores[0].Nome != "Atacado" ||
+		!medio.Fornecedores[0].Valor.Equal(dec("2.40")) || medio.Fornecedores[1].Quantidade != 10 {
+		t.Errorf("fornecedores = %+v", medio.Fornecedores)
+	}
+
+	// no PEPS a venda consome as 10 do saldo inicial e 2 da compra; a
+	// devolução volta pelo custo da última camada
+	peps := AvaliarEstoque("2026-10-17", AvaliacaoPEPS, produtos, movimentos)
+	caneta = peps.Produtos[0]
+	if caneta.Quantidade != 10 || !caneta.Valor.Equal(dec("20.00")) {
+		t.Errorf("caneta pelo PEPS = %+v", caneta)
+	}
+	if !peps.Total.Equal(dec("22.40")) {
+		t.Errorf("total pelo PEPS = %s", peps.Total)
+	}
+
+	if err := MetodoAvaliacao("UEPS").Validate(); err == nil {
+		t.Error("método desconhecido aceito")
+	}
+}
@@ -1,8 +1,83 @@
 package domain
 
+import (
+	"errors"
+	"fmt"
+)
+
 type Cliente struct {
-	ID           int64  `json:"id"`
-	Nome         string `json:"nome"`
-	Telefone     string `json:"telefone"`
-	DataCadastro string `json:"data_cadastro"` // YYYY-MM-DD
+	ID            int64    `json:"id"`
+	Nome          string   `json:"nome"`
+	Telefone      string   `json:"telefone"`
+	DataCadastro  string   `json:"data_cadastro"` // YYYY-MM-DD
+	LimiteCredito *Decimal `json:"limite_credito,omitempty"`
+	Bloqueado     bool     `json:"bloqueado"`
+}
+
+// SituacaoCredito mostra quanto o cliente ainda pode comprar a prazo.
+// Limite e Disponivel nulos significam crédito sem limite.
+type SituacaoCredito struct {
+	ClienteID   int64    `json:"cliente_id"`
+	Limite      *Decimal `json:"limite_credito"`
+	Bloqueado   bool     `json:"bloqueado"`
+	SaldoAberto Decimal  `json:"saldo_aberto"`
+	Disponivel  *Decimal `json:"disponivel"`
+}
+
+// ErrLiberacaoNaoAutorizada indica liberação de crédito pedida por perfil que
+// não pode autorizá-la
+var ErrLiberacaoNaoAutorizada = errors.New("perfil sem permissão para liberar crédito")
+
+// CreditoInsuficienteError recusa uma venda a prazo de cliente bloqueado ou
+// que passaria do limite de crédito
+type CreditoInsuficienteError struct {
+	Situacao   SituacaoCredito `json:"situacao"`
+	ValorPrazo Decimal         `json:"valor_a_prazo"`
+}
+
+func (e *CreditoInsuficienteError) Error() string {
+	if e.Situacao.Bloqueado {
+		return fmt.Sprintf("cliente %d bloqueado para vendas a prazo", e.Situacao.ClienteID)
+	}
+	return fmt.Sprintf("limite de crédito do cliente %d excedido: disponível %s, a prazo %s",
+		e.Situacao.ClienteID, e.Situacao.Disponivel, e.ValorPrazo)
+}
+
+// ValidarCredito confere o limite informado no cadastro
+func (c *Cliente) ValidarCredito() error {
+	if c.LimiteCredito != nil && (c.LimiteCredito.Decimal == nil || c.LimiteCredito.Sign() < 0) {
+		return errors.New("limite de crédito não pode ser negativo")
+	}
+	return nil
+}
+
+// SituacaoCredito calcula o crédito disponível dado o saldo em aberto
+func (c *Cliente) SituacaoCredito(saldoAberto Decimal) SituacaoCredito {
+	s := SituacaoCredito{
+		ClienteID:   c.ID,
+		Limite:      c.LimiteCredito,
+		Bloqueado:   c.Bloqueado,
+		SaldoAberto: saldoAberto,
+	}
+	if c.LimiteCredito != nil {
+		disponivel := c.LimiteCredito.Sub(saldoAberto)
+		if disponivel.Sign() < 0 {
+			disponivel = DecimalFromInt(0)
+		}
+		s.Disponivel = &disponivel
+	}
+	return s
+}
+
+// AvaliarCredito decide se o cliente pode levar valorPrazo a prazo, tendo
+// saldoAberto de outras vendas. Retorna *CreditoInsuficienteError quando não pode.
+func (c *Cliente) AvaliarCredito(saldoAberto, valorPrazo Decimal) error {
+	if valorPrazo.Sign() <= 0 {
+		return nil
+	}
+	s := c.SituacaoCredito(saldoAberto)
+	if s.Bloqueado || (s.Limite != nil && saldoAberto.Add(valorPrazo).Compare(*s.Limite) > 0) {
+		return &CreditoInsuficienteError{Situacao: s, ValorPrazo: valorPrazo}
+	}
+	return nil
 }
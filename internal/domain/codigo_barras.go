@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PrefixoCodigoInterno inicia os EAN-13 gerados pela loja. Prefixos 200 a 299
+// são reservados pela GS1 para uso interno e nunca saem de fábrica.
+const PrefixoCodigoInterno = "200"
+
+// ErrCodigoBarrasInvalido indica código que não é um GTIN válido
+var ErrCodigoBarrasInvalido = errors.New("código de barras inválido")
+
+// ErrCodigoBarrasReservado indica código manual na faixa dos gerados pela loja,
+// que poderia colidir com o CodigoBarrasInterno de outro produto
+var ErrCodigoBarrasReservado = errors.New("códigos EAN-13 iniciados em 2 são reservados aos gerados pela loja")
+
+// GTINValido confere tamanho (EAN-8, UPC-A, EAN-13 ou GTIN-14), se só há
+// dígitos e o dígito verificador
+func GTINValido(codigo string) bool {
+	switch len(codigo) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	if somenteDigitos(codigo) != codigo {
+		return false
+	}
+	return DigitoGTIN(codigo[:len(codigo)-1]) == codigo[len(codigo)-1]
+}
+
+// DigitoGTIN calcula o dígito verificador de corpo, um GTIN sem o último
+// dígito: pesos 3 e 1 alternados a partir da direita, módulo 10
+func DigitoGTIN(corpo string) byte {
+	soma := 0
+	for i := 0; i < len(corpo); i++ {
+		peso := 1
+		if (len(corpo)-i)%2 == 1 {
+			peso = 3
+		}
+		soma += int(corpo[i]-'0') * peso
+	}
+	return byte('0' + (10-soma%10)%10)
+}
+
+// CodigoBarrasInterno monta o EAN-13 interno de sequencia (normalmente o id
+// do produto): PrefixoCodigoInterno, a sequência em 9 dígitos e o verificador
+func CodigoBarrasInterno(sequencia int64) (string, error) {
+	if sequencia <= 0 || sequencia > 999_999_999 {
+		return "", fmt.Errorf("sequência fora da faixa de códigos internos: %d", sequencia)
+	}
+	corpo := fmt.Sprintf("%s%09d", PrefixoCodigoInterno, sequencia)
+	return corpo + string(DigitoGTIN(corpo)), nil
+}
+
+// CodigoBarrasInternoDaLoja indica EAN-13 na faixa reservada para uso interno (2xx)
+func CodigoBarrasInternoDaLoja(codigo string) bool {
+	return len(codigo) == 13 && strings.HasPrefix(codigo, "2")
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGTINValido(t *testing.T) {
+	casos := []struct {
+		codigo string
+		valido bool
+	}{
+		{"4006381333931", true},  // EAN-13
+		{"96385074", true},       // EAN-8
+		{"036000291452", true},   // UPC-A
+		{"10614141000415", true}, // GTIN-14
+		{"4006381333932", false}, // dígito verificador errado
+		{"400638133393", false},  // UPC-A com verificador errado
+		{"40063813339a", false},
+		{"1234567", false},
+		{"", false},
+	}
+	for _, c := range casos {
+		if got := GTINValido(c.codigo); got != c.valido {
+			t.Errorf("GTINValido(%q) = %v", c.codigo, got)
+		}
+	}
+}
+
+func TestCodigoBarrasInterno(t *testing.T) {
+	codigo, err := CodigoBarrasInterno(42)
+	if err != nil || codigo != "2000000000428" || !GTINValido(codigo) || !CodigoBarrasInternoDaLoja(codigo) {
+		t.Errorf("CodigoBarrasInterno(42) = %q, %v", codigo, err)
+	}
+	if _, err := CodigoBarrasInterno(1_000_000_000); err == nil {
+		t.Error("sequência acima de 9 dígitos aceita")
+	}
+	if CodigoBarrasInternoDaLoja("4006381333931") {
+		t.Error("EAN de fábrica tratado como interno")
+	}
+
+	var p Produto
+	if err := p.SetCodigoBarras(" 4006381 333931 "); err != nil || p.CodigoBarras != "4006381333931" {
+		t.Errorf("SetCodigoBarras = %q, %v", p.CodigoBarras, err)
+	}
+	if err := p.SetCodigoBarras("4006381333932"); !errors.Is(err, ErrCodigoBarrasInvalido) {
+		t.Errorf("SetCodigoBarras(inválido) = %v", err)
+	}
+	if err := p.SetCodigoBarras(""); err != nil || p.CodigoBarras != "" {
+		t.Errorf("SetCodigoBarras(vazio) = %q, %v", p.CodigoBarras, err)
+	}
+
+	// a faixa interna é da geração automática: à mão colidiria com outro produto
+	if err := p.SetCodigoBarras(codigo); !errors.Is(err, ErrCodigoBarrasReservado) || p.CodigoBarras != "" {
+		t.Errorf("SetCodigoBarras(interno) = %q, %v", p.CodigoBarras, err)
+	}
+	p.CodigoBarras = codigo
+	if err := p.SetCodigoBarras(codigo); err != nil {
+		t.Errorf("reenviar o próprio código interno: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type StatusCompra string
+
+const (
+	StatusCompraPendente  StatusCompra = "PENDENTE"
+	StatusCompraRecebida  StatusCompra = "RECEBIDA"
+	StatusCompraCancelada StatusCompra = "CANCELADA"
+)
+
+// Compra representa um pedido de compra feito a um fornecedor
+type Compra struct {
+	ID                 int64        `json:"id"`
+	FornecedorID       int64        `json:"fornecedor_id"`
+	DepositoID         int64        `json:"deposito_id"` // onde a mercadoria é recebida; padrão DepositoPrincipal
+	Data               time.Time    `json:"data"`
+	Total              Decimal      `json:"total"`
+	Status             StatusCompra `json:"status"`
+	DataRecebimento    *time.Time   `json:"data_recebimento,omitempty"`
+	CanceladaEm        *time.Time   `json:"cancelada_em,omitempty"`
+	MotivoCancelamento string       `json:"motivo_cancelamento,omitempty"`
+	Items              []CompraItem `json:"items"`
+}
+
+// CompraItem representa um produto do pedido de compra; PrecoUnitario é o custo pago.
+// Lotes traz os lotes informados no recebimento, com a quantidade recebida em cada um.
+type CompraItem struct {
+	CompraID      int64   `json:"compra_id"`
+	ProdutoID     int64   `json:"produto_id"`
+	Quantidade    int64   `json:"quantidade"`
+	PrecoUnitario Decimal `json:"preco_unitario"`
+	Total         Decimal `json:"total"`
+	Lotes         []Lote  `json:"lotes,omitempty"`
+}
+
+// Validate confere fornecedor e itens do pedido
+func (c *Compra) Validate() error {
+	if c.FornecedorID <= 0 {
+		return errors.New("fornecedor inválido")
+	}
+	if len(c.Items) == 0 {
+		return errors.New("a compra precisa de ao menos um item")
+	}
+	vistos := make(map[int64]bool, len(c.Items))
+	for _, item := range c.Items {
+		if item.ProdutoID <= 0 {
+			return errors.New("produto inválido")
+		}
+		if vistos[item.ProdutoID] {
+			return fmt.Errorf("produto %d repetido na compra", item.ProdutoID)
+		}
+		vistos[item.ProdutoID] = true
+		if item.Quantidade <= 0 {
+			return fmt.Errorf("quantidade do produto %d deve ser positiva", item.ProdutoID)
+		}
+		if item.PrecoUnitario.Decimal == nil || item.PrecoUnitario.Sign() < 0 {
+			return fmt.Errorf("custo do produto %d não pode ser negativo", item.ProdutoID)
+		}
+	}
+	return nil
+}
+
+// CalcularTotal preenche o total de cada item e o total da compra
+func (c *Compra) CalcularTotal() {
+	total := DecimalFromInt(0)
+	for i := range c.Items {
+		c.Items[i].Total = c.Items[i].PrecoUnitario.MulInt(c.Items[i].Quantidade).RoundTo(2)
+		total = total.Add(c.Items[i].Total)
+	}
+	c.Total = total
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/cockroachdb/apd/v3"
 )
@@ -13,6 +14,87 @@ type Decimal struct {
 	*apd.Decimal
 }
 
+// decimalCtx é o contexto usado em toda a aritmética monetária do domínio
+var decimalCtx = apd.BaseContext.WithPrecision(34)
+
+// DecimalFromInt cria um Decimal a partir de um inteiro
+func DecimalFromInt(n int64) Decimal {
+	return Decimal{apd.New(n, 0)}
+}
+
+// ParseDecimal converte uma string como "12.50" em Decimal
+func ParseDecimal(s string) (Decimal, error) {
+	var dec apd.Decimal
+	if _, _, err := dec.SetString(s); err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{&dec}, nil
+}
+
+// orZero trata um Decimal nulo como zero nas operações
+func (d Decimal) orZero() *apd.Decimal {
+	if d.Decimal == nil {
+		return apd.New(0, 0)
+	}
+	return d.Decimal
+}
+
+// Add retorna d + o
+func (d Decimal) Add(o Decimal) Decimal {
+	var r apd.Decimal
+	decimalCtx.Add(&r, d.orZero(), o.orZero())
+	return Decimal{&r}
+}
+
+// Sub retorna d - o
+func (d Decimal) Sub(o Decimal) Decimal {
+	var r apd.Decimal
+	decimalCtx.Sub(&r, d.orZero(), o.orZero())
+	return Decimal{&r}
+}
+
+// Mul retorna d * o
+func (d Decimal) Mul(o Decimal) Decimal {
+	var r apd.Decimal
+	decimalCtx.Mul(&r, d.orZero(), o.orZero())
+	return Decimal{&r}
+}
+
+// MulInt retorna d * n
+func (d Decimal) MulInt(n int64) Decimal {
+	return d.Mul(DecimalFromInt(n))
+}
+
+// Quo retorna d / o; divisão por zero resulta em zero
+func (d Decimal) Quo(o Decimal) Decimal {
+	if o.orZero().IsZero() {
+		return DecimalFromInt(0)
+	}
+	var r apd.Decimal
+	decimalCtx.Quo(&r, d.orZero(), o.orZero())
+	return Decimal{&r}
+}
+
+// RoundTo arredonda (meio para cima) para a quantidade de casas decimais informada
+func (d Decimal) RoundTo(places int32) Decimal {
+	var r apd.Decimal
+	ctx := decimalCtx.WithPrecision(34)
+	ctx.Rounding = apd.RoundHalfUp
+	ctx.Quantize(&r, d.orZero(), -places)
+	return Decimal{&r}
+}
+
+// Equal compara os valores numéricos, ignorando a escala ("10.0" == "10")
+func (d Decimal) Equal(o Decimal) bool {
+	return d.orZero().Cmp(o.orZero()) == 0
+}
+
+// Compare retorna -1, 0 ou 1 conforme d seja menor, igual ou maior que o;
+// Decimal nulo vale zero
+func (d Decimal) Compare(o Decimal) int {
+	return d.orZero().Cmp(o.orZero())
+}
+
 func (d Decimal) MarshalJSON() ([]byte, error) {
 	if d.Decimal == nil {
 		return []byte("null"), nil
@@ -64,6 +146,10 @@ func (d *Decimal) Scan(value interface{}) error {
 		str = v
 	case []byte:
 		str = string(v)
+	case float64:
+		str = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		str = strconv.FormatInt(v, 10)
 	default:
 		return errors.New("tipo incompatível para Decimal.Scan")
 	}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DepositoPrincipal é a loja, usada por vendas, compras e ajustes que não
+// informam depósito
+const DepositoPrincipal int64 = 1
+
+// ErrDepositoInativo indica documento que movimenta um depósito desativado
+var ErrDepositoInativo = errors.New("depósito inativo")
+
+// Deposito é um local onde o estoque fica guardado: a loja, o depósito dos
+// fundos, uma filial. O estoque total do produto é a soma dos depósitos.
+type Deposito struct {
+	ID    int64  `json:"id"`
+	Nome  string `json:"nome"`
+	Ativo bool   `json:"ativo"`
+}
+
+// Validate confere o nome do depósito
+func (d *Deposito) Validate() error {
+	d.Nome = strings.TrimSpace(d.Nome)
+	if d.Nome == "" {
+		return errors.New("nome do depósito é obrigatório")
+	}
+	return nil
+}
+
+// EstoqueDeposito é a quantidade de um produto guardada num depósito
+type EstoqueDeposito struct {
+	DepositoID int64  `json:"deposito_id"`
+	Deposito   string `json:"deposito"`
+	ProdutoID  int64  `json:"produto_id"`
+	Produto    string `json:"produto"`
+	Quantidade int64  `json:"quantidade"`
+}
+
+// Transferencia move mercadoria de um depósito para outro. Cada item vira um
+// movimento TRANSFERENCIA de saída na origem e outro de entrada no destino,
+// gravados juntos ou não gravados.
+type Transferencia struct {
+	ID         int64               `json:"id"`
+	OrigemID   int64               `json:"origem_id"`
+	DestinoID  int64               `json:"destino_id"`
+	Data       time.Time           `json:"data"`
+	Observacao string              `json:"observacao,omitempty"`
+	Usuario    string              `json:"usuario,omitempty"`
+	Itens      []ItemTransferencia `json:"itens"`
+}
+
+// ItemTransferencia é a quantidade transferida de um produto
+type ItemTransferencia struct {
+	ProdutoID  int64  `json:"produto_id"`
+	Nome       string `json:"nome,omitempty"`
+	Quantidade int64  `json:"quantidade"`
+}
+
+// Validate confere os depósitos e os itens da transferência
+func (t *Transferencia) Validate() error {
+	if t.OrigemID <= 0 || t.DestinoID <= 0 {
+		return errors.New("informe origem_id e destino_id")
+	}
+	if t.OrigemID == t.DestinoID {
+		return errors.New("origem e destino devem ser depósitos diferentes")
+	}
+	if len(t.Itens) == 0 {
+		return errors.New("a transferência precisa de ao menos um item")
+	}
+	vistos := make(map[int64]bool, len(t.Itens))
+	for _, item := range t.Itens {
+		if item.ProdutoID <= 0 {
+			return errors.New("produto inválido")
+		}
+		if item.Quantidade <= 0 {
+			return fmt.Errorf("produto %d: quantidade deve ser positiva", item.ProdutoID)
+		}
+		if vistos[item.ProdutoID] {
+			return fmt.Errorf("produto %d repetido na transferência", item.ProdutoID)
+		}
+		vistos[item.ProdutoID] = true
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "testing"
+
+func TestDepositoValidate(t *testing.T) {
+	d := Deposito{Nome: "  Fundos  "}
+	if err := d.Validate(); err != nil || d.Nome != "Fundos" {
+		t.Errorf("Validate = %v, nome %q", err, d.Nome)
+	}
+	if err := (&Deposito{Nome: " "}).Validate(); err == nil {
+		t.Error("depósito sem nome foi aceito")
+	}
+}
+
+func TestTransferenciaValidate(t *testing.T) {
+	item := []ItemTransferencia{{ProdutoID: 1, Quantidade: 2}}
+	casos := []struct {
+		nome   string
+		t      Transferencia
+		valido bool
+	}{
+		{"válida", Transferencia{OrigemID: 2, DestinoID: 1, Itens: item}, true},
+		{"sem origem", Transferencia{DestinoID: 1, Itens: item}, false},
+		{"mesmo depósito", Transferencia{OrigemID: 1, DestinoID: 1, Itens: item}, false},
+		{"sem itens", Transferencia{OrigemID: 2, DestinoID: 1}, false},
+		{"quantidade zero", Transferencia{OrigemID: 2, DestinoID: 1,
+			Itens: []ItemTransferencia{{ProdutoID: 1}}}, false},
+		{"produto repetido", Transferencia{OrigemID: 2, DestinoID: 1,
+			Itens: []ItemTransferencia{{ProdutoID: 1, Quantidade: 1}, {ProdutoID: 1, Quantidade: 3}}}, false},
+	}
+	for _, c := range casos {
+		if err := c.t.Validate(); (err == nil) != c.valido {
+			t.Errorf("%s: Validate = %v", c.nome, err)
+		}
+	}
+}
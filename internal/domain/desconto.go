@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TipoDesconto diz se o valor do desconto é um percentual ou um valor fixo
+type TipoDesconto string
+
+const (
+	DescontoPercentual TipoDesconto = "PERCENTUAL"
+	DescontoValor      TipoDesconto = "VALOR"
+)
+
+// Desconto concedido num item ou na venda inteira
+type Desconto struct {
+	Tipo  TipoDesconto `json:"tipo"`
+	Valor Decimal      `json:"valor"`
+}
+
+// ErrDescontoMaiorQueValor indica desconto fixo acima do valor sobre o qual incide
+var ErrDescontoMaiorQueValor = errors.New("desconto maior que o valor")
+
+// DescontoAcimaDoLimiteError indica desconto acima do máximo permitido ao
+// perfil do usuário; ProdutoID zero refere-se ao desconto total da venda
+type DescontoAcimaDoLimiteError struct {
+	Perfil     string  `json:"perfil"`
+	ProdutoID  int64   `json:"produto_id,omitempty"`
+	Percentual Decimal `json:"percentual"`
+	Maximo     Decimal `json:"maximo"`
+}
+
+func (e *DescontoAcimaDoLimiteError) Error() string {
+	alvo := "da venda"
+	if e.ProdutoID != 0 {
+		alvo = fmt.Sprintf("do produto %d", e.ProdutoID)
+	}
+	return fmt.Sprintf("desconto %s de %s%% excede o máximo de %s%% do perfil %q",
+		alvo, e.Percentual, e.Maximo, e.Perfil)
+}
+
+// Validate confere tipo e valor do desconto
+func (d *Desconto) Validate() error {
+	if d.Tipo != DescontoPercentual && d.Tipo != DescontoValor {
+		return fmt.Errorf("tipo de desconto deve ser %s ou %s", DescontoPercentual, DescontoValor)
+	}
+	if d.Valor.Decimal == nil || d.Valor.Sign() < 0 {
+		return errors.New("valor do desconto não pode ser negativo")
+	}
+	if d.Tipo == DescontoPercentual && d.Valor.Compare(DecimalFromInt(100)) > 0 {
+		return errors.New("desconto percentual não pode passar de 100")
+	}
+	return nil
+}
+
+// Calcular devolve o valor do desconto sobre base, arredondado em centavos
+func (d *Desconto) Calcular(base Decimal) (Decimal, error) {
+	if d == nil {
+		return DecimalFromInt(0), nil
+	}
+	if d.Tipo == DescontoPercentual {
+		return base.Mul(d.Valor).Quo(DecimalFromInt(100)).RoundTo(2), nil
+	}
+	valor := d.Valor.RoundTo(2)
+	if valor.Compare(base) > 0 {
+		return Decimal{}, fmt.Errorf("%w: desconto %s sobre %s", ErrDescontoMaiorQueValor, valor, base)
+	}
+	return valor, nil
+}
+
+// percentualDe devolve quanto parte representa de total, em pontos percentuais
+// com duas casas
+func percentualDe(parte, total Decimal) Decimal {
+	return parte.MulInt(100).Quo(total).RoundTo(2)
+}
+
+// ratearDesconto distribui desconto entre as bases proporcionalmente; o
+// último item com base positiva recebe a sobra do arredondamento
+func ratearDesconto(desconto Decimal, bases []Decimal) []Decimal {
+	partes := make([]Decimal, len(bases))
+	soma := DecimalFromInt(0)
+	ultimo := -1
+	for i, b := range bases {
+		partes[i] = DecimalFromInt(0)
+		soma = soma.Add(b)
+		if b.Sign() > 0 {
+			ultimo = i
+		}
+	}
+	if ultimo < 0 || desconto.Sign() == 0 {
+		return partes
+	}
+	restante := desconto
+	for i, b := range bases {
+		if b.Sign() <= 0 {
+			continue
+		}
+		if i == ultimo {
+			partes[i] = restante
+			break
+		}
+		partes[i] = desconto.Mul(b).Quo(soma).RoundTo(2)
+		restante = restante.Sub(partes[i])
+	}
+	return partes
+}
+
+// LimiteDesconto é o desconto máximo, em percentual, que um perfil concede
+type LimiteDesconto struct {
+	Perfil           string  `json:"perfil"`
+	PercentualMaximo Decimal `json:"percentual_maximo"`
+}
+
+// Validate confere perfil e percentual entre 0 e 100
+func (l *LimiteDesconto) Validate() error {
+	if l.Perfil == "" {
+		return errors.New("perfil é obrigatório")
+	}
+	if l.PercentualMaximo.Decimal == nil || l.PercentualMaximo.Sign() < 0 ||
+		l.PercentualMaximo.Compare(DecimalFromInt(100)) > 0 {
+		return errors.New("percentual máximo deve estar entre 0 e 100")
+	}
+	return nil
+}
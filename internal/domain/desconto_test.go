@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCalcularTotaisComDesconto(t *testing.T) {
+	precos := map[int64]Decimal{1: dec("10.00"), 2: dec("3.33"), 3: dec("20.00")}
+	s := Sale{
+		Desconto: &Desconto{Tipo: DescontoValor, Valor: dec("10")},
+		Items: []SaleItem{
+			{ProductID: 1, Quantity: 2, Desconto: &Desconto{Tipo: DescontoPercentual, Valor: dec("10")}},
+			{ProductID: 2, Quantity: 3},
+			{ProductID: 3, Quantity: 1},
+		},
+	}
+	if err := s.CalcularTotais(precos); err != nil {
+		t.Fatal(err)
+	}
+
+	// bruto 20.00 + 9.99 + 20.00; item 1 tem 2.00 de desconto próprio e os
+	// 10.00 da venda são rateados sobre os líquidos 18.00, 9.99 e 20.00
+	if !s.Bruto.Equal(dec("49.99")) || !s.ValorDesconto.Equal(dec("12.00")) || !s.Total.Equal(dec("37.99")) {
+		t.Errorf("venda: bruto %s desconto %s total %s", s.Bruto, s.ValorDesconto, s.Total)
+	}
+	esperados := []struct{ desconto, total string }{
+		{"5.75", "14.25"}, // 2.00 + 10*18/47.99 = 3.75
+		{"2.08", "7.91"},  // 10*9.99/47.99
+		{"4.17", "15.83"}, // sobra do rateio
+	}
+	for i, e := range esperados {
+		item := s.Items[i]
+		if !item.ValorDesconto.Equal(dec(e.desconto)) || !item.Total.Equal(dec(e.total)) {
+			t.Errorf("item %d: desconto %s total %s, esperado %s e %s",
+				item.ProductID, item.ValorDesconto, item.Total, e.desconto, e.total)
+		}
+	}
+
+	s = Sale{Items: []SaleItem{{ProductID: 1, Quantity: 1, Desconto: &Desconto{Tipo: DescontoValor, Valor: dec("11")}}}}
+	if err := s.CalcularTotais(precos); !errors.Is(err, ErrDescontoMaiorQueValor) {
+		t.Errorf("desconto acima do valor do item: err = %v", err)
+	}
+}
+
+func TestConferirDesconto(t *testing.T) {
+	precos := map[int64]Decimal{1: dec("10.00"), 2: dec("10.00")}
+	s := Sale{
+		Desconto: &Desconto{Tipo: DescontoPercentual, Valor: dec("5")},
+		Items: []SaleItem{
+			{ProductID: 1, Quantity: 1, Desconto: &Desconto{Tipo: DescontoPercentual, Valor: dec("8")}},
+			{ProductID: 2, Quantity: 1},
+		},
+	}
+	if err := s.CalcularTotais(precos); err != nil {
+		t.Fatal(err)
+	}
+	// item com 8%, venda com 0.80 + 5% de 19.20 = 1.76 de 20.00, 8.80%
+	if err := s.ConferirDesconto("vendedor", dec("10")); err != nil {
+		t.Errorf("desconto dentro do limite: %v", err)
+	}
+
+	var acima *DescontoAcimaDoLimiteError
+	err := s.ConferirDesconto("vendedor", dec("8.5"))
+	if !errors.As(err, &acima) || acima.ProdutoID != 0 || !acima.Percentual.Equal(dec("8.80")) {
+		t.Errorf("desconto total acima do limite: err = %v", err)
+	}
+	err = s.ConferirDesconto("caixa", dec("5"))
+	if !errors.As(err, &acima) || acima.ProdutoID != 1 || acima.Perfil != "caixa" {
+		t.Errorf("desconto do item acima do limite: err = %v", err)
+	}
+	if err := s.ConferirDesconto("", dec("0")); !errors.As(err, &acima) {
+		t.Errorf("perfil sem limite concedeu desconto: err = %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DestinoDevolucao diz o que fazer com o valor que o cliente já tinha pago
+type DestinoDevolucao string
+
+const (
+	DevolucaoReembolso DestinoDevolucao = "REEMBOLSO"
+	DevolucaoCredito   DestinoDevolucao = "CREDITO"
+)
+
+// ItemDevolucao é a quantidade devolvida de um produto da venda; as unidades
+// avariadas vão para o estoque de avariados em vez de voltar à venda. Total é
+// o valor líquido devolvido, já descontados os descontos da venda.
+type ItemDevolucao struct {
+	ProdutoID          int64   `json:"produto_id"`
+	Quantidade         int64   `json:"quantidade"`
+	QuantidadeAvariada int64   `json:"quantidade_avariada"`
+	PrecoUnitario      Decimal `json:"preco_unitario"`
+	Total              Decimal `json:"total"`
+}
+
+// Devolucao registra mercadoria devolvida por um cliente. O valor devolvido
+// primeiro abate o saldo em aberto da venda; o que exceder, já pago pelo
+// cliente, vira reembolso ou crédito na loja conforme Destino.
+type Devolucao struct {
+	ID             int64            `json:"id"`
+	VendaID        int64            `json:"venda_id"`
+	ClienteID      int64            `json:"cliente_id"`
+	Data           time.Time        `json:"data"`
+	Motivo         string           `json:"motivo,omitempty"`
+	Destino        DestinoDevolucao `json:"destino"`
+	FormaReembolso FormaPagamento   `json:"forma_reembolso,omitempty"`
+	Valor          Decimal          `json:"valor"`
+	Abatido        Decimal          `json:"abatido"`
+	Reembolso      Decimal          `json:"reembolso"`
+	Credito        Decimal          `json:"credito"`
+	Usuario        string           `json:"usuario,omitempty"`
+	Items          []ItemDevolucao  `json:"items"`
+}
+
+// QuantidadeDevolucaoError indica devolução maior do que o vendido ainda não devolvido
+type QuantidadeDevolucaoError struct {
+	ProdutoID  int64 `json:"produto_id"`
+	Solicitado int64 `json:"solicitado"`
+	Disponivel int64 `json:"disponivel"`
+}
+
+func (e *QuantidadeDevolucaoError) Error() string {
+	return fmt.Sprintf("produto %d: devolução de %d excede as %d unidades que podem ser devolvidas",
+		e.ProdutoID, e.Solicitado, e.Disponivel)
+}
+
+// Validate confere destino e itens da devolução
+func (d *Devolucao) Validate() error {
+	switch d.Destino {
+	case DevolucaoCredito:
+	case DevolucaoReembolso:
+		if !d.FormaReembolso.Valida() || d.FormaReembolso == FormaCreditoLoja {
+			return fmt.Errorf("forma de reembolso inválida: %q", d.FormaReembolso)
+		}
+	default:
+		return fmt.Errorf("destino da devolução deve ser %s ou %s", DevolucaoReembolso, DevolucaoCredito)
+	}
+	if len(d.Items) == 0 {
+		return errors.New("a devolução precisa de ao menos um item")
+	}
+	vistos := make(map[int64]bool, len(d.Items))
+	for _, item := range d.Items {
+		if vistos[item.ProdutoID] {
+			return fmt.Errorf("produto %d repetido na devolução", item.ProdutoID)
+		}
+		vistos[item.ProdutoID] = true
+		if item.Quantidade <= 0 {
+			return fmt.Errorf("quantidade devolvida do produto %d deve ser positiva", item.ProdutoID)
+		}
+		if item.QuantidadeAvariada < 0 || item.QuantidadeAvariada > item.Quantidade {
+			return fmt.Errorf("quantidade avariada do produto %d deve estar entre 0 e a quantidade devolvida", item.ProdutoID)
+		}
+	}
+	return nil
+}
+
+// Calcular confere as quantidades contra o que pode ser devolvido (vendido
+// menos devoluções anteriores), valoriza os itens pelo preço praticado na
+// venda e reparte o valor entre abatimento do saldo em aberto e excedente
+func (d *Devolucao) Calcular(vendidos []SaleItem, jaDevolvidos map[int64]int64, saldoAberto Decimal) error {
+	porProduto := make(map[int64]SaleItem, len(vendidos))
+	for _, item := range vendidos {
+		porProduto[item.ProductID] = item
+	}
+
+	d.Valor = DecimalFromInt(0)
+	for i := range d.Items {
+		item := &d.Items[i]
+		vendido, ok := porProduto[item.ProdutoID]
+		disponivel := int64(vendido.Quantity) - jaDevolvidos[item.ProdutoID]
+		if !ok || item.Quantidade > disponivel {
+			return &QuantidadeDevolucaoError{ProdutoID: item.ProdutoID, Solicitado: item.Quantidade, Disponivel: disponivel}
+		}
+		item.PrecoUnitario = vendido.UnitPrice
+		item.Total = valorDevolvido(vendido, jaDevolvidos[item.ProdutoID], item.Quantidade)
+		d.Valor = d.Valor.Add(item.Total)
+	}
+
+	d.Abatido = d.Valor
+	if saldoAberto.Compare(d.Valor) < 0 {
+		d.Abatido = saldoAberto
+	}
+	if d.Abatido.Sign() < 0 {
+		d.Abatido = DecimalFromInt(0)
+	}
+	excedente := d.Valor.Sub(d.Abatido)
+	d.Reembolso, d.Credito = DecimalFromInt(0), DecimalFromInt(0)
+	if d.Destino == DevolucaoCredito {
+		d.Credito = excedente
+	} else {
+		d.Reembolso = excedente
+	}
+	return nil
+}
+
+// valorDevolvido é a parte do total líquido do item vendido que corresponde
+// às unidades devolvidas agora. O valor é calculado pela diferença entre as
+// proporções acumuladas, para que devoluções parciais somem exatamente o total.
+func valorDevolvido(vendido SaleItem, antes, agora int64) Decimal {
+	proporcao := func(n int64) Decimal {
+		return vendido.Total.MulInt(n).Quo(DecimalFromInt(int64(vendido.Quantity))).RoundTo(2)
+	}
+	return proporcao(antes + agora).Sub(proporcao(antes))
+}
+
+// MovimentoCredito é uma linha da conta de crédito do cliente na loja:
+// positiva quando gerada por devolução, negativa quando usada em pagamento
+type MovimentoCredito struct {
+	ID        int64     `json:"id"`
+	ClienteID int64     `json:"cliente_id"`
+	Valor     Decimal   `json:"valor"`
+	Documento string    `json:"documento"`
+	Usuario   string    `json:"usuario,omitempty"`
+	CriadoEm  time.Time `json:"criado_em"`
+}
+
+// ErrCreditoLojaInsuficiente indica pagamento com crédito maior que o saldo do cliente
+var ErrCreditoLojaInsuficiente = errors.New("crédito na loja insuficiente")
+
+// ContaCredito é o saldo de crédito do cliente na loja com seu histórico
+type ContaCredito struct {
+	ClienteID  int64              `json:"cliente_id"`
+	Saldo      Decimal            `json:"saldo"`
+	Movimentos []MovimentoCredito `json:"movimentos"`
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ItemDevolucaoFornecedor é a quantidade de um produto da compra devolvida ao
+// fornecedor; QuantidadeAvariada dessas unidades sai do estoque de avariados
+// e o restante do estoque disponível
+type ItemDevolucaoFornecedor struct {
+	ProdutoID          int64   `json:"produto_id"`
+	Quantidade         int64   `json:"quantidade"`
+	QuantidadeAvariada int64   `json:"quantidade_avariada"`
+	CustoUnitario      Decimal `json:"custo_unitario"`
+	Total              Decimal `json:"total"`
+}
+
+// DevolucaoFornecedor registra mercadoria devolvida ao fornecedor de uma
+// compra recebida e o crédito esperado dele
+type DevolucaoFornecedor struct {
+	ID                int64                     `json:"id"`
+	CompraID          int64                     `json:"compra_id"`
+	FornecedorID      int64                     `json:"fornecedor_id"`
+	Data              time.Time                 `json:"data"`
+	Motivo            string                    `json:"motivo,omitempty"`
+	Credito           Decimal                   `json:"credito"`
+	CreditoRecebidoEm *time.Time                `json:"credito_recebido_em,omitempty"`
+	Usuario           string                    `json:"usuario,omitempty"`
+	Items             []ItemDevolucaoFornecedor `json:"items"`
+}
+
+// CreditosFornecedor resume as devoluções de um fornecedor e o crédito ainda
+// não recebido
+type CreditosFornecedor struct {
+	FornecedorID int64                 `json:"fornecedor_id"`
+	Pendente     Decimal               `json:"pendente"`
+	Devolucoes   []DevolucaoFornecedor `json:"devolucoes"`
+}
+
+// Validate confere os itens da devolução ao fornecedor
+func (d *DevolucaoFornecedor) Validate() error {
+	if len(d.Items) == 0 {
+		return errors.New("a devolução precisa de ao menos um item")
+	}
+	vistos := make(map[int64]bool, len(d.Items))
+	for _, item := range d.Items {
+		if vistos[item.ProdutoID] {
+			return fmt.Errorf("produto %d repetido na devolução", item.ProdutoID)
+		}
+		vistos[item.ProdutoID] = true
+		if item.Quantidade <= 0 {
+			return fmt.Errorf("quantidade devolvida do produto %d deve ser positiva", item.ProdutoID)
+		}
+		if item.QuantidadeAvariada < 0 || item.QuantidadeAvariada > item.Quantidade {
+			return fmt.Errorf("quantidade avariada do produto %d deve estar entre 0 e a quantidade devolvida", item.ProdutoID)
+		}
+	}
+	return nil
+}
+
+// Calcular confere as quantidades contra o recebido na compra menos o já
+// devolvido e valoriza o crédito pelo custo de cada item na compra
+func (d *DevolucaoFornecedor) Calcular(recebidos []CompraItem, jaDevolvidos map[int64]int64) error {
+	porProduto := make(map[int64]CompraItem, len(recebidos))
+	for _, item := range recebidos {
+		porProduto[item.ProdutoID] = item
+	}
+
+	d.Credito = DecimalFromInt(0)
+	for i := range d.Items {
+		item := &d.Items[i]
+		recebido, ok := porProduto[item.ProdutoID]
+		disponivel := recebido.Quantidade - jaDevolvidos[item.ProdutoID]
+		if !ok || item.Quantidade > disponivel {
+			return &QuantidadeDevolucaoError{ProdutoID: item.ProdutoID, Solicitado: item.Quantidade, Disponivel: disponivel}
+		}
+		item.CustoUnitario = recebido.PrecoUnitario
+		item.Total = recebido.PrecoUnitario.MulInt(item.Quantidade).RoundTo(2)
+		d.Credito = d.Credito.Add(item.Total)
+	}
+	return nil
+}
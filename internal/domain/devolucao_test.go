@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDevolucaoCalcular(t *testing.T) {
+	vendidos := []SaleItem{
+		{ProductID: 1, Quantity: 3, UnitPrice: dec("10.00"), Total: dec("30.00")},
+		{ProductID: 2, Quantity: 1, UnitPrice: dec("25.50"), Total: dec("25.50")},
+	}
+
+	// venda de 55.50 com 40.00 pagos: 15.50 abatem o saldo, o resto vira crédito
+	d := Devolucao{Destino: DevolucaoCredito, Items: []ItemDevolucao{
+		{ProdutoID: 1, Quantidade: 1},
+		{ProdutoID: 2, Quantidade: 1, QuantidadeAvariada: 1},
+	}}
+	if err := d.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Calcular(vendidos, nil, dec("15.50")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Valor.Equal(dec("35.50")) || !d.Abatido.Equal(dec("15.50")) ||
+		!d.Credito.Equal(dec("20.00")) || !d.Reembolso.Equal(dec("0")) {
+		t.Errorf("devolução = %+v", d)
+	}
+
+	// sem saldo em aberto, tudo que foi pago volta como reembolso
+	d = Devolucao{Destino: DevolucaoReembolso, FormaReembolso: FormaDinheiro,
+		Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 2}}}
+	if err := d.Calcular(vendidos, map[int64]int64{1: 1}, dec("0")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Abatido.Equal(dec("0")) || !d.Reembolso.Equal(dec("20.00")) {
+		t.Errorf("reembolso = %+v", d)
+	}
+
+	// item com desconto devolve o líquido; a última unidade leva a sobra do arredondamento
+	comDesconto := []SaleItem{{ProductID: 1, Quantity: 3, UnitPrice: dec("10.00"), Total: dec("25.00")}}
+	d = Devolucao{Destino: DevolucaoCredito, Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 1}}}
+	if err := d.Calcular(comDesconto, map[int64]int64{1: 2}, dec("0")); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Valor.Equal(dec("8.33")) {
+		t.Errorf("valor da última unidade com desconto = %s, esperado 8.33", d.Valor)
+	}
+
+	var excede *QuantidadeDevolucaoError
+	d = Devolucao{Destino: DevolucaoCredito, Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 2}}}
+	if err := d.Calcular(vendidos, map[int64]int64{1: 2}, dec("0")); !errors.As(err, &excede) || excede.Disponivel != 1 {
+		t.Errorf("devolução acima do disponível: err = %v", err)
+	}
+	d = Devolucao{Destino: DevolucaoCredito, Items: []ItemDevolucao{{ProdutoID: 9, Quantidade: 1}}}
+	if err := d.Calcular(vendidos, nil, dec("0")); !errors.As(err, &excede) {
+		t.Errorf("produto fora da venda: err = %v", err)
+	}
+}
+
+func TestDevolucaoValidate(t *testing.T) {
+	casos := map[string]Devolucao{
+		"sem destino":          {Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 1}}},
+		"reembolso sem forma":  {Destino: DevolucaoReembolso, Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 1}}},
+		"reembolso em crédito": {Destino: DevolucaoReembolso, FormaReembolso: FormaCreditoLoja, Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 1}}},
+		"sem itens":            {Destino: DevolucaoCredito},
+		"quantidade zero":      {Destino: DevolucaoCredito, Items: []ItemDevolucao{{ProdutoID: 1}}},
+		"avariadas demais":     {Destino: DevolucaoCredito, Items: []ItemDevolucao{{ProdutoID: 1, Quantidade: 1, QuantidadeAvariada: 2}}},
+		"produto repetido": {Destino: DevolucaoCredito, Items: []ItemDevolucao{
+			{ProdutoID: 1, Quantidade: 1}, {ProdutoID: 1, Quantidade: 1}}},
+	}
+	for nome, d := range casos {
+		if err := d.Validate(); err == nil {
+			t.Errorf("%s: esperado erro", nome)
+		}
+	}
+}
@@ -1,6 +1,73 @@
 package domain
 
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
 type Fornecedor struct {
-	Id   int64  `json:"id"`
-	Nome string `json:"nome"`
+	Id                 int64  `json:"id"`
+	Nome               string `json:"nome"`
+	CNPJ               string `json:"cnpj,omitempty"`
+	Contato            string `json:"contato,omitempty"`
+	Telefone           string `json:"telefone,omitempty"`
+	Email              string `json:"email,omitempty"`
+	CondicoesPagamento string `json:"condicoes_pagamento,omitempty"`
+}
+
+// Validate verifica os campos obrigatórios e normaliza o CNPJ (somente dígitos)
+func (f *Fornecedor) Validate() error {
+	f.Nome = strings.TrimSpace(f.Nome)
+	if f.Nome == "" {
+		return errors.New("nome do fornecedor não pode ser vazio")
+	}
+	if f.CNPJ != "" {
+		cnpj := somenteDigitos(f.CNPJ)
+		if !CNPJValido(cnpj) {
+			return errors.New("CNPJ inválido")
+		}
+		f.CNPJ = cnpj
+	}
+	if f.Email != "" {
+		if _, err := mail.ParseAddress(f.Email); err != nil {
+			return errors.New("e-mail inválido")
+		}
+	}
+	return nil
+}
+
+// CNPJValido confere o tamanho e os dois dígitos verificadores de um CNPJ
+func CNPJValido(cnpj string) bool {
+	cnpj = somenteDigitos(cnpj)
+	if len(cnpj) != 14 || strings.Count(cnpj, cnpj[:1]) == 14 {
+		return false
+	}
+	pesos := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	for _, n := range []int{12, 13} {
+		soma := 0
+		for i := 0; i < n; i++ {
+			soma += int(cnpj[i]-'0') * pesos[len(pesos)-n+i]
+		}
+		dv := soma % 11
+		if dv < 2 {
+			dv = 0
+		} else {
+			dv = 11 - dv
+		}
+		if int(cnpj[n]-'0') != dv {
+			return false
+		}
+	}
+	return true
+}
+
+func somenteDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
 }
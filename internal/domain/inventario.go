@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StatusInventario acompanha a sessão de contagem da abertura à aprovação
+type StatusInventario string
+
+const (
+	InventarioAberto    StatusInventario = "ABERTO"
+	InventarioAprovado  StatusInventario = "APROVADO"
+	InventarioCancelado StatusInventario = "CANCELADO"
+)
+
+// ErrSituacaoInventario indica operação que a situação da sessão não permite
+var ErrSituacaoInventario = errors.New("operação não permitida na situação do inventário")
+
+// ErrInventarioEmAndamento indica que já existe sessão de contagem aberta no depósito
+var ErrInventarioEmAndamento = errors.New("já existe inventário aberto no depósito")
+
+// ErrProdutoForaDoInventario indica contagem de produto que não estava no
+// estoque congelado na abertura da sessão
+var ErrProdutoForaDoInventario = errors.New("produto não faz parte do inventário")
+
+// Inventario é uma sessão de contagem física de um depósito. Na abertura o
+// estoque de cada produto no depósito é congelado em Esperada; as contagens chegam em lotes e, na
+// aprovação, cada produto contado com diferença recebe um movimento
+// INVENTARIO. Produtos não contados ficam como estão.
+type Inventario struct {
+	ID          int64            `json:"id"`
+	DepositoID  int64            `json:"deposito_id"`
+	Descricao   string           `json:"descricao,omitempty"`
+	Status      StatusInventario `json:"status"`
+	AbertoEm    time.Time        `json:"aberto_em"`
+	Usuario     string           `json:"usuario,omitempty"`
+	AprovadoEm  *time.Time       `json:"aprovado_em,omitempty"`
+	AprovadoPor string           `json:"aprovado_por,omitempty"`
+	Produtos    int              `json:"produtos"`
+	Contados    int              `json:"contados"`
+	Divergentes int              `json:"divergentes"`
+	Itens       []ItemInventario `json:"itens,omitempty"`
+}
+
+// ItemInventario é a quantidade esperada de um produto na sessão e, depois
+// de contado, a quantidade encontrada. Diferença é Contada - Esperada.
+type ItemInventario struct {
+	ProdutoID  int64      `json:"produto_id"`
+	Nome       string     `json:"nome"`
+	Esperada   int64      `json:"esperada"`
+	Contada    *int64     `json:"contada"`
+	Diferenca  int64      `json:"diferenca"`
+	ContadoEm  *time.Time `json:"contado_em,omitempty"`
+	ContadoPor string     `json:"contado_por,omitempty"`
+}
+
+// Contagem é a quantidade encontrada de um produto, identificado pelo id ou
+// pelo código de barras. Uma nova contagem do mesmo produto substitui a anterior.
+type Contagem struct {
+	ProdutoID    int64  `json:"produto_id,omitempty"`
+	CodigoBarras string `json:"codigo_barras,omitempty"`
+	Quantidade   int64  `json:"quantidade"`
+}
+
+// Validate confere que a contagem identifica o produto de um único jeito e
+// não é negativa
+func (c Contagem) Validate() error {
+	if (c.ProdutoID > 0) == (c.CodigoBarras != "") {
+		return errors.New("informe produto_id ou codigo_barras")
+	}
+	if c.Quantidade < 0 {
+		return fmt.Errorf("quantidade contada não pode ser negativa: %d", c.Quantidade)
+	}
+	return nil
+}
+
+// CalcularDiferencas preenche a diferença dos itens contados e os totais da sessão
+func (inv *Inventario) CalcularDiferencas() {
+	inv.Produtos, inv.Contados, inv.Divergentes = len(inv.Itens), 0, 0
+	for i := range inv.Itens {
+		item := &inv.Itens[i]
+		item.Diferenca = 0
+		if item.Contada == nil {
+			continue
+		}
+		inv.Contados++
+		item.Diferenca = *item.Contada - item.Esperada
+		if item.Diferenca != 0 {
+			inv.Divergentes++
+		}
+	}
+}
+
+// Divergencias devolve os itens contados cuja quantidade difere da esperada
+func (inv *Inventario) Divergencias() []ItemInventario {
+	inv.CalcularDiferencas()
+	divergencias := []ItemInventario{}
+	for _, item := range inv.Itens {
+		if item.Contada != nil && item.Diferenca != 0 {
+			divergencias = append(divergencias, item)
+		}
+	}
+	return divergencias
+}
+
+// Aprovar marca a sessão aberta como aprovada
+func (inv *Inventario) Aprovar(agora time.Time, usuario string) error {
+	if inv.Status != InventarioAberto {
+		return fmt.Errorf("aprovar inventário %s: %w", inv.Status, ErrSituacaoInventario)
+	}
+	inv.Status = InventarioAprovado
+	inv.AprovadoEm = &agora
+	inv.AprovadoPor = usuario
+	return nil
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInventarioDivergencias(t *testing.T) {
+	contada := func(n int64) *int64 { return &n }
+	inv := Inventario{Status: InventarioAberto, Itens: []ItemInventario{
+		{ProdutoID: 1, Esperada: 10, Contada: contada(8)},
+		{ProdutoID: 2, Esperada: 5, Contada: contada(5)},
+		{ProdutoID: 3, Esperada: 4},
+		{ProdutoID: 4, Esperada: 0, Contada: contada(2)},
+	}}
+
+	divergencias := inv.Divergencias()
+	if len(divergencias) != 2 || divergencias[0].Diferenca != -2 || divergencias[1].Diferenca != 2 {
+		t.Errorf("Divergencias = %+v", divergencias)
+	}
+	if inv.Produtos != 4 || inv.Contados != 3 || inv.Divergentes != 2 {
+		t.Errorf("totais = %d/%d/%d", inv.Produtos, inv.Contados, inv.Divergentes)
+	}
+
+	if err := inv.Aprovar(time.Now(), "gerente"); err != nil || inv.Status != InventarioAprovado {
+		t.Fatalf("Aprovar: %v", err)
+	}
+	if err := inv.Aprovar(time.Now(), "gerente"); !errors.Is(err, ErrSituacaoInventario) {
+		t.Errorf("aprovação repetida: %v", err)
+	}
+}
+
+func TestContagemValidate(t *testing.T) {
+	casos := []struct {
+		contagem Contagem
+		valida   bool
+	}{
+		{Contagem{ProdutoID: 1, Quantidade: 0}, true},
+		{Contagem{CodigoBarras: "7891234567895", Quantidade: 3}, true},
+		{Contagem{Quantidade: 3}, false},
+		{Contagem{ProdutoID: 1, CodigoBarras: "7891234567895", Quantidade: 3}, false},
+		{Contagem{ProdutoID: 1, Quantidade: -1}, false},
+	}
+	for _, c := range casos {
+		if err := c.contagem.Validate(); (err == nil) != c.valida {
+			t.Errorf("Validate(%+v) = %v", c.contagem, err)
+		}
+	}
+}
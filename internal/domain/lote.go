@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lote é uma partida de um produto guardada num depósito, identificada pelo
+// número do fabricante e/ou pela validade ("YYYY-MM-DD"). Controlar lote é
+// opcional: o estoque do depósito pode ter unidades fora de qualquer lote,
+// mas a soma dos lotes nunca passa do saldo do depósito.
+type Lote struct {
+	ID         int64     `json:"id"`
+	ProdutoID  int64     `json:"produto_id"`
+	Produto    string    `json:"produto,omitempty"`
+	DepositoID int64     `json:"deposito_id"`
+	Deposito   string    `json:"deposito,omitempty"`
+	Numero     string    `json:"numero,omitempty"`
+	Validade   string    `json:"validade,omitempty"`
+	Quantidade int64     `json:"quantidade"`
+	CriadoEm   time.Time `json:"criado_em"`
+}
+
+// ErrValidadeLoteDivergente indica lote já cadastrado com outra validade
+var ErrValidadeLoteDivergente = errors.New("lote já cadastrado com outra validade")
+
+// Validate exige número ou validade e quantidade positiva; o número é
+// guardado sem espaços nas pontas
+func (l *Lote) Validate() error {
+	l.Numero = strings.TrimSpace(l.Numero)
+	if l.Numero == "" && l.Validade == "" {
+		return errors.New("lote precisa de número ou validade")
+	}
+	if l.Validade != "" {
+		if _, err := time.Parse("2006-01-02", l.Validade); err != nil {
+			return fmt.Errorf("validade do lote %q deve estar no formato YYYY-MM-DD", l.Numero)
+		}
+	}
+	if l.Quantidade <= 0 {
+		return fmt.Errorf("quantidade do lote %q deve ser positiva", l.Numero)
+	}
+	return nil
+}
+
+// DiasParaVencer conta os dias de hoje até a validade, negativo quando já
+// venceu; ok é falso para lote sem validade
+func (l Lote) DiasParaVencer(hoje time.Time) (dias int, ok bool) {
+	validade, err := time.Parse("2006-01-02", l.Validade)
+	if err != nil {
+		return 0, false
+	}
+	dia := time.Date(hoje.Year(), hoje.Month(), hoje.Day(), 0, 0, 0, 0, time.UTC)
+	return int(validade.Sub(dia).Hours() / 24), true
+}
+
+// LoteAVencer é um lote do relatório de vencimentos, valorizado pelo custo
+// médio atual do produto
+type LoteAVencer struct {
+	Lote
+	DiasRestantes int     `json:"dias_restantes"`
+	Vencido       bool    `json:"vencido"`
+	Custo         Decimal `json:"custo"`
+	Valor         Decimal `json:"valor"`
+}
+
+// RelatorioVencimentos lista os lotes com saldo que vencem até Ate, incluindo
+// os já vencidos, do que vence primeiro ao último
+type RelatorioVencimentos struct {
+	Dias       int           `json:"dias"`
+	Ate        string        `json:"ate"`
+	Lotes      []LoteAVencer `json:"lotes"`
+	Quantidade int64         `json:"quantidade"`
+	Valor      Decimal       `json:"valor"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoteValidate(t *testing.T) {
+	l := Lote{Numero: " L-01 ", Validade: "2030-01-10", Quantidade: 3}
+	if err := l.Validate(); err != nil || l.Numero != "L-01" {
+		t.Errorf("Validate = %v, número %q", err, l.Numero)
+	}
+	casos := []struct {
+		nome string
+		l    Lote
+	}{
+		{"sem número nem validade", Lote{Numero: " ", Quantidade: 1}},
+		{"validade inválida", Lote{Numero: "L", Validade: "10/01/2030", Quantidade: 1}},
+		{"quantidade zero", Lote{Validade: "2030-01-10"}},
+	}
+	for _, c := range casos {
+		if err := c.l.Validate(); err == nil {
+			t.Errorf("%s: lote aceito", c.nome)
+		}
+	}
+	if err := (&Lote{Validade: "2030-01-10", Quantidade: 1}).Validate(); err != nil {
+		t.Errorf("lote só com validade: %v", err)
+	}
+}
+
+func TestDiasParaVencer(t *testing.T) {
+	hoje := time.Date(2030, 1, 1, 23, 30, 0, 0, time.Local)
+	casos := []struct {
+		validade string
+		dias     int
+		ok       bool
+	}{
+		{"2030-01-01", 0, true},
+		{"2030-01-10", 9, true},
+		{"2029-12-20", -12, true},
+		{"2030-03-01", 59, true},
+		{"", 0, false},
+	}
+	for _, c := range casos {
+		dias, ok := Lote{Validade: c.validade}.DiasParaVencer(hoje)
+		if dias != c.dias || ok != c.ok {
+			t.Errorf("DiasParaVencer(%q) = %d, %v; esperado %d, %v", c.validade, dias, ok, c.dias, c.ok)
+		}
+	}
+}
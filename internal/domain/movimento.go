@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+type TipoMovimento string
+
+const (
+	MovimentoSaldoInicial        TipoMovimento = "SALDO_INICIAL"
+	MovimentoCompra              TipoMovimento = "COMPRA"
+	MovimentoVenda               TipoMovimento = "VENDA"
+	MovimentoAlteracaoVenda      TipoMovimento = "ALTERACAO_VENDA" // diferença dos itens ao editar a venda
+	MovimentoCancelamento        TipoMovimento = "CANCELAMENTO"
+	MovimentoDevolucao           TipoMovimento = "DEVOLUCAO"
+	MovimentoDevolucaoFornecedor TipoMovimento = "DEVOLUCAO_FORNECEDOR"
+	MovimentoAjuste              TipoMovimento = "AJUSTE"
+	MovimentoInventario          TipoMovimento = "INVENTARIO"
+	MovimentoTransferencia       TipoMovimento = "TRANSFERENCIA"
+)
+
+// MovimentoEstoque é uma linha do razão de estoque. Quantidade é positiva nas
+// entradas e negativa nas saídas; Saldo é o estoque total do produto após o
+// movimento e SaldoDeposito o estoque no depósito movimentado.
+type MovimentoEstoque struct {
+	ID            int64         `json:"id"`
+	ProdutoID     int64         `json:"produto_id"`
+	DepositoID    int64         `json:"deposito_id"`
+	Tipo          TipoMovimento `json:"tipo"`
+	Quantidade    int64         `json:"quantidade"`
+	Saldo         int64         `json:"saldo"`
+	SaldoDeposito int64         `json:"saldo_deposito"`
+	Motivo        string        `json:"motivo,omitempty"`
+	MotivoAjuste  MotivoAjuste  `json:"motivo_ajuste,omitempty"` // código dos ajustes manuais
+	Documento     string        `json:"documento,omitempty"`
+	Usuario       string        `json:"usuario,omitempty"`
+	CriadoEm      time.Time     `json:"criado_em"`
+}
+
+// DivergenciaEstoque aponta um produto cujo estoque não bate com a soma do
+// razão; com DepositoID, o saldo daquele depósito
+type DivergenciaEstoque struct {
+	ProdutoID  int64  `json:"produto_id"`
+	DepositoID int64  `json:"deposito_id,omitempty"`
+	Nome       string `json:"nome"`
+	Estoque    int64  `json:"estoque"`
+	SaldoRazao int64  `json:"saldo_razao"`
+	Diferenca  int64  `json:"diferenca"`
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StatusOrcamento acompanha o orçamento do pedido de preço até virar venda
+type StatusOrcamento string
+
+const (
+	OrcamentoAberto     StatusOrcamento = "ABERTO"
+	OrcamentoAprovado   StatusOrcamento = "APROVADO"
+	OrcamentoExpirado   StatusOrcamento = "EXPIRADO"
+	OrcamentoConvertido StatusOrcamento = "CONVERTIDO"
+)
+
+// ValidadePadraoOrcamento é o prazo, em dias, de orçamentos sem valido_ate
+const ValidadePadraoOrcamento = 7
+
+// ErrOrcamentoExpirado indica orçamento cuja validade já passou
+var ErrOrcamentoExpirado = errors.New("orçamento expirado")
+
+// ErrSituacaoOrcamento indica operação que a situação atual do orçamento não permite
+var ErrSituacaoOrcamento = errors.New("operação não permitida na situação do orçamento")
+
+// Orcamento é uma proposta de venda com os mesmos itens e preços de uma Sale,
+// válida até ValidoAte ("YYYY-MM-DD"). Não mexe no estoque; ao ser convertido
+// gera a venda VendaID.
+type Orcamento struct {
+	ID            int64           `json:"id"`
+	ClientID      int64           `json:"cliente_id"`
+	Cliente       *Cliente        `json:"cliente,omitempty"`
+	Data          time.Time       `json:"data"`
+	ValidoAte     string          `json:"valido_ate"`
+	Status        StatusOrcamento `json:"status"`
+	Bruto         Decimal         `json:"bruto"`
+	Desconto      *Desconto       `json:"desconto,omitempty"`
+	ValorDesconto Decimal         `json:"valor_desconto"`
+	Total         Decimal         `json:"total"`
+	AprovadoEm    *time.Time      `json:"aprovado_em,omitempty"`
+	VendaID       *int64          `json:"venda_id,omitempty"`
+	Usuario       string          `json:"usuario,omitempty"`
+	Items         []SaleItem      `json:"items"`
+
+	// Perfil do usuário que registra o orçamento, usado para o limite de desconto
+	Perfil string `json:"-"`
+}
+
+// Validate confere cliente, itens e validade do orçamento criado em hoje;
+// sem valido_ate, o orçamento vale ValidadePadraoOrcamento dias
+func (o *Orcamento) Validate(hoje time.Time) error {
+	if o.ClientID <= 0 {
+		return errors.New("cliente inválido")
+	}
+	if o.ValidoAte == "" {
+		o.ValidoAte = hoje.AddDate(0, 0, ValidadePadraoOrcamento).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", o.ValidoAte); err != nil {
+		return fmt.Errorf("valido_ate inválido: %q", o.ValidoAte)
+	}
+	if o.ValidoAte < hoje.Format("2006-01-02") {
+		return errors.New("valido_ate não pode ser anterior a hoje")
+	}
+	if o.Desconto != nil {
+		if err := o.Desconto.Validate(); err != nil {
+			return err
+		}
+	}
+	venda := Sale{Items: o.Items}
+	return venda.ValidarItens()
+}
+
+// Vencido indica orçamento ainda aberto ou aprovado cuja validade passou antes de hoje
+func (o *Orcamento) Vencido(hoje time.Time) bool {
+	return (o.Status == OrcamentoAberto || o.Status == OrcamentoAprovado) &&
+		o.ValidoAte < hoje.Format("2006-01-02")
+}
+
+// Expirar passa para expirado o status do orçamento vencido em hoje
+func (o *Orcamento) Expirar(hoje time.Time) {
+	if o.Vencido(hoje) {
+		o.Status = OrcamentoExpirado
+	}
+}
+
+// Aprovar marca o orçamento aberto e dentro da validade como aprovado
+func (o *Orcamento) Aprovar(agora time.Time) error {
+	if o.Vencido(agora) || o.Status == OrcamentoExpirado {
+		return ErrOrcamentoExpirado
+	}
+	if o.Status != OrcamentoAberto {
+		return fmt.Errorf("aprovar orçamento %s: %w", o.Status, ErrSituacaoOrcamento)
+	}
+	o.Status = OrcamentoAprovado
+	o.AprovadoEm = &agora
+	return nil
+}
+
+// Venda monta a venda do orçamento aprovado e dentro da validade. Os preços
+// serão conferidos com os atuais na gravação: com precosAtuais falso, um
+// preço que mudou desde o orçamento resulta em *TotalDivergenteError; com
+// verdadeiro, a venda sai pelos preços atuais. Preços autorizados e
+// descontos valem como foram aceitos no orçamento.
+func (o *Orcamento) Venda(hoje time.Time, precosAtuais bool) (Sale, error) {
+	if o.Vencido(hoje) || o.Status == OrcamentoExpirado {
+		return Sale{}, ErrOrcamentoExpirado
+	}
+	if o.Status != OrcamentoAprovado {
+		return Sale{}, fmt.Errorf("converter orçamento %s: %w", o.Status, ErrSituacaoOrcamento)
+	}
+
+	venda := Sale{ClientID: o.ClientID, Desconto: o.Desconto, Items: make([]SaleItem, len(o.Items)),
+		AutorizadaNoOrcamento: true}
+	for i, item := range o.Items {
+		venda.Items[i] = SaleItem{
+			ProductID:     item.ProductID,
+			Quantity:      item.Quantity,
+			PriceOverride: item.PriceOverride,
+			AuthorizedBy:  item.AuthorizedBy,
+			Desconto:      item.Desconto,
+		}
+		if !precosAtuais {
+			venda.Items[i].UnitPrice = item.UnitPrice
+		}
+	}
+	if !precosAtuais {
+		venda.Total = o.Total
+	}
+	return venda, nil
+}
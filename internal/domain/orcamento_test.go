@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrcamentoValidadeEAprovacao(t *testing.T) {
+	hoje := time.Date(2024, 3, 10, 15, 0, 0, 0, time.Local)
+	o := Orcamento{ClientID: 1, Items: []SaleItem{{ProductID: 1, Quantity: 2}}}
+	if err := o.Validate(hoje); err != nil {
+		t.Fatal(err)
+	}
+	if o.ValidoAte != "2024-03-17" {
+		t.Errorf("validade padrão = %s", o.ValidoAte)
+	}
+	vencido := Orcamento{ClientID: 1, ValidoAte: "2024-03-09", Items: o.Items}
+	if err := vencido.Validate(hoje); err == nil {
+		t.Error("validade no passado deveria falhar")
+	}
+
+	o.Status = OrcamentoAberto
+	if o.Vencido(hoje.AddDate(0, 0, 7)) {
+		t.Error("orçamento vale até o último dia de validade")
+	}
+	if !o.Vencido(hoje.AddDate(0, 0, 8)) {
+		t.Error("orçamento deveria vencer no dia seguinte à validade")
+	}
+	if _, err := o.Venda(hoje, false); !errors.Is(err, ErrSituacaoOrcamento) {
+		t.Errorf("converter orçamento não aprovado: err = %v", err)
+	}
+	if err := o.Aprovar(hoje.AddDate(0, 0, 8)); !errors.Is(err, ErrOrcamentoExpirado) {
+		t.Errorf("aprovar vencido: err = %v", err)
+	}
+	if err := o.Aprovar(hoje); err != nil || o.Status != OrcamentoAprovado || o.AprovadoEm == nil {
+		t.Fatalf("Aprovar = %+v, %v", o, err)
+	}
+	if err := o.Aprovar(hoje); !errors.Is(err, ErrSituacaoOrcamento) {
+		t.Errorf("aprovar duas vezes: err = %v", err)
+	}
+}
+
+func TestOrcamentoVenda(t *testing.T) {
+	autorizado := dec("8.00")
+	o := Orcamento{ClientID: 3, ValidoAte: "2024-03-17", Status: OrcamentoAprovado, Total: dec("26.00"),
+		Items: []SaleItem{
+			{ProductID: 1, Quantity: 2, UnitPrice: dec("5.00"), Total: dec("10.00")},
+			{ProductID: 2, Quantity: 2, PriceOverride: &autorizado, AuthorizedBy: "gerente",
+				UnitPrice: dec("8.00"), Total: dec("16.00")},
+		}}
+	hoje := time.Date(2024, 3, 12, 0, 0, 0, 0, time.Local)
+
+	venda, err := o.Venda(hoje, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	venda.Perfil = PerfilGerente
+	// preço do produto 1 subiu: a venda recusa em vez de cobrar diferente do orçado
+	var divergente *TotalDivergenteError
+	if err := venda.CalcularTotais(map[int64]Decimal{1: dec("5.50"), 2: dec("9.00")}); !errors.As(err, &divergente) ||
+		divergente.ProdutoID != 1 {
+		t.Errorf("preço alterado: err = %v", err)
+	}
+
+	venda, err = o.Venda(hoje, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	venda.Perfil = PerfilGerente
+	if err := venda.CalcularTotais(map[int64]Decimal{1: dec("5.50"), 2: dec("9.00")}); err != nil {
+		t.Fatal(err)
+	}
+	if venda.ClientID != 3 || !venda.Total.Equal(dec("27.00")) {
+		t.Errorf("venda com preços atuais = %+v", venda)
+	}
+
+	if _, err := o.Venda(hoje.AddDate(0, 0, 6), true); !errors.Is(err, ErrOrcamentoExpirado) {
+		t.Errorf("converter vencido: err = %v", err)
+	}
+}
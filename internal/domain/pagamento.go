@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type FormaPagamento string
+
+const (
+	FormaDinheiro      FormaPagamento = "DINHEIRO"
+	FormaPix           FormaPagamento = "PIX"
+	FormaCartaoDebito  FormaPagamento = "CARTAO_DEBITO"
+	FormaCartaoCredito FormaPagamento = "CARTAO_CREDITO"
+	FormaBoleto        FormaPagamento = "BOLETO"
+	FormaCheque        FormaPagamento = "CHEQUE"
+	FormaOutro         FormaPagamento = "OUTRO"
+	FormaCreditoLoja   FormaPagamento = "CREDITO_LOJA"
+
+	// FormaDevolucao é gerada pelo sistema quando uma devolução abate o saldo
+	// da venda; não pode ser informada em pagamentos
+	FormaDevolucao FormaPagamento = "DEVOLUCAO"
+)
+
+// Valida indica se a forma de pagamento pode ser informada num pagamento
+func (f FormaPagamento) Valida() bool {
+	switch f {
+	case FormaDinheiro, FormaPix, FormaCartaoDebito, FormaCartaoCredito, FormaBoleto, FormaCheque, FormaOutro,
+		FormaCreditoLoja:
+		return true
+	}
+	return false
+}
+
+// Pagamento é um valor recebido de uma venda
+type Pagamento struct {
+	ID       int64          `json:"id"`
+	VendaID  int64          `json:"venda_id"`
+	Valor    Decimal        `json:"valor"`
+	Data     time.Time      `json:"data"`
+	Forma    FormaPagamento `json:"forma"`
+	Usuario  string         `json:"usuario,omitempty"`
+	CriadoEm time.Time      `json:"criado_em"`
+}
+
+// Validate confere valor e forma do pagamento
+func (p *Pagamento) Validate() error {
+	if p.Valor.Decimal == nil || p.Valor.Sign() <= 0 {
+		return errors.New("valor do pagamento deve ser positivo")
+	}
+	if p.Valor.Exponent < -2 {
+		return errors.New("valor do pagamento deve ter no máximo duas casas decimais")
+	}
+	if !p.Forma.Valida() {
+		return fmt.Errorf("forma de pagamento inválida: %q", p.Forma)
+	}
+	return nil
+}
+
+// ErrPagamentoExcedeSaldo indica pagamento maior do que o valor em aberto da venda
+var ErrPagamentoExcedeSaldo = errors.New("pagamento maior que o saldo em aberto da venda")
+
+// ErrParcelasInvalidas indica plano de parcelas que não pode ser aceito
+var ErrParcelasInvalidas = errors.New("plano de parcelas inválido")
+
+type SituacaoParcela string
+
+const (
+	ParcelaAberta  SituacaoParcela = "ABERTA"
+	ParcelaParcial SituacaoParcela = "PARCIAL"
+	ParcelaPaga    SituacaoParcela = "PAGA"
+	ParcelaVencida SituacaoParcela = "VENCIDA"
+)
+
+// Parcela é um vencimento combinado com o cliente. ValorPago e Situacao não
+// são gravados: vêm da distribuição dos pagamentos da venda pelas parcelas, da
+// que vence primeiro para a última.
+type Parcela struct {
+	ID         int64           `json:"id"`
+	VendaID    int64           `json:"venda_id"`
+	Numero     int             `json:"numero"`
+	Valor      Decimal         `json:"valor"`
+	Vencimento string          `json:"vencimento"` // YYYY-MM-DD
+	ValorPago  Decimal         `json:"valor_pago"`
+	Situacao   SituacaoParcela `json:"situacao"`
+}
+
+// ResumoPagamentos reúne o total da venda, o que já foi recebido e o plano de parcelas
+type ResumoPagamentos struct {
+	VendaID       int64         `json:"venda_id"`
+	Total         Decimal       `json:"total"`
+	Pago          Decimal       `json:"pago"`
+	Saldo         Decimal       `json:"saldo"`
+	Status        PaymentStatus `json:"status_pagamento"`
+	DataPagamento *time.Time    `json:"data_pagamento,omitempty"`
+	Parcelas      []Parcela     `json:"parcelas"`
+	Pagamentos    []Pagamento   `json:"pagamentos"`
+}
+
+// SituacaoPagamento deriva o status de pagamento da venda a partir do valor pago
+func SituacaoPagamento(total, pago Decimal) PaymentStatus {
+	switch {
+	case pago.Sign() <= 0:
+		return PaymentStatusPending
+	case pago.Compare(total) >= 0:
+		return PaymentStatusPaid
+	default:
+		return PaymentStatusPartial
+	}
+}
+
+// DividirParcelas reparte total em n parcelas iguais, a primeira vencendo em
+// primeiro e as demais a cada intervaloDias dias. A diferença de centavos do
+// arredondamento fica na última parcela.
+func DividirParcelas(total Decimal, n int, primeiro time.Time, intervaloDias int) ([]Parcela, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: quantidade de parcelas deve ser positiva", ErrParcelasInvalidas)
+	}
+	if intervaloDias <= 0 {
+		return nil, fmt.Errorf("%w: intervalo entre parcelas deve ser positivo", ErrParcelasInvalidas)
+	}
+	valor := total.Quo(DecimalFromInt(int64(n))).RoundTo(2)
+	restante := total
+
+	parcelas := make([]Parcela, n)
+	for i := range parcelas {
+		parcelas[i] = Parcela{
+			Numero:     i + 1,
+			Valor:      valor,
+			Vencimento: primeiro.AddDate(0, 0, i*intervaloDias).Format("2006-01-02"),
+		}
+		if i == n-1 {
+			parcelas[i].Valor = restante.RoundTo(2)
+		}
+		restante = restante.Sub(valor)
+	}
+	return parcelas, ValidarParcelas(parcelas, total)
+}
+
+// ValidarParcelas confere datas e valores do plano e se a soma fecha com o total da venda
+func ValidarParcelas(parcelas []Parcela, total Decimal) error {
+	if len(parcelas) == 0 {
+		return fmt.Errorf("%w: informe ao menos uma parcela", ErrParcelasInvalidas)
+	}
+	soma := DecimalFromInt(0)
+	for i, p := range parcelas {
+		if p.Valor.Decimal == nil || p.Valor.Sign() <= 0 {
+			return fmt.Errorf("%w: parcela %d com valor não positivo", ErrParcelasInvalidas, i+1)
+		}
+		if _, err := time.Parse("2006-01-02", p.Vencimento); err != nil {
+			return fmt.Errorf("%w: vencimento da parcela %d deve estar no formato AAAA-MM-DD", ErrParcelasInvalidas, i+1)
+		}
+		soma = soma.Add(p.Valor)
+	}
+	if !soma.Equal(total) {
+		return fmt.Errorf("%w: soma das parcelas (%s) difere do total da venda (%s)",
+			ErrParcelasInvalidas, soma.RoundTo(2), total)
+	}
+	return nil
+}
+
+// AlocarPagamentos distribui o valor pago pelas parcelas em ordem de
+// vencimento e define a situação de cada uma na data hoje
+func AlocarPagamentos(parcelas []Parcela, pago Decimal, hoje time.Time) {
+	dia := hoje.Format("2006-01-02")
+	restante := pago
+	for i := range parcelas {
+		p := &parcelas[i]
+		switch {
+		case restante.Compare(p.Valor) >= 0:
+			p.ValorPago = p.Valor
+		case restante.Sign() > 0:
+			p.ValorPago = restante
+		default:
+			p.ValorPago = DecimalFromInt(0)
+		}
+		restante = restante.Sub(p.ValorPago)
+
+		switch {
+		case p.ValorPago.Equal(p.Valor):
+			p.Situacao = ParcelaPaga
+		case p.Vencimento < dia:
+			p.Situacao = ParcelaVencida
+		case p.ValorPago.Sign() > 0:
+			p.Situacao = ParcelaParcial
+		default:
+			p.Situacao = ParcelaAberta
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func dec(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestDividirParcelas(t *testing.T) {
+	primeiro := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	parcelas, err := DividirParcelas(dec("100.00"), 3, primeiro, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := []struct {
+		valor, vencimento string
+	}{
+		{"33.33", "2024-01-31"},
+		{"33.33", "2024-03-01"},
+		{"33.34", "2024-03-31"},
+	}
+	for i, e := range esperado {
+		if !parcelas[i].Valor.Equal(dec(e.valor)) || parcelas[i].Vencimento != e.vencimento || parcelas[i].Numero != i+1 {
+			t.Errorf("parcela %d = %+v, esperado %+v", i+1, parcelas[i], e)
+		}
+	}
+
+	if _, err := DividirParcelas(dec("10"), 0, primeiro, 30); err == nil {
+		t.Error("zero parcelas deveria falhar")
+	}
+}
+
+func TestAlocarPagamentos(t *testing.T) {
+	parcelas := []Parcela{
+		{Valor: dec("40"), Vencimento: "2024-01-10"},
+		{Valor: dec("40"), Vencimento: "2024-02-10"},
+		{Valor: dec("40"), Vencimento: "2024-03-10"},
+	}
+	AlocarPagamentos(parcelas, dec("50"), time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC))
+
+	situacoes := []SituacaoParcela{ParcelaPaga, ParcelaVencida, ParcelaAberta}
+	pagos := []string{"40", "10", "0"}
+	for i := range parcelas {
+		if parcelas[i].Situacao != situacoes[i] || !parcelas[i].ValorPago.Equal(dec(pagos[i])) {
+			t.Errorf("parcela %d = %s pago %s, esperado %s pago %s",
+				i+1, parcelas[i].Situacao, parcelas[i].ValorPago, situacoes[i], pagos[i])
+		}
+	}
+}
+
+func TestSituacaoPagamento(t *testing.T) {
+	casos := map[string]PaymentStatus{"0": PaymentStatusPending, "10": PaymentStatusPartial, "25.50": PaymentStatusPaid}
+	for pago, esperado := range casos {
+		if got := SituacaoPagamento(dec("25.5"), dec(pago)); got != esperado {
+			t.Errorf("SituacaoPagamento(25.5, %s) = %s, esperado %s", pago, got, esperado)
+		}
+	}
+}
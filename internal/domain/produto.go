@@ -3,15 +3,23 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type Produto struct {
-	ID                int64      `json:"id"`
-	Nome              string     `json:"nome"`
-	Fornecedor        Fornecedor `json:"fornecedor"`
-	CodigoFornecedor  string     `json:"codigo_fornecedor"`
-	QuantidadeEstoque int64      `json:"quantidade_estoque"`
-	Preco             Decimal    `json:"preco"`
+	ID                 int64      `json:"id"`
+	Nome               string     `json:"nome"`
+	Fornecedor         Fornecedor `json:"fornecedor"`
+	CodigoFornecedor   string     `json:"codigo_fornecedor"`
+	CodigoBarras       string     `json:"codigo_barras,omitempty"` // GTIN (EAN-8/13, UPC-A, GTIN-14)
+	QuantidadeEstoque  int64      `json:"quantidade_estoque"`
+	QuantidadeMinima   int64      `json:"qtd_minima"`
+	QuantidadeMaxima   int64      `json:"qtd_maxima"`
+	Preco              Decimal    `json:"preco"`
+	QuantidadeAvariada int64      `json:"qtd_avariada"` // devolvidas com avaria, fora do estoque vendável
+	Custo              Decimal    `json:"custo"`        // custo médio ponderado das entradas
+	Margem             *Decimal   `json:"margem,omitempty"`
+	Markup             *Decimal   `json:"markup,omitempty"`
 }
 
 func NewProduto(
@@ -54,9 +62,21 @@ func (p *Produto) Validate() error {
 	if p.QuantidadeEstoque < 0 {
 		return errors.New("estoque não pode ser negativo")
 	}
+	if p.CodigoBarras != "" && !GTINValido(p.CodigoBarras) {
+		return fmt.Errorf("%w: %q", ErrCodigoBarrasInvalido, p.CodigoBarras)
+	}
 	if p.Preco.Decimal == nil || p.Preco.Sign() < 0 {
 		return errors.New("preço não pode ser negativo")
 	}
+	if p.Custo.Decimal != nil && p.Custo.Sign() < 0 {
+		return errors.New("custo não pode ser negativo")
+	}
+	if p.QuantidadeMinima < 0 || p.QuantidadeMaxima < 0 {
+		return errors.New("níveis de estoque não podem ser negativos")
+	}
+	if p.QuantidadeMaxima > 0 && p.QuantidadeMaxima < p.QuantidadeMinima {
+		return errors.New("quantidade máxima não pode ser menor que a mínima")
+	}
 	return nil
 }
 
@@ -68,11 +88,16 @@ func (p *Produto) SetNome(nome string) error {
 	return nil
 }
 
-func (p *Produto) SetQuantidadeEstoque(quantidade int64) error {
-	if quantidade < 0 {
-		return errors.New("quantidade em estoque não pode ser negativa")
+// SetNiveisEstoque define o estoque mínimo e o nível alvo (máximo); zero desativa
+func (p *Produto) SetNiveisEstoque(minima, maxima int64) error {
+	if minima < 0 || maxima < 0 {
+		return errors.New("níveis de estoque não podem ser negativos")
+	}
+	if maxima > 0 && maxima < minima {
+		return errors.New("quantidade máxima não pode ser menor que a mínima")
 	}
-	p.QuantidadeEstoque = quantidade
+	p.QuantidadeMinima = minima
+	p.QuantidadeMaxima = maxima
 	return nil
 }
 
@@ -89,6 +114,66 @@ func (p *Produto) SetPreco(precoStr string) error {
 	return nil
 }
 
+// SetCusto altera o custo do produto com validação
+func (p *Produto) SetCusto(custoStr string) error {
+	custo, err := ParseDecimal(custoStr)
+	if err != nil {
+		return fmt.Errorf("custo inválido: %w", err)
+	}
+	if custo.Sign() < 0 {
+		return errors.New("custo não pode ser negativo")
+	}
+	p.Custo = custo.RoundTo(2)
+	return nil
+}
+
+// SetCodigoBarras define o código de barras do produto, sem espaços; vazio
+// remove o código. Códigos da faixa interna da loja só vêm da geração
+// automática: informados à mão são recusados, exceto o que o produto já tem.
+func (p *Produto) SetCodigoBarras(codigo string) error {
+	codigo = strings.Join(strings.Fields(codigo), "")
+	if codigo != "" && !GTINValido(codigo) {
+		return fmt.Errorf("%w: %q (EAN-8, UPC-A, EAN-13 ou GTIN-14 com dígito verificador)", ErrCodigoBarrasInvalido, codigo)
+	}
+	if CodigoBarrasInternoDaLoja(codigo) && codigo != p.CodigoBarras {
+		return fmt.Errorf("%w: %q", ErrCodigoBarrasReservado, codigo)
+	}
+	p.CodigoBarras = codigo
+	return nil
+}
+
+// CalcularMargens preenche Margem, o lucro sobre o preço, e Markup, o lucro
+// sobre o custo, ambos em percentual; ficam vazios sem preço ou sem custo
+func (p *Produto) CalcularMargens() {
+	p.Margem, p.Markup = nil, nil
+	if p.Preco.Decimal == nil || p.Custo.Decimal == nil || p.Custo.Sign() == 0 {
+		return
+	}
+	lucro := p.Preco.Sub(p.Custo)
+	if p.Preco.Sign() > 0 {
+		margem := percentualDe(lucro, p.Preco)
+		p.Margem = &margem
+	}
+	markup := percentualDe(lucro, p.Custo)
+	p.Markup = &markup
+}
+
+// PrecoSugerido é o custo acrescido de markup por cento, em centavos
+func (p *Produto) PrecoSugerido(markup Decimal) Decimal {
+	return p.Custo.Add(p.Custo.Mul(markup).Quo(DecimalFromInt(100))).RoundTo(2)
+}
+
+// CustoMedio devolve o custo médio ponderado depois da entrada de quantidade
+// unidades a custoEntrada sobre estoque unidades a custoAtual. Sem estoque
+// positivo ou sem custo conhecido (zero), o custo passa a ser o da entrada.
+func CustoMedio(estoque int64, custoAtual Decimal, quantidade int64, custoEntrada Decimal) Decimal {
+	if estoque <= 0 || custoAtual.Sign() == 0 {
+		return custoEntrada.RoundTo(2)
+	}
+	total := custoAtual.MulInt(estoque).Add(custoEntrada.MulInt(quantidade))
+	return total.Quo(DecimalFromInt(estoque + quantidade)).RoundTo(2)
+}
+
 // ValidateAndUpdate valida o produto após qualquer atualização
 func (p *Produto) ValidateAndUpdate() error {
 	return p.Validate()
@@ -0,0 +1,49 @@
+package domain
+
+import "testing"
+
+func TestCustoMedio(t *testing.T) {
+	casos := []struct {
+		estoque    int64
+		custoAtual string
+		quantidade int64
+		entrada    string
+		esperado   string
+	}{
+		{10, "4.00", 10, "5.00", "4.50"},
+		{3, "2.00", 4, "2.50", "2.29"}, // 16.00 / 7
+		{0, "4.00", 5, "6.00", "6.00"},
+		{-2, "4.00", 5, "6.00", "6.00"},
+		{8, "0", 2, "3.00", "3.00"},
+	}
+	for _, c := range casos {
+		got := CustoMedio(c.estoque, dec(c.custoAtual), c.quantidade, dec(c.entrada))
+		if !got.Equal(dec(c.esperado)) {
+			t.Errorf("CustoMedio(%d, %s, %d, %s) = %s, esperado %s",
+				c.estoque, c.custoAtual, c.quantidade, c.entrada, got, c.esperado)
+		}
+	}
+}
+
+func TestMargensEPrecoSugerido(t *testing.T) {
+	p := Produto{Preco: dec("10.00"), Custo: dec("6.00")}
+	p.CalcularMargens()
+	if p.Margem == nil || !p.Margem.Equal(dec("40.00")) || p.Markup == nil || !p.Markup.Equal(dec("66.67")) {
+		t.Errorf("margem %v markup %v", p.Margem, p.Markup)
+	}
+	if got := p.PrecoSugerido(dec("50")); !got.Equal(dec("9.00")) {
+		t.Errorf("PrecoSugerido(50) = %s", got)
+	}
+
+	p.Custo = dec("0")
+	p.CalcularMargens()
+	if p.Margem != nil || p.Markup != nil {
+		t.Errorf("sem custo: margem %v markup %v", p.Margem, p.Markup)
+	}
+
+	item := SaleItem{Quantity: 3, Total: dec("25.00")}
+	item.ApurarLucro(dec("6.15"))
+	if !item.CustoUnitario.Equal(dec("6.15")) || !item.LucroBruto.Equal(dec("6.55")) {
+		t.Errorf("lucro do item = %s", item.LucroBruto)
+	}
+}
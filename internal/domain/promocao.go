@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TipoPromocao identifica a regra de preço da promoção
+type TipoPromocao string
+
+const (
+	// PromocaoPercentualFornecedor dá Percentual de desconto nos produtos do fornecedor
+	PromocaoPercentualFornecedor TipoPromocao = "PERCENTUAL_FORNECEDOR"
+	// PromocaoLevePague cobra Pague a cada Leve unidades do produto
+	PromocaoLevePague TipoPromocao = "LEVE_PAGUE"
+	// PromocaoFaixaQuantidade cobra o preço da maior faixa atingida pela quantidade
+	PromocaoFaixaQuantidade TipoPromocao = "FAIXA_QUANTIDADE"
+)
+
+// FaixaPreco é o preço unitário a partir de QuantidadeMinima unidades
+type FaixaPreco struct {
+	QuantidadeMinima int     `json:"quantidade_minima"`
+	Preco            Decimal `json:"preco"`
+}
+
+// Promocao é uma regra de preço vigente de Inicio até Fim ("YYYY-MM-DD",
+// inclusive; Fim vazio não expira), aplicada automaticamente ao precificar
+// vendas e orçamentos
+type Promocao struct {
+	ID           int64        `json:"id"`
+	Nome         string       `json:"nome"`
+	Tipo         TipoPromocao `json:"tipo"`
+	FornecedorID int64        `json:"fornecedor_id,omitempty"`
+	ProdutoID    int64        `json:"produto_id,omitempty"`
+	Percentual   *Decimal     `json:"percentual,omitempty"`
+	Leve         int          `json:"leve,omitempty"`
+	Pague        int          `json:"pague,omitempty"`
+	Faixas       []FaixaPreco `json:"faixas,omitempty"`
+	Inicio       string       `json:"inicio"`
+	Fim          string       `json:"fim,omitempty"`
+	CriadaEm     time.Time    `json:"criada_em"`
+}
+
+// PromocaoAplicada registra no item a promoção usada e quanto ela tirou da linha
+type PromocaoAplicada struct {
+	ID    int64        `json:"id"`
+	Nome  string       `json:"nome,omitempty"`
+	Tipo  TipoPromocao `json:"tipo,omitempty"`
+	Valor Decimal      `json:"valor"`
+}
+
+// ErrPromocaoEncerrada indica promoção cuja vigência já terminou
+var ErrPromocaoEncerrada = errors.New("promoção já encerrada")
+
+// Validate confere os campos exigidos pelo tipo da promoção; sem inicio, a
+// promoção começa em hoje
+func (p *Promocao) Validate(hoje time.Time) error {
+	if p.Nome == "" {
+		return errors.New("nome da promoção é obrigatório")
+	}
+	if p.Inicio == "" {
+		p.Inicio = hoje.Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", p.Inicio); err != nil {
+		return fmt.Errorf("inicio inválido: %q", p.Inicio)
+	}
+	if p.Fim != "" {
+		if _, err := time.Parse("2006-01-02", p.Fim); err != nil {
+			return fmt.Errorf("fim inválido: %q", p.Fim)
+		}
+		if p.Fim < p.Inicio {
+			return errors.New("fim não pode ser anterior ao inicio")
+		}
+	}
+
+	switch p.Tipo {
+	case PromocaoPercentualFornecedor:
+		if p.FornecedorID <= 0 {
+			return errors.New("fornecedor_id é obrigatório")
+		}
+		if p.Percentual == nil || p.Percentual.Decimal == nil || p.Percentual.Sign() <= 0 ||
+			p.Percentual.Compare(DecimalFromInt(100)) > 0 {
+			return errors.New("percentual deve ser maior que 0 e no máximo 100")
+		}
+	case PromocaoLevePague:
+		if p.ProdutoID <= 0 {
+			return errors.New("produto_id é obrigatório")
+		}
+		if p.Pague <= 0 || p.Leve <= p.Pague {
+			return errors.New("leve deve ser maior que pague, e pague maior que zero")
+		}
+	case PromocaoFaixaQuantidade:
+		if p.ProdutoID <= 0 {
+			return errors.New("produto_id é obrigatório")
+		}
+		if len(p.Faixas) == 0 {
+			return errors.New("informe ao menos uma faixa")
+		}
+		vistas := make(map[int]bool, len(p.Faixas))
+		for _, f := range p.Faixas {
+			if f.QuantidadeMinima <= 1 {
+				return errors.New("quantidade_minima da faixa deve ser maior que 1")
+			}
+			if vistas[f.QuantidadeMinima] {
+				return fmt.Errorf("faixa de %d unidades repetida", f.QuantidadeMinima)
+			}
+			vistas[f.QuantidadeMinima] = true
+			if f.Preco.Decimal == nil || f.Preco.Sign() <= 0 {
+				return errors.New("preço da faixa deve ser positivo")
+			}
+		}
+		sort.Slice(p.Faixas, func(i, j int) bool { return p.Faixas[i].QuantidadeMinima < p.Faixas[j].QuantidadeMinima })
+	default:
+		return fmt.Errorf("tipo de promoção deve ser %s, %s ou %s",
+			PromocaoPercentualFornecedor, PromocaoLevePague, PromocaoFaixaQuantidade)
+	}
+	return nil
+}
+
+// Vigente indica se a promoção vale no dia
+func (p *Promocao) Vigente(dia time.Time) bool {
+	d := dia.Format("2006-01-02")
+	return p.Inicio <= d && (p.Fim == "" || d <= p.Fim)
+}
+
+// Encerrar faz a promoção valer só até o dia anterior a hoje. Na que começou
+// hoje ou ainda não começou, Fim fica antes de Inicio: sem dia de vigência.
+func (p *Promocao) Encerrar(hoje time.Time) error {
+	ontem := hoje.AddDate(0, 0, -1).Format("2006-01-02")
+	if p.Fim != "" && p.Fim <= ontem {
+		return ErrPromocaoEncerrada
+	}
+	p.Fim = ontem
+	return nil
+}
+
+// Reducao devolve quanto a promoção tira da linha com quantidade unidades do
+// produto (do fornecedor) ao preço unitário preco; zero quando não se aplica
+func (p *Promocao) Reducao(produtoID, fornecedorID int64, preco Decimal, quantidade int) Decimal {
+	zero := DecimalFromInt(0)
+	switch p.Tipo {
+	case PromocaoPercentualFornecedor:
+		if fornecedorID != p.FornecedorID {
+			return zero
+		}
+		return preco.MulInt(int64(quantidade)).Mul(*p.Percentual).Quo(DecimalFromInt(100)).RoundTo(2)
+	case PromocaoLevePague:
+		if produtoID != p.ProdutoID {
+			return zero
+		}
+		gratis := quantidade / p.Leve * (p.Leve - p.Pague)
+		return preco.MulInt(int64(gratis)).RoundTo(2)
+	case PromocaoFaixaQuantidade:
+		if produtoID != p.ProdutoID {
+			return zero
+		}
+		var faixa *FaixaPreco
+		for i := range p.Faixas {
+			if quantidade >= p.Faixas[i].QuantidadeMinima &&
+				(faixa == nil || p.Faixas[i].QuantidadeMinima > faixa.QuantidadeMinima) {
+				faixa = &p.Faixas[i]
+			}
+		}
+		if faixa == nil || faixa.Preco.Compare(preco) >= 0 {
+			return zero
+		}
+		return preco.Sub(faixa.Preco).MulInt(int64(quantidade)).RoundTo(2)
+	}
+	return zero
+}
+
+// Precificador é o serviço de preços das vendas: aplica a cada item a
+// promoção vigente mais vantajosa para o cliente e calcula os totais.
+// Promocoes deve trazer só as promoções vigentes no dia da venda.
+type Precificador struct {
+	Precos       map[int64]Decimal // preço de tabela por produto
+	Fornecedores map[int64]int64   // fornecedor de cada produto
+	Promocoes    []Promocao
+}
+
+// Precificar define a promoção de cada item, descartando a que tenha vindo
+// no payload, e chama CalcularTotais. Itens com preço autorizado não recebem
+// promoção; em empate vence a promoção mais antiga.
+func (p Precificador) Precificar(s *Sale) error {
+	for i := range s.Items {
+		item := &s.Items[i]
+		item.Promocao = nil
+		preco, ok := p.Precos[item.ProductID]
+		if !ok || item.PriceOverride != nil {
+			continue
+		}
+		for j := range p.Promocoes {
+			promo := &p.Promocoes[j]
+			reducao := promo.Reducao(item.ProductID, p.Fornecedores[item.ProductID], preco, item.Quantity)
+			if reducao.Sign() <= 0 || (item.Promocao != nil && reducao.Compare(item.Promocao.Valor) <= 0) {
+				continue
+			}
+			item.Promocao = &PromocaoAplicada{ID: promo.ID, Nome: promo.Nome, Tipo: promo.Tipo, Valor: reducao}
+		}
+	}
+	return s.CalcularTotais(p.Precos)
+}
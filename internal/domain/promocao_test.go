@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPrecificarPromocoes(t *testing.T) {
+	percentual := dec("10")
+	p := Precificador{
+		Precos:       map[int64]Decimal{1: dec("5.00"), 2: dec("2.50"), 3: dec("8.00"), 4: dec("3.00")},
+		Fornecedores: map[int64]int64{1: 7, 2: 7, 3: 9, 4: 7},
+		Promocoes: []Promocao{
+			{ID: 1, Nome: "Fornecedor 7", Tipo: PromocaoPercentualFornecedor, FornecedorID: 7, Percentual: &percentual},
+			{ID: 2, Nome: "Leve 3 pague 2", Tipo: PromocaoLevePague, ProdutoID: 1, Leve: 3, Pague: 2},
+			{ID: 3, Nome: "Atacado", Tipo: PromocaoFaixaQuantidade, ProdutoID: 3,
+				Faixas: []FaixaPreco{{QuantidadeMinima: 6, Preco: dec("7.00")}, {QuantidadeMinima: 12, Preco: dec("6.50")}}},
+		},
+	}
+	autorizado := dec("2.00")
+	s := Sale{Items: []SaleItem{
+		{ProductID: 1, Quantity: 7},  // leve/pague: 2 grátis = 10.00 > 10% de 35.00
+		{ProductID: 2, Quantity: 3},  // só o percentual do fornecedor: 0.75
+		{ProductID: 3, Quantity: 12}, // faixa de 12: 1.50 x 12
+		{ProductID: 4, Quantity: 1, PriceOverride: &autorizado, AuthorizedBy: "gerente"},
+	}, Perfil: PerfilGerente}
+	s.Items[3].Promocao = &PromocaoAplicada{ID: 1, Valor: dec("1.00")} // enviado pelo cliente, descartado
+	if err := p.Precificar(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	esperados := []struct {
+		promocao int64
+		valor    string
+		total    string
+	}{
+		{2, "10.00", "25.00"},
+		{1, "0.75", "6.75"},
+		{3, "18.00", "78.00"},
+		{0, "", "2.00"},
+	}
+	for i, e := range esperados {
+		item := s.Items[i]
+		if e.promocao == 0 {
+			if item.Promocao != nil {
+				t.Errorf("item %d com preço autorizado recebeu promoção %+v", item.ProductID, item.Promocao)
+			}
+		} else if item.Promocao == nil || item.Promocao.ID != e.promocao || !item.Promocao.Valor.Equal(dec(e.valor)) {
+			t.Errorf("item %d: promoção %+v, esperada %d de %s", item.ProductID, item.Promocao, e.promocao, e.valor)
+		}
+		if !item.Total.Equal(dec(e.total)) {
+			t.Errorf("item %d: total %s, esperado %s", item.ProductID, item.Total, e.total)
+		}
+	}
+	if !s.Total.Equal(dec("111.75")) || !s.ValorDesconto.Equal(dec("0")) {
+		t.Errorf("venda: total %s desconto %s", s.Total, s.ValorDesconto)
+	}
+
+	// abaixo da menor faixa e sem completar o leve/pague, vale o preço de tabela
+	s = Sale{Items: []SaleItem{{ProductID: 1, Quantity: 2}, {ProductID: 3, Quantity: 5}}}
+	p.Promocoes = p.Promocoes[1:]
+	if err := p.Precificar(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Items[0].Promocao != nil || s.Items[1].Promocao != nil || !s.Total.Equal(dec("50.00")) {
+		t.Errorf("sem promoção: %+v", s)
+	}
+}
+
+func TestPromocaoValidateVigencia(t *testing.T) {
+	hoje := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	invalidas := []Promocao{
+		{Nome: "Sem tipo"},
+		{Nome: "Sem fornecedor", Tipo: PromocaoPercentualFornecedor},
+		{Nome: "Leve menor", Tipo: PromocaoLevePague, ProdutoID: 1, Leve: 2, Pague: 2},
+		{Nome: "Sem faixas", Tipo: PromocaoFaixaQuantidade, ProdutoID: 1},
+		{Nome: "Datas", Tipo: PromocaoLevePague, ProdutoID: 1, Leve: 3, Pague: 2, Inicio: "2026-03-10", Fim: "2026-03-01"},
+	}
+	for _, p := range invalidas {
+		if err := p.Validate(hoje); err == nil {
+			t.Errorf("Validate(%q) aceitou promoção inválida", p.Nome)
+		}
+	}
+
+	p := Promocao{Nome: "Leve 3", Tipo: PromocaoLevePague, ProdutoID: 1, Leve: 3, Pague: 2, Fim: "2026-03-31"}
+	if err := p.Validate(hoje); err != nil {
+		t.Fatal(err)
+	}
+	if p.Inicio != "2026-03-10" || !p.Vigente(hoje) || p.Vigente(hoje.AddDate(0, 1, 0)) {
+		t.Errorf("vigência de %+v", p)
+	}
+	if err := p.Encerrar(hoje.AddDate(0, 0, 5)); err != nil || p.Fim != "2026-03-14" {
+		t.Errorf("Encerrar: fim %s, err %v", p.Fim, err)
+	}
+	if err := p.Encerrar(hoje.AddDate(0, 0, 10)); !errors.Is(err, ErrPromocaoEncerrada) {
+		t.Errorf("Encerrar de promoção encerrada: err = %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// TituloAberto é um valor a receber: uma parcela ainda não quitada ou, em
+// vendas sem plano de parcelas, o saldo da venda vencendo na data da venda
+type TituloAberto struct {
+	VendaID     int64   `json:"venda_id"`
+	ClienteID   int64   `json:"cliente_id"`
+	ClienteNome string  `json:"cliente_nome"`
+	Parcela     int     `json:"parcela,omitempty"`
+	Vencimento  string  `json:"vencimento"` // YYYY-MM-DD
+	Valor       Decimal `json:"valor"`
+	DiasAtraso  int     `json:"dias_atraso"`
+}
+
+// FaixasAtraso soma valores em aberto por dias de atraso (aging)
+type FaixasAtraso struct {
+	AVencer Decimal `json:"a_vencer"`
+	Ate30   Decimal `json:"0_30"`
+	De31a60 Decimal `json:"31_60"`
+	De61a90 Decimal `json:"61_90"`
+	Acima90 Decimal `json:"90_mais"`
+	Total   Decimal `json:"total"`
+}
+
+// NovasFaixasAtraso cria faixas zeradas
+func NovasFaixasAtraso() FaixasAtraso {
+	zero := DecimalFromInt(0)
+	return FaixasAtraso{AVencer: zero, Ate30: zero, De31a60: zero, De61a90: zero, Acima90: zero, Total: zero}
+}
+
+// Somar acrescenta o título à faixa correspondente ao seu atraso
+func (f *FaixasAtraso) Somar(t TituloAberto) {
+	switch {
+	case t.DiasAtraso < 0:
+		f.AVencer = f.AVencer.Add(t.Valor)
+	case t.DiasAtraso <= 30:
+		f.Ate30 = f.Ate30.Add(t.Valor)
+	case t.DiasAtraso <= 60:
+		f.De31a60 = f.De31a60.Add(t.Valor)
+	case t.DiasAtraso <= 90:
+		f.De61a90 = f.De61a90.Add(t.Valor)
+	default:
+		f.Acima90 = f.Acima90.Add(t.Valor)
+	}
+	f.Total = f.Total.Add(t.Valor)
+}
+
+// SaldoCliente é o valor em aberto de um cliente distribuído por atraso
+type SaldoCliente struct {
+	ClienteID int64        `json:"cliente_id"`
+	Nome      string       `json:"nome"`
+	Saldo     Decimal      `json:"saldo"`
+	Faixas    FaixasAtraso `json:"faixas"`
+}
+
+// DevedorVencido resume o que um cliente deve com vencimento já passado
+type DevedorVencido struct {
+	ClienteID      int64   `json:"cliente_id"`
+	Nome           string  `json:"nome"`
+	ValorVencido   Decimal `json:"valor_vencido"`
+	MaiorAtraso    int     `json:"maior_atraso"`
+	TitulosAbertos int     `json:"titulos"`
+}
+
+// TitulosDaVenda calcula os títulos em aberto de uma venda na data hoje, dado
+// o total já pago e o plano de parcelas (que pode ser vazio)
+func TitulosDaVenda(v Sale, pago Decimal, parcelas []Parcela, hoje time.Time) []TituloAberto {
+	titulo := func(valor Decimal, vencimento string, numero int) TituloAberto {
+		t := TituloAberto{
+			VendaID:    v.ID,
+			ClienteID:  v.ClientID,
+			Parcela:    numero,
+			Vencimento: vencimento,
+			Valor:      valor,
+			DiasAtraso: diasEntre(vencimento, hoje),
+		}
+		if v.Cliente != nil {
+			t.ClienteNome = v.Cliente.Nome
+		}
+		return t
+	}
+
+	if len(parcelas) == 0 {
+		saldo := v.Total.Sub(pago)
+		if saldo.Sign() <= 0 {
+			return nil
+		}
+		return []TituloAberto{titulo(saldo, v.DataVenda.Format("2006-01-02"), 0)}
+	}
+
+	AlocarPagamentos(parcelas, pago, hoje)
+	var titulos []TituloAberto
+	for _, p := range parcelas {
+		if aberto := p.Valor.Sub(p.ValorPago); aberto.Sign() > 0 {
+			titulos = append(titulos, titulo(aberto, p.Vencimento, p.Numero))
+		}
+	}
+	return titulos
+}
+
+// diasEntre conta os dias corridos de vencimento até hoje; negativo se ainda vai vencer
+func diasEntre(vencimento string, hoje time.Time) int {
+	v, err := time.Parse("2006-01-02", vencimento)
+	if err != nil {
+		return 0
+	}
+	h := time.Date(hoje.Year(), hoje.Month(), hoje.Day(), 0, 0, 0, 0, time.UTC)
+	return int(h.Sub(v).Hours() / 24)
+}
+
+// ResumirPorCliente agrupa os títulos em saldos por cliente, do maior saldo para o menor
+func ResumirPorCliente(titulos []TituloAberto) []SaldoCliente {
+	saldos := []SaldoCliente{}
+	indice := make(map[int64]int)
+	for _, t := range titulos {
+		pos, ok := indice[t.ClienteID]
+		if !ok {
+			pos = len(saldos)
+			indice[t.ClienteID] = pos
+			saldos = append(saldos, SaldoCliente{ClienteID: t.ClienteID, Nome: t.ClienteNome, Faixas: NovasFaixasAtraso()})
+		}
+		saldos[pos].Faixas.Somar(t)
+		saldos[pos].Saldo = saldos[pos].Faixas.Total
+	}
+	sort.SliceStable(saldos, func(i, j int) bool { return saldos[i].Saldo.Compare(saldos[j].Saldo) > 0 })
+	return saldos
+}
+
+// ListarVencidos agrupa por cliente os títulos com atraso. porAtraso ordena
+// pelo maior atraso; caso contrário, pelo maior valor vencido.
+func ListarVencidos(titulos []TituloAberto, porAtraso bool) []DevedorVencido {
+	devedores := []DevedorVencido{}
+	indice := make(map[int64]int)
+	for _, t := range titulos {
+		if t.DiasAtraso <= 0 {
+			continue
+		}
+		pos, ok := indice[t.ClienteID]
+		if !ok {
+			pos = len(devedores)
+			indice[t.ClienteID] = pos
+			devedores = append(devedores, DevedorVencido{ClienteID: t.ClienteID, Nome: t.ClienteNome,
+				ValorVencido: DecimalFromInt(0)})
+		}
+		d := &devedores[pos]
+		d.ValorVencido = d.ValorVencido.Add(t.Valor)
+		d.TitulosAbertos++
+		if t.DiasAtraso > d.MaiorAtraso {
+			d.MaiorAtraso = t.DiasAtraso
+		}
+	}
+	sort.SliceStable(devedores, func(i, j int) bool {
+		if porAtraso && devedores[i].MaiorAtraso != devedores[j].MaiorAtraso {
+			return devedores[i].MaiorAtraso > devedores[j].MaiorAtraso
+		}
+		return devedores[i].ValorVencido.Compare(devedores[j].ValorVencido) > 0
+	})
+	return devedores
+}
+
+type TipoLancamento string
+
+const (
+	LancamentoVenda        TipoLancamento = "VENDA"
+	LancamentoCancelamento TipoLancamento = "CANCELAMENTO"
+	LancamentoPagamento    TipoLancamento = "PAGAMENTO"
+	LancamentoDevolucao    TipoLancamento = "DEVOLUCAO"
+)
+
+// LancamentoExtrato é uma linha do extrato do cliente. Valor é positivo para
+// débitos (vendas) e negativo para créditos; Saldo acumula os lançamentos.
+type LancamentoExtrato struct {
+	Data    time.Time      `json:"data"`
+	Tipo    TipoLancamento `json:"tipo"`
+	VendaID int64          `json:"venda_id"`
+	Valor   Decimal        `json:"valor"`
+	Saldo   Decimal        `json:"saldo"`
+	Detalhe string         `json:"detalhe,omitempty"`
+}
+
+// Extrato é o histórico de vendas e pagamentos de um cliente num período
+type Extrato struct {
+	Cliente       Cliente             `json:"cliente"`
+	SaldoAnterior Decimal             `json:"saldo_anterior"`
+	Lancamentos   []LancamentoExtrato `json:"lancamentos"`
+	SaldoFinal    Decimal             `json:"saldo_final"`
+}
+
+// MontarExtrato ordena os lançamentos por data, calcula o saldo corrido e
+// mantém só os do período [inicio, fim]; datas zeradas não limitam. O que vem
+// antes de inicio compõe o saldo anterior.
+func MontarExtrato(cliente Cliente, lancamentos []LancamentoExtrato, inicio, fim time.Time) Extrato {
+	sort.SliceStable(lancamentos, func(i, j int) bool { return lancamentos[i].Data.Before(lancamentos[j].Data) })
+
+	extrato := Extrato{Cliente: cliente, SaldoAnterior: DecimalFromInt(0), Lancamentos: []LancamentoExtrato{}}
+	saldo := DecimalFromInt(0)
+	for _, l := range lancamentos {
+		if !fim.IsZero() && l.Data.After(fim) {
+			break
+		}
+		saldo = saldo.Add(l.Valor)
+		if !inicio.IsZero() && l.Data.Before(inicio) {
+			extrato.SaldoAnterior = saldo
+			continue
+		}
+		l.Saldo = saldo
+		extrato.Lancamentos = append(extrato.Lancamentos, l)
+	}
+	extrato.SaldoFinal = saldo
+	return extrato
+}
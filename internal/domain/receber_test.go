@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFaixasAtraso(t *testing.T) {
+	f := NovasFaixasAtraso()
+	for _, dias := range []int{-3, 0, 30, 31, 60, 61, 90, 91} {
+		f.Somar(TituloAberto{Valor: dec("1"), DiasAtraso: dias})
+	}
+	got := []Decimal{f.AVencer, f.Ate30, f.De31a60, f.De61a90, f.Acima90, f.Total}
+	esperado := []string{"1", "2", "2", "2", "1", "8"}
+	for i := range got {
+		if !got[i].Equal(dec(esperado[i])) {
+			t.Errorf("faixa %d = %s, esperado %s", i, got[i], esperado[i])
+		}
+	}
+}
+
+func TestTitulosDaVendaSemParcelas(t *testing.T) {
+	hoje := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	venda := Sale{ID: 1, ClientID: 2, Total: dec("100"), DataVenda: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+
+	titulos := TitulosDaVenda(venda, dec("30"), nil, hoje)
+	if len(titulos) != 1 || !titulos[0].Valor.Equal(dec("70")) || titulos[0].DiasAtraso != 9 || titulos[0].Vencimento != "2024-03-01" {
+		t.Errorf("títulos = %+v", titulos)
+	}
+	if titulos := TitulosDaVenda(venda, dec("100"), nil, hoje); len(titulos) != 0 {
+		t.Errorf("venda quitada gerou títulos: %+v", titulos)
+	}
+}
+
+func TestListarVencidos(t *testing.T) {
+	titulos := []TituloAberto{
+		{ClienteID: 1, Valor: dec("10"), DiasAtraso: 80},
+		{ClienteID: 2, Valor: dec("50"), DiasAtraso: 5},
+		{ClienteID: 2, Valor: dec("20"), DiasAtraso: 35},
+		{ClienteID: 3, Valor: dec("500"), DiasAtraso: -1},
+	}
+
+	porValor := ListarVencidos(titulos, false)
+	if len(porValor) != 2 || porValor[0].ClienteID != 2 || !porValor[0].ValorVencido.Equal(dec("70")) ||
+		porValor[0].MaiorAtraso != 35 || porValor[0].TitulosAbertos != 2 {
+		t.Errorf("por valor = %+v", porValor)
+	}
+	if porAtraso := ListarVencidos(titulos, true); porAtraso[0].ClienteID != 1 {
+		t.Errorf("por atraso = %+v", porAtraso)
+	}
+}
+
+func TestMontarExtrato(t *testing.T) {
+	dia := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	lancamentos := []LancamentoExtrato{
+		{Data: dia(20), Tipo: LancamentoPagamento, Valor: dec("-30")},
+		{Data: dia(1), Tipo: LancamentoVenda, Valor: dec("100")},
+		{Data: dia(10), Tipo: LancamentoVenda, Valor: dec("50")},
+		{Data: dia(25), Tipo: LancamentoPagamento, Valor: dec("-20")},
+	}
+
+	extrato := MontarExtrato(Cliente{ID: 1}, lancamentos, dia(5), dia(21))
+	if !extrato.SaldoAnterior.Equal(dec("100")) || len(extrato.Lancamentos) != 2 || !extrato.SaldoFinal.Equal(dec("120")) {
+		t.Fatalf("extrato = %+v", extrato)
+	}
+	if !extrato.Lancamentos[0].Saldo.Equal(dec("150")) || !extrato.Lancamentos[1].Saldo.Equal(dec("120")) {
+		t.Errorf("saldos corridos = %s, %s", extrato.Lancamentos[0].Saldo, extrato.Lancamentos[1].Saldo)
+	}
+}
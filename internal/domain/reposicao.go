@@ -0,0 +1,70 @@
+package domain
+
+// ItemReposicao é um produto abaixo do estoque mínimo com os dados usados para
+// sugerir a quantidade a comprar. Os campos produto_id, quantidade e
+// preco_unitario seguem o formato de CompraItem, para virar um pedido de compra.
+type ItemReposicao struct {
+	ProdutoID      int64   `json:"produto_id"`
+	Nome           string  `json:"nome"`
+	FornecedorID   int64   `json:"-"`
+	FornecedorNome string  `json:"-"`
+	Estoque        int64   `json:"estoque"`
+	QtdMinima      int64   `json:"qtd_minima"`
+	QtdMaxima      int64   `json:"qtd_maxima"`
+	EmPedido       int64   `json:"em_pedido"`
+	VendidoPeriodo int64   `json:"vendido_periodo"`
+	MediaDiaria    Decimal `json:"media_diaria"`
+	Quantidade     int64   `json:"quantidade"`
+	PrecoUnitario  Decimal `json:"preco_unitario"`
+}
+
+// SugestaoReposicao agrupa os itens a comprar de um mesmo fornecedor
+type SugestaoReposicao struct {
+	FornecedorID   int64           `json:"fornecedor_id"`
+	FornecedorNome string          `json:"fornecedor_nome"`
+	Items          []ItemReposicao `json:"items"`
+}
+
+// CalcularQuantidade sugere quanto comprar: o alvo é o mínimo mais a demanda
+// prevista para os próximos `cobertura` dias, pela média de venda dos últimos
+// `dias` dias, limitado à quantidade máxima quando ela existe. Do alvo são
+// descontados o estoque atual e o que já está em pedidos pendentes.
+func (i *ItemReposicao) CalcularQuantidade(dias, cobertura int) {
+	if dias <= 0 {
+		dias = 1
+	}
+	i.MediaDiaria = DecimalFromInt(i.VendidoPeriodo).Quo(DecimalFromInt(int64(dias))).RoundTo(2)
+
+	demanda := (i.VendidoPeriodo*int64(cobertura) + int64(dias) - 1) / int64(dias)
+	alvo := i.QtdMinima + demanda
+	if i.QtdMaxima > 0 && alvo > i.QtdMaxima {
+		alvo = i.QtdMaxima
+	}
+
+	i.Quantidade = alvo - i.Estoque - i.EmPedido
+	if i.Quantidade < 0 {
+		i.Quantidade = 0
+	}
+}
+
+// AgruparReposicao agrupa por fornecedor os itens com quantidade sugerida
+func AgruparReposicao(itens []ItemReposicao) []SugestaoReposicao {
+	sugestoes := []SugestaoReposicao{}
+	indice := make(map[int64]int)
+	for _, item := range itens {
+		if item.Quantidade <= 0 {
+			continue
+		}
+		pos, ok := indice[item.FornecedorID]
+		if !ok {
+			pos = len(sugestoes)
+			indice[item.FornecedorID] = pos
+			sugestoes = append(sugestoes, SugestaoReposicao{
+				FornecedorID:   item.FornecedorID,
+				FornecedorNome: item.FornecedorNome,
+			})
+		}
+		sugestoes[pos].Items = append(sugestoes[pos].Items, item)
+	}
+	return sugestoes
+}
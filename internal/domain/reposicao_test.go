@@ -0,0 +1,48 @@
+package domain
+
+import "testing"
+
+func TestCalcularQuantidadeReposicao(t *testing.T) {
+	casos := []struct {
+		nome       string
+		item       ItemReposicao
+		dias       int
+		cobertura  int
+		quantidade int64
+		media      string
+	}{
+		// 1 por dia: mínimo 5 + 15 dias de venda, menos estoque e pedido
+		{"demanda", ItemReposicao{Estoque: 3, EmPedido: 2, QtdMinima: 5, VendidoPeriodo: 30}, 30, 15, 15, "1.00"},
+		{"limitada ao máximo", ItemReposicao{Estoque: 3, EmPedido: 2, QtdMinima: 5, QtdMaxima: 12, VendidoPeriodo: 30}, 30, 15, 7, "1.00"},
+		// 10 em 30 dias por 7 dias são 2.33 unidades: arredonda para cima
+		{"demanda fracionada", ItemReposicao{QtdMinima: 1, VendidoPeriodo: 10}, 30, 7, 4, "0.33"},
+		{"estoque basta", ItemReposicao{Estoque: 40, QtdMinima: 5, VendidoPeriodo: 30}, 30, 15, 0, "1.00"},
+		{"sem período", ItemReposicao{QtdMinima: 2, VendidoPeriodo: 3}, 0, 1, 5, "3.00"},
+	}
+	for _, c := range casos {
+		c.item.CalcularQuantidade(c.dias, c.cobertura)
+		if c.item.Quantidade != c.quantidade || !c.item.MediaDiaria.Equal(dec(c.media)) {
+			t.Errorf("%s: quantidade %d média %s, esperado %d e %s",
+				c.nome, c.item.Quantidade, c.item.MediaDiaria, c.quantidade, c.media)
+		}
+	}
+}
+
+func TestAgruparReposicao(t *testing.T) {
+	itens := []ItemReposicao{
+		{ProdutoID: 1, FornecedorID: 7, FornecedorNome: "Sete", Quantidade: 4},
+		{ProdutoID: 2, FornecedorID: 3, FornecedorNome: "Três", Quantidade: 0},
+		{ProdutoID: 3, FornecedorID: 9, FornecedorNome: "Nove", Quantidade: 1},
+		{ProdutoID: 4, FornecedorID: 7, FornecedorNome: "Sete", Quantidade: 2},
+	}
+	sugestoes := AgruparReposicao(itens)
+	if len(sugestoes) != 2 || sugestoes[0].FornecedorID != 7 || sugestoes[1].FornecedorID != 9 {
+		t.Fatalf("sugestões = %+v", sugestoes)
+	}
+	if len(sugestoes[0].Items) != 2 || sugestoes[0].Items[1].ProdutoID != 4 || sugestoes[0].FornecedorNome != "Sete" {
+		t.Errorf("itens do fornecedor 7 = %+v", sugestoes[0])
+	}
+	if vazio := AgruparReposicao(nil); vazio == nil || len(vazio) != 0 {
+		t.Errorf("sem itens = %#v, esperado lista vazia", vazio)
+	}
+}
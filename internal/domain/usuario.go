@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// PerfilGerente é o perfil que define limites de desconto, libera crédito
+// acima do limite do cliente e autoriza preço abaixo da tabela
+const PerfilGerente = "gerente"
+
+// Usuario é quem opera o sistema. Ele se identifica pelo token gerado no
+// cadastro; nome e perfil vêm do cadastro, nunca do corpo ou dos cabeçalhos
+// da requisição.
+type Usuario struct {
+	ID     int64  `json:"id"`
+	Nome   string `json:"nome"`
+	Perfil string `json:"perfil"`
+	Ativo  bool   `json:"ativo"`
+}
+
+// Validate exige nome e perfil; o perfil é guardado em minúsculas, como as
+// chaves de limites de desconto
+func (u *Usuario) Validate() error {
+	u.Nome = strings.TrimSpace(u.Nome)
+	u.Perfil = strings.ToLower(strings.TrimSpace(u.Perfil))
+	if u.Nome == "" {
+		return errors.New("nome do usuário é obrigatório")
+	}
+	if u.Perfil == "" {
+		return errors.New("perfil do usuário é obrigatório")
+	}
+	return nil
+}
+
+// PodeAutorizar indica perfil que libera crédito, autoriza preço abaixo da
+// tabela e altera limites de desconto
+func PodeAutorizar(perfil string) bool {
+	return perfil == PerfilGerente
+}
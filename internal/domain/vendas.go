@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -12,22 +14,248 @@ const (
 	PaymentStatusPartial PaymentStatus = "PARCIAL"
 )
 
+// StatusVenda indica se a venda vale ou foi cancelada
+type StatusVenda string
+
+const (
+	StatusVendaAtiva     StatusVenda = "ATIVA"
+	StatusVendaCancelada StatusVenda = "CANCELADA"
+)
+
 type Sale struct {
-	ID            int64         `json:"id"`
-	ClientID      int64         `json:"cliente_id"`
-	DataVenda     time.Time     `json:"data"`
-	Total         Decimal       `json:"total"`
-	PaymentDate   *time.Time    `json:"data_pagamento,omitempty"`
-	PaymentStatus PaymentStatus `json:"status_pagamento"`
-	Items         []SaleItem    `json:"items"`
+	ID                 int64         `json:"id"`
+	ClientID           int64         `json:"cliente_id"`
+	Cliente            *Cliente      `json:"cliente,omitempty"`
+	DepositoID         int64         `json:"deposito_id"` // de onde sai a mercadoria; padrão DepositoPrincipal
+	DataVenda          time.Time     `json:"data"`
+	Bruto              Decimal       `json:"bruto"`
+	Desconto           *Desconto     `json:"desconto,omitempty"`
+	ValorDesconto      Decimal       `json:"valor_desconto"`
+	Total              Decimal       `json:"total"`
+	PaymentDate        *time.Time    `json:"data_pagamento,omitempty"`
+	PaymentStatus      PaymentStatus `json:"status_pagamento"`
+	Status             StatusVenda   `json:"status"`
+	CanceladaEm        *time.Time    `json:"cancelada_em,omitempty"`
+	MotivoCancelamento string        `json:"motivo_cancelamento,omitempty"`
+	CreditoLiberadoPor string        `json:"credito_liberado_por,omitempty"`
+	Items              []SaleItem    `json:"items"`
+	Pagamentos         []Pagamento   `json:"pagamentos,omitempty"`
+	Parcelas           []Parcela     `json:"parcelas,omitempty"`
+
+	// Perfil do usuário que registra a venda, usado para o limite de desconto
+	// e para autorizar preços fora da tabela
+	Perfil string `json:"-"`
+	// AutorizadaNoOrcamento indica venda de orçamento, cujos preços
+	// autorizados e descontos já foram conferidos com o perfil de quem o fez
+	AutorizadaNoOrcamento bool `json:"-"`
 }
 
-// SaleItem representa um item de uma venda
+// SaleItem representa um item de uma venda. UnitPrice e Total são calculados
+// pelo servidor a partir do preço de tabela (ListPrice); PriceOverride permite
+// vender por outro preço desde que AuthorizedBy identifique quem autorizou e
+// o perfil que registra a venda possa autorizar (PodeAutorizar).
+// Bruto é UnitPrice x Quantity menos a Promocao aplicada, se houver;
+// ValorDesconto soma o desconto do item e sua parte do desconto da venda, e
+// Total é o líquido. CustoUnitario é o custo médio do produto no momento da
+// venda e LucroBruto o Total menos o custo das unidades (só em vendas).
 type SaleItem struct {
-	ID        int64   `json:"id"`
-	SaleID    int64   `json:"venda_id"`
-	ProductID int64   `json:"produto_id"`
-	Quantity  int     `json:"quantidade"`
-	UnitPrice Decimal `json:"preco_unitario"`
-	Total     Decimal `json:"total"`
+	ID            int64             `json:"id"`
+	SaleID        int64             `json:"venda_id,omitempty"`
+	ProductID     int64             `json:"produto_id"`
+	Quantity      int               `json:"quantidade"`
+	ListPrice     Decimal           `json:"preco_tabela"`
+	PriceOverride *Decimal          `json:"preco_autorizado,omitempty"`
+	AuthorizedBy  string            `json:"autorizado_por,omitempty"`
+	UnitPrice     Decimal           `json:"preco_unitario"`
+	Promocao      *PromocaoAplicada `json:"promocao,omitempty"`
+	Bruto         Decimal           `json:"bruto"`
+	Desconto      *Desconto         `json:"desconto,omitempty"`
+	ValorDesconto Decimal           `json:"valor_desconto"`
+	Total         Decimal           `json:"total"`
+	CustoUnitario *Decimal          `json:"custo_unitario,omitempty"`
+	LucroBruto    *Decimal          `json:"lucro_bruto,omitempty"`
+}
+
+// ApurarLucro registra o custo unitário do produto e o lucro bruto do item;
+// chamar depois de CalcularTotais
+func (item *SaleItem) ApurarLucro(custo Decimal) {
+	lucro := item.Total.Sub(custo.MulInt(int64(item.Quantity))).RoundTo(2)
+	item.CustoUnitario, item.LucroBruto = &custo, &lucro
+}
+
+// ErrPrecoSemAutorizacao indica preço alterado sem informar quem autorizou
+var ErrPrecoSemAutorizacao = errors.New("preço diferente do de tabela exige autorizado_por")
+
+// ErrPrecoNaoAutorizado indica preço alterado por perfil que não pode autorizá-lo
+var ErrPrecoNaoAutorizado = errors.New("perfil sem permissão para autorizar preço")
+
+// TotalDivergenteError é retornado quando um valor enviado pelo cliente não
+// confere com o calculado pelo servidor
+type TotalDivergenteError struct {
+	Campo     string  `json:"campo"`
+	ProdutoID int64   `json:"produto_id,omitempty"`
+	Informado Decimal `json:"informado"`
+	Calculado Decimal `json:"calculado"`
+}
+
+func (e *TotalDivergenteError) Error() string {
+	if e.ProdutoID != 0 {
+		return fmt.Sprintf("%s do produto %d divergente: informado %s, calculado %s",
+			e.Campo, e.ProdutoID, e.Informado, e.Calculado)
+	}
+	return fmt.Sprintf("%s divergente: informado %s, calculado %s", e.Campo, e.Informado, e.Calculado)
+}
+
+// Validate confere cliente e itens da venda
+func (s *Sale) Validate() error {
+	if s.ClientID <= 0 {
+		return errors.New("cliente inválido")
+	}
+	for i := range s.Pagamentos {
+		if err := s.Pagamentos[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if s.Desconto != nil {
+		if err := s.Desconto.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.ValidarItens()
+}
+
+// ValidarItens confere apenas os itens, para alterações que não trocam o cliente
+func (s *Sale) ValidarItens() error {
+	if len(s.Items) == 0 {
+		return errors.New("a venda precisa de ao menos um item")
+	}
+	vistos := make(map[int64]bool, len(s.Items))
+	for _, item := range s.Items {
+		if item.ProductID <= 0 {
+			return errors.New("produto inválido")
+		}
+		if vistos[item.ProductID] {
+			return fmt.Errorf("produto %d repetido na venda", item.ProductID)
+		}
+		vistos[item.ProductID] = true
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantidade do produto %d deve ser positiva", item.ProductID)
+		}
+		if item.PriceOverride != nil {
+			if item.PriceOverride.Decimal == nil || item.PriceOverride.Sign() < 0 {
+				return fmt.Errorf("preço autorizado do produto %d não pode ser negativo", item.ProductID)
+			}
+			if item.AuthorizedBy == "" {
+				return fmt.Errorf("produto %d: %w", item.ProductID, ErrPrecoSemAutorizacao)
+			}
+		}
+		if item.Desconto != nil {
+			if err := item.Desconto.Validate(); err != nil {
+				return fmt.Errorf("produto %d: %w", item.ProductID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CalcularTotais aplica os preços de tabela (ou o preço autorizado, se o perfil
+// da venda puder autorizá-lo ou o orçamento de origem já o tiver autorizado) a
+// cada item, a promoção já definida no item (ver Precificador), os descontos
+// do item e o da venda, este rateado entre os itens pelo valor líquido de cada
+// um, calcula os totais com arredondamento em centavos e confere os valores
+// que o cliente tenha enviado. Valores ausentes no payload são apenas
+// preenchidos.
+func (s *Sale) CalcularTotais(precos map[int64]Decimal) error {
+	bruto := DecimalFromInt(0)
+	liquidos := make([]Decimal, len(s.Items))
+	for i := range s.Items {
+		item := &s.Items[i]
+		preco, ok := precos[item.ProductID]
+		if !ok {
+			return fmt.Errorf("produto %d sem preço cadastrado", item.ProductID)
+		}
+		item.ListPrice = preco
+
+		unitario := preco
+		if item.PriceOverride != nil {
+			if item.AuthorizedBy == "" {
+				return fmt.Errorf("produto %d: %w", item.ProductID, ErrPrecoSemAutorizacao)
+			}
+			if !PodeAutorizar(s.Perfil) && !s.AutorizadaNoOrcamento {
+				return fmt.Errorf("produto %d: %w", item.ProductID, ErrPrecoNaoAutorizado)
+			}
+			unitario = *item.PriceOverride
+		}
+		if item.UnitPrice.Decimal != nil && !item.UnitPrice.Equal(unitario) {
+			return &TotalDivergenteError{Campo: "preco_unitario", ProdutoID: item.ProductID,
+				Informado: item.UnitPrice, Calculado: unitario}
+		}
+		item.UnitPrice = unitario
+
+		item.Bruto = unitario.MulInt(int64(item.Quantity)).RoundTo(2)
+		if item.Promocao != nil {
+			item.Bruto = item.Bruto.Sub(item.Promocao.Valor)
+		}
+		desconto, err := item.Desconto.Calcular(item.Bruto)
+		if err != nil {
+			return fmt.Errorf("produto %d: %w", item.ProductID, err)
+		}
+		item.ValorDesconto = desconto
+		liquidos[i] = item.Bruto.Sub(desconto)
+		bruto = bruto.Add(item.Bruto)
+	}
+
+	subtotal := DecimalFromInt(0)
+	for _, l := range liquidos {
+		subtotal = subtotal.Add(l)
+	}
+	descontoVenda, err := s.Desconto.Calcular(subtotal)
+	if err != nil {
+		return err
+	}
+
+	total := DecimalFromInt(0)
+	for i, parte := range ratearDesconto(descontoVenda, liquidos) {
+		item := &s.Items[i]
+		item.ValorDesconto = item.ValorDesconto.Add(parte)
+		linha := item.Bruto.Sub(item.ValorDesconto)
+		if item.Total.Decimal != nil && !item.Total.Equal(linha) {
+			return &TotalDivergenteError{Campo: "total", ProdutoID: item.ProductID,
+				Informado: item.Total, Calculado: linha}
+		}
+		item.Total = linha
+		total = total.Add(linha)
+	}
+
+	if s.Total.Decimal != nil && !s.Total.Equal(total) {
+		return &TotalDivergenteError{Campo: "total", Informado: s.Total, Calculado: total}
+	}
+	s.Bruto = bruto
+	s.ValorDesconto = bruto.Sub(total)
+	s.Total = total
+	return nil
+}
+
+// ConferirDesconto recusa, com *DescontoAcimaDoLimiteError, desconto de item
+// ou total da venda acima de maximo pontos percentuais. Deve ser chamada
+// depois de CalcularTotais.
+func (s *Sale) ConferirDesconto(perfil string, maximo Decimal) error {
+	for _, item := range s.Items {
+		if item.Desconto == nil || item.Bruto.Sign() == 0 {
+			continue
+		}
+		desconto, err := item.Desconto.Calcular(item.Bruto)
+		if err != nil {
+			return err
+		}
+		if p := percentualDe(desconto, item.Bruto); p.Compare(maximo) > 0 {
+			return &DescontoAcimaDoLimiteError{Perfil: perfil, ProdutoID: item.ProductID, Percentual: p, Maximo: maximo}
+		}
+	}
+	if s.ValorDesconto.Sign() > 0 {
+		if p := percentualDe(s.ValorDesconto, s.Bruto); p.Compare(maximo) > 0 {
+			return &DescontoAcimaDoLimiteError{Perfil: perfil, Percentual: p, Maximo: maximo}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,126 @@
+package etiqueta
+
+import (
+	"fmt"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// padroesCode128 são as larguras alternadas barra/espaço de cada valor do
+// Code128; 103 a 105 iniciam os subconjuntos A, B e C e 106 é a parada
+var padroesCode128 = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	inicioCode128B = 104
+	inicioCode128C = 105
+	paradaCode128  = 106
+)
+
+// Code128 codifica texto ASCII imprimível. Sequências só de dígitos com
+// tamanho par (como os GTIN) usam o subconjunto C, dois dígitos por símbolo,
+// e o resto usa o subconjunto B.
+func Code128(texto string) ([]bool, error) {
+	if texto == "" {
+		return nil, fmt.Errorf("code128: texto vazio")
+	}
+	var valores []int
+	if len(texto)%2 == 0 && somenteDigitos(texto) {
+		valores = append(valores, inicioCode128C)
+		for i := 0; i < len(texto); i += 2 {
+			valores = append(valores, int(texto[i]-'0')*10+int(texto[i+1]-'0'))
+		}
+	} else {
+		valores = append(valores, inicioCode128B)
+		for _, r := range texto {
+			if r < ' ' || r > '~' {
+				return nil, fmt.Errorf("code128: caractere %q fora do subconjunto B", r)
+			}
+			valores = append(valores, int(r-' '))
+		}
+	}
+
+	soma := valores[0]
+	for i, v := range valores[1:] {
+		soma += (i + 1) * v
+	}
+	valores = append(valores, soma%103, paradaCode128)
+
+	var barras []bool
+	for _, v := range valores {
+		for i, largura := range padroesCode128[v] {
+			for range int(largura - '0') {
+				barras = append(barras, i%2 == 0)
+			}
+		}
+	}
+	return barras, nil
+}
+
+// codigosEAN são os padrões L (ímpares) de cada dígito; o R é o complemento
+// do L e o G é o R invertido
+var codigosEAN = [10]string{
+	"0001101", "0011001", "0010011", "0111101", "0100011",
+	"0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// paridadesEAN dizem, pelo primeiro dígito, se cada dígito da metade
+// esquerda usa o padrão L ou G
+var paridadesEAN = [10]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// EAN13 codifica um EAN-13 válido nos 95 módulos do símbolo
+func EAN13(codigo string) ([]bool, error) {
+	if len(codigo) != 13 || !domain.GTINValido(codigo) {
+		return nil, fmt.Errorf("ean-13 %q: %w", codigo, domain.ErrCodigoBarrasInvalido)
+	}
+
+	barras := make([]bool, 0, 95)
+	guarda := func(padrao string) {
+		for _, c := range padrao {
+			barras = append(barras, c == '1')
+		}
+	}
+	guarda("101")
+	paridade := paridadesEAN[codigo[0]-'0']
+	for i := 1; i <= 6; i++ {
+		l := codigosEAN[codigo[i]-'0']
+		for j := range 7 {
+			if paridade[i-1] == 'L' {
+				barras = append(barras, l[j] == '1')
+			} else {
+				barras = append(barras, l[6-j] == '0')
+			}
+		}
+	}
+	guarda("01010")
+	for i := 7; i <= 12; i++ {
+		for _, c := range codigosEAN[codigo[i]-'0'] {
+			barras = append(barras, c == '0')
+		}
+	}
+	guarda("101")
+	return barras, nil
+}
+
+func somenteDigitos(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
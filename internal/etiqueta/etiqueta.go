@@ -0,0 +1,115 @@
+// Package etiqueta desenha etiquetas de produto (nome, preço em reais e
+// código de barras) para folhas A4 de adesivos, em PDF, e para impressoras
+// térmicas, em ZPL. As barras são calculadas aqui e enviadas como retângulos,
+// sem depender de fontes ou comandos de código de barras da impressora.
+package etiqueta
+
+import (
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// zonaQuieta é a margem em branco, em módulos, exigida antes e depois das
+// barras (11 cobre o EAN-13; o Code128 pede 10)
+const zonaQuieta = 11
+
+// Etiqueta é o conteúdo impresso de uma etiqueta
+type Etiqueta struct {
+	Nome   string
+	Preco  string // já formatado, ex.: "R$ 1.234,56"
+	Codigo string // texto legível sob as barras
+	Barras []bool // módulos do símbolo, true = barra; vazio quando o produto não tem código
+}
+
+// Nova monta a etiqueta do produto: EAN-13 para códigos de 13 dígitos e
+// Code128 para os demais GTIN. Produto sem código de barras sai só com nome e preço.
+func Nova(p domain.Produto) (Etiqueta, error) {
+	e := Etiqueta{Nome: p.Nome, Preco: FormatarReais(p.Preco), Codigo: p.CodigoBarras}
+	var err error
+	switch {
+	case p.CodigoBarras == "":
+	case len(p.CodigoBarras) == 13:
+		e.Barras, err = EAN13(p.CodigoBarras)
+	default:
+		e.Barras, err = Code128(p.CodigoBarras)
+	}
+	return e, err
+}
+
+// FormatarReais escreve o valor como moeda brasileira: "R$ 1.234,56"
+func FormatarReais(valor domain.Decimal) string {
+	if valor.Decimal == nil {
+		valor = domain.DecimalFromInt(0)
+	}
+	texto := valor.RoundTo(2).String()
+	sinal := ""
+	if strings.HasPrefix(texto, "-") {
+		sinal, texto = "-", texto[1:]
+	}
+	inteiro, centavos, _ := strings.Cut(texto, ".")
+	centavos = (centavos + "00")[:2]
+
+	var b strings.Builder
+	for i, r := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return sinal + "R$ " + b.String() + "," + centavos
+}
+
+// trechos devolve início e largura, em módulos, de cada barra contínua
+func trechos(barras []bool) [][2]int {
+	var t [][2]int
+	for i := 0; i < len(barras); i++ {
+		if !barras[i] {
+			continue
+		}
+		inicio := i
+		for i < len(barras) && barras[i] {
+			i++
+		}
+		t = append(t, [2]int{inicio, i - inicio})
+	}
+	return t
+}
+
+// quebrarLinhas divide texto em até linhas linhas de no máximo largura
+// caracteres, quebrando entre palavras; o excesso vira reticências
+func quebrarLinhas(texto string, largura, linhas int) []string {
+	var resultado []string
+	atual := ""
+	palavras := strings.Fields(texto)
+	for i, palavra := range palavras {
+		candidata := palavra
+		if atual != "" {
+			candidata = atual + " " + palavra
+		}
+		if len([]rune(candidata)) <= largura {
+			atual = candidata
+			continue
+		}
+		if atual != "" {
+			resultado = append(resultado, atual)
+		}
+		atual = palavra
+		if len(resultado) == linhas-1 {
+			atual = strings.Join(palavras[i:], " ")
+			break
+		}
+	}
+	if atual != "" {
+		resultado = append(resultado, atual)
+	}
+	if len(resultado) > linhas {
+		resultado = resultado[:linhas]
+	}
+	if n := len(resultado); n > 0 {
+		if r := []rune(resultado[n-1]); len(r) > largura {
+			resultado[n-1] = string(r[:largura-3]) + "..."
+		}
+	}
+	return resultado
+}
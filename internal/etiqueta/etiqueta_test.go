@@ -0,0 +1,190 @@
+package etiqueta
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func modulos(barras []bool) string {
+	var b strings.Builder
+	for _, m := range barras {
+		if m {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func TestPadroesCode128(t *testing.T) {
+	for v, p := range padroesCode128 {
+		soma, barras := 0, 0
+		for i, c := range p {
+			soma += int(c - '0')
+			if i%2 == 0 {
+				barras += int(c - '0')
+			}
+		}
+		if v == paradaCode128 {
+			soma -= 2 // barra final de terminação
+		}
+		if soma != 11 || barras%2 != 0 {
+			t.Errorf("padrão %d (%s): %d módulos, %d de barra", v, p, soma, barras)
+		}
+	}
+}
+
+func TestCode128(t *testing.T) {
+	// subconjunto B: 104 + 1*33 + 2*34 + 3*35 = 310, verificador 310 % 103 = 1
+	barras, err := Code128("ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := modulos(barras)
+	if len(m) != 11*5+13 {
+		t.Fatalf("ABC: %d módulos", len(m))
+	}
+	if got, want := m[44:55], modulos(simbolo(1)); got != want {
+		t.Errorf("verificador de ABC = %s, want %s", got, want)
+	}
+
+	// só dígitos e tamanho par usa o subconjunto C
+	barras, err = Code128("12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = modulos(barras)
+	if got, want := m[:11], modulos(simbolo(inicioCode128C)); got != want {
+		t.Errorf("início de 12345678 = %s, want %s", got, want)
+	}
+	if len(m) != 11*6+13 {
+		t.Errorf("12345678: %d módulos", len(m))
+	}
+	if !strings.HasSuffix(m, "1100011101011") {
+		t.Errorf("12345678 sem parada: %s", m)
+	}
+
+	if _, err := Code128("café"); err == nil {
+		t.Error("Code128 aceitou caractere fora do ASCII")
+	}
+}
+
+func simbolo(v int) []bool {
+	var b []bool
+	for i, c := range padroesCode128[v] {
+		for range int(c - '0') {
+			b = append(b, i%2 == 0)
+		}
+	}
+	return b
+}
+
+func TestEAN13(t *testing.T) {
+	barras, err := EAN13("4006381333931")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := modulos(barras)
+	if len(m) != 95 {
+		t.Fatalf("%d módulos", len(m))
+	}
+	if m[:3] != "101" || m[45:50] != "01010" || m[92:] != "101" {
+		t.Errorf("guardas erradas: %s", m)
+	}
+	// primeiro dígito 4 = LGLLGG: 0 em L, 0 em G, 6 em L
+	if got := m[3:24]; got != "0001101"+"0100111"+"0101111" {
+		t.Errorf("metade esquerda = %s", got)
+	}
+	// metade direita em R: 3 e 3
+	if got := m[50:64]; got != "1000010"+"1000010" {
+		t.Errorf("metade direita = %s", got)
+	}
+
+	if _, err := EAN13("4006381333932"); err == nil {
+		t.Error("EAN13 aceitou dígito verificador errado")
+	}
+}
+
+func TestFormatarReais(t *testing.T) {
+	casos := map[string]string{
+		"0":        "R$ 0,00",
+		"4.5":      "R$ 4,50",
+		"999.99":   "R$ 999,99",
+		"1234.567": "R$ 1.234,57",
+		"1000000":  "R$ 1.000.000,00",
+		"-12345.1": "-R$ 12.345,10",
+	}
+	for valor, want := range casos {
+		d, err := domain.ParseDecimal(valor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatarReais(d); got != want {
+			t.Errorf("FormatarReais(%s) = %q, want %q", valor, got, want)
+		}
+	}
+}
+
+func TestQuebrarLinhas(t *testing.T) {
+	got := quebrarLinhas("Arroz Tipo 1 Agulhinha Pacote Grande de Cinco Quilos", 20, 2)
+	if len(got) != 2 || got[0] != "Arroz Tipo 1" || len([]rune(got[1])) > 20 || !strings.HasSuffix(got[1], "...") {
+		t.Errorf("quebrarLinhas = %q", got)
+	}
+	if got := quebrarLinhas("Feijão", 20, 2); len(got) != 1 || got[0] != "Feijão" {
+		t.Errorf("quebrarLinhas curto = %q", got)
+	}
+}
+
+func TestGerarPDFeZPL(t *testing.T) {
+	preco, _ := domain.ParseDecimal("12.9")
+	com, err := Nova(domain.Produto{Nome: "Café (500g)", Preco: preco, CodigoBarras: "4006381333931"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sem, err := Nova(domain.Produto{Nome: "Pão_francês ^kg", Preco: preco})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sem.Barras) != 0 {
+		t.Error("produto sem código ganhou barras")
+	}
+	etiquetas := make([]Etiqueta, 0, 30)
+	for range 15 {
+		etiquetas = append(etiquetas, com, sem)
+	}
+
+	var pdf bytes.Buffer
+	if err := GerarPDF(&pdf, etiquetas, FolhaA4, 20); err != nil {
+		t.Fatal(err)
+	}
+	s := pdf.String()
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Error("PDF sem cabeçalho ou final")
+	}
+	// começando na posição 20 de 24, as 30 etiquetas ocupam 3 folhas
+	if !strings.Contains(s, "/Count 3") {
+		t.Error("PDF não tem 3 páginas")
+	}
+	if !strings.Contains(s, "(Caf\xe9 \\(500g\\)) Tj") || !strings.Contains(s, "(R$ 12,90) Tj") {
+		t.Error("PDF sem nome em WinAnsi ou preço")
+	}
+
+	var zpl bytes.Buffer
+	if err := GerarZPL(&zpl, etiquetas[:2], RoloPadrao); err != nil {
+		t.Fatal(err)
+	}
+	z := zpl.String()
+	if strings.Count(z, "^XA") != 2 || strings.Count(z, "^XZ") != 2 {
+		t.Errorf("ZPL deveria ter 2 etiquetas:\n%s", z)
+	}
+	if !strings.Contains(z, "^FDCafé (500g)^FS") || !strings.Contains(z, "^FDPão_5Ffrancês _5Ekg^FS") {
+		t.Errorf("ZPL sem nomes:\n%s", z)
+	}
+	if !strings.Contains(z, "^GB") || !strings.Contains(z, "^FD4006381333931^FS") {
+		t.Errorf("ZPL sem barras:\n%s", z)
+	}
+}
@@ -0,0 +1,160 @@
+package etiqueta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const pontosPorMM = 72 / 25.4
+
+// Folha descreve a folha de adesivos, com medidas em milímetros
+type Folha struct {
+	Largura, Altura                 float64 // página
+	Colunas, Linhas                 int
+	LarguraEtiqueta, AlturaEtiqueta float64
+	MargemEsquerda, MargemSuperior  float64
+	EspacoColunas, EspacoLinhas     float64
+}
+
+// FolhaA4 é a folha A4 de 3 x 8 etiquetas de 70 x 37 mm (tipo Pimaco A4356)
+var FolhaA4 = Folha{
+	Largura: 210, Altura: 297,
+	Colunas: 3, Linhas: 8,
+	LarguraEtiqueta: 70, AlturaEtiqueta: 37,
+	MargemSuperior: 0.5,
+}
+
+// PorFolha é quantas etiquetas cabem numa folha
+func (f Folha) PorFolha() int {
+	return f.Colunas * f.Linhas
+}
+
+// GerarPDF escreve as etiquetas em PDF, preenchendo a folha da esquerda para a
+// direita e de cima para baixo. inicio (a partir de 0) pula posições já usadas
+// da primeira folha, para reaproveitar folhas pela metade.
+func GerarPDF(w io.Writer, etiquetas []Etiqueta, folha Folha, inicio int) error {
+	if folha.PorFolha() <= 0 {
+		return fmt.Errorf("folha sem etiquetas")
+	}
+	inicio = max(0, inicio%folha.PorFolha())
+
+	var paginas []string
+	var pagina strings.Builder
+	for i, e := range etiquetas {
+		pos := (inicio + i) % folha.PorFolha()
+		if pos == 0 && pagina.Len() > 0 {
+			paginas = append(paginas, pagina.String())
+			pagina.Reset()
+		}
+		col, lin := pos%folha.Colunas, pos/folha.Colunas
+		x := (folha.MargemEsquerda + float64(col)*(folha.LarguraEtiqueta+folha.EspacoColunas)) * pontosPorMM
+		topo := (folha.Altura - folha.MargemSuperior - float64(lin)*(folha.AlturaEtiqueta+folha.EspacoLinhas)) * pontosPorMM
+		desenharEtiquetaPDF(&pagina, e, x, topo-folha.AlturaEtiqueta*pontosPorMM,
+			folha.LarguraEtiqueta*pontosPorMM, folha.AlturaEtiqueta*pontosPorMM)
+	}
+	if pagina.Len() > 0 || len(paginas) == 0 {
+		paginas = append(paginas, pagina.String())
+	}
+	return escreverPDF(w, paginas, folha.Largura*pontosPorMM, folha.Altura*pontosPorMM)
+}
+
+// desenharEtiquetaPDF escreve os operadores de conteúdo de uma etiqueta cujo
+// canto inferior esquerdo é (x, y), em pontos: nome em até duas linhas, preço
+// em destaque e, embaixo, as barras com o código legível
+func desenharEtiquetaPDF(b *strings.Builder, e Etiqueta, x, y, largura, altura float64) {
+	const (
+		margem     = 8.0
+		fonteNome  = 8.0
+		fontePreco = 16.0
+		fonteCod   = 7.0
+	)
+	util := largura - 2*margem
+	linha := y + altura - margem - fonteNome
+	for _, texto := range quebrarLinhas(e.Nome, int(util/(fonteNome*0.55)), 2) {
+		escreverTexto(b, "F1", fonteNome, x+margem, linha, texto)
+		linha -= fonteNome + 2
+	}
+	linha -= fontePreco - fonteNome
+	escreverTexto(b, "F2", fontePreco, x+margem, linha, e.Preco)
+
+	if len(e.Barras) == 0 {
+		return
+	}
+	base := y + margem + fonteCod + 2
+	alturaBarras := linha - 6 - base
+	if alturaBarras < 10 {
+		return
+	}
+	modulo := util / float64(len(e.Barras)+2*zonaQuieta)
+	inicio := x + margem + zonaQuieta*modulo
+	for _, t := range trechos(e.Barras) {
+		fmt.Fprintf(b, "%.3f %.3f %.3f %.3f re\n", inicio+float64(t[0])*modulo, base, float64(t[1])*modulo, alturaBarras)
+	}
+	b.WriteString("f\n")
+	// dígitos da Helvetica têm 0,556 em de largura
+	larguraCodigo := float64(len(e.Codigo)) * fonteCod * 0.556
+	escreverTexto(b, "F1", fonteCod, x+(largura-larguraCodigo)/2, y+margem, e.Codigo)
+}
+
+func escreverTexto(b *strings.Builder, fonte string, tamanho, x, y float64, s string) {
+	fmt.Fprintf(b, "BT /%s %.1f Tf %.3f %.3f Td (%s) Tj ET\n", fonte, tamanho, x, y, textoPDF(s))
+}
+
+// textoPDF converte para WinAnsiEncoding, que coincide com o Latin-1 nos
+// acentos do português, e escapa os delimitadores de string do PDF
+func textoPDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// escreverPDF monta o arquivo com uma página por conteúdo: catálogo, árvore
+// de páginas, as duas fontes padrão e, para cada página, o objeto da página e
+// o stream de conteúdo, seguidos da tabela xref
+func escreverPDF(w io.Writer, paginas []string, largura, altura float64) error {
+	var buf bytes.Buffer
+	var offsets []int
+	objeto := func(corpo string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), corpo)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(paginas))
+	for i := range paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objeto("<< /Type /Catalog /Pages 2 0 R >>")
+	objeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(paginas)))
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, conteudo := range paginas {
+		objeto(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", largura, altura, 6+2*i))
+		objeto(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(conteudo), conteudo))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
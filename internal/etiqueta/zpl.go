@@ -0,0 +1,61 @@
+package etiqueta
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Rolo descreve a etiqueta da impressora térmica: tamanho em milímetros e
+// resolução em pontos por milímetro (8 para 203 dpi, 12 para 300 dpi)
+type Rolo struct {
+	Largura, Altura float64
+	PontosPorMM     int
+}
+
+// RoloPadrao é a etiqueta de gôndola de 50 x 30 mm a 203 dpi
+var RoloPadrao = Rolo{Largura: 50, Altura: 30, PontosPorMM: 8}
+
+// GerarZPL escreve um formato ^XA...^XZ por etiqueta. O texto vai em UTF-8
+// (^CI28) e as barras como caixas ^GB, com módulo de largura inteira em pontos
+// para que a impressora não distorça as barras.
+func GerarZPL(w io.Writer, etiquetas []Etiqueta, rolo Rolo) error {
+	largura := int(rolo.Largura * float64(rolo.PontosPorMM))
+	altura := int(rolo.Altura * float64(rolo.PontosPorMM))
+	margem := 2 * rolo.PontosPorMM
+	util := largura - 2*margem
+	fonteNome, fontePreco, fonteCod := 3*rolo.PontosPorMM, 5*rolo.PontosPorMM, 2*rolo.PontosPorMM
+
+	var b strings.Builder
+	for _, e := range etiquetas {
+		fmt.Fprintf(&b, "^XA\n^CI28\n^PW%d\n^LL%d\n", largura, altura)
+		fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FB%d,2,0,L^FH^FD%s^FS\n",
+			margem, margem, fonteNome, fonteNome, util, textoZPL(e.Nome))
+		linha := margem + 2*fonteNome + 4
+		fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FH^FD%s^FS\n", margem, linha, fontePreco, fontePreco, textoZPL(e.Preco))
+		linha += fontePreco + 4
+
+		if len(e.Barras) > 0 {
+			modulo := max(1, util/(len(e.Barras)+2*zonaQuieta))
+			inicio := margem + (util-modulo*len(e.Barras))/2
+			alturaBarras := altura - margem - fonteCod - 4 - linha
+			if alturaBarras >= rolo.PontosPorMM*5 {
+				for _, t := range trechos(e.Barras) {
+					espessura := t[1] * modulo
+					fmt.Fprintf(&b, "^FO%d,%d^GB%d,%d,%d^FS\n", inicio+t[0]*modulo, linha, espessura, alturaBarras, espessura)
+				}
+				fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FB%d,1,0,C^FH^FD%s^FS\n",
+					margem, altura-margem-fonteCod, fonteCod, fonteCod, util, textoZPL(e.Codigo))
+			}
+		}
+		b.WriteString("^XZ\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// textoZPL troca os caracteres de comando (^ e ~) e o indicador de ^FH (_)
+// pela forma hexadecimal, para que o nome do produto não quebre o formato
+func textoZPL(s string) string {
+	return strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// AvaliacaoEstoque retorna http.HandlerFunc com o valor do estoque por produto
+// e por fornecedor. Parâmetros: data (YYYY-MM-DD, padrão hoje), metodo
+// (CUSTO_MEDIO, o padrão, ou PEPS) e formato=csv para baixar uma planilha.
+func AvaliacaoEstoque(mr *repository.MovimentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := r.URL.Query().Get("data")
+		if data == "" {
+			data = time.Now().Format("2006-01-02")
+		} else if _, err := time.Parse("2006-01-02", data); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "data deve estar no formato YYYY-MM-DD")
+			return
+		}
+		metodo := domain.AvaliacaoCustoMedio
+		if v := r.URL.Query().Get("metodo"); v != "" {
+			metodo = domain.MetodoAvaliacao(v)
+		}
+		if err := metodo.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		formato := r.URL.Query().Get("formato")
+		if formato != "" && formato != "json" && formato != "csv" {
+			RespondWithError(w, http.StatusBadRequest, "formato deve ser json ou csv")
+			return
+		}
+
+		produtos, movimentos, err := mr.BuscarBaseAvaliacao(data)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao avaliar estoque: %v", err))
+			return
+		}
+		avaliacao := domain.AvaliarEstoque(data, metodo, produtos, movimentos)
+
+		if formato == "csv" {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition",
+				fmt.Sprintf(`attachment; filename="avaliacao-estoque-%s-%s.csv"`, data, metodo))
+			if err := escreverAvaliacaoCSV(w, avaliacao); err != nil {
+				log.Printf("Erro ao escrever CSV da avaliação: %v", err)
+			}
+			return
+		}
+		RespondOK(w, avaliacao)
+	}
+}
+
+// escreverAvaliacaoCSV grava uma linha por produto, o subtotal de cada
+// fornecedor e o total geral
+func escreverAvaliacaoCSV(w http.ResponseWriter, a domain.AvaliacaoEstoque) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"produto_id", "produto", "fornecedor_id", "fornecedor", "quantidade", "custo_unitario", "valor"})
+	var quantidade int64
+	for _, p := range a.Produtos {
+		quantidade += p.Quantidade
+		cw.Write([]string{
+			strconv.FormatInt(p.ProdutoID, 10),
+			p.Nome,
+			strconv.FormatInt(p.FornecedorID, 10),
+			p.Fornecedor,
+			strconv.FormatInt(p.Quantidade, 10),
+			p.CustoUnitario.String(),
+			p.Valor.String(),
+		})
+	}
+	for _, f := range a.Fornecedores {
+		cw.Write([]string{"", "SUBTOTAL", strconv.FormatInt(f.FornecedorID, 10), f.Nome,
+			strconv.FormatInt(f.Quantidade, 10), "", f.Valor.String()})
+	}
+	cw.Write([]string{"", "TOTAL", "", "", strconv.FormatInt(quantidade, 10), "", a.Total.String()})
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -22,6 +24,10 @@ func CriarCliente(cr *repository.ClienteRepository) http.HandlerFunc {
 			RespondWithError(w, http.StatusBadRequest, "Erro ao decodificar JSON")
 			return
 		}
+		if err := cliente.ValidarCredito(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		cliente.DataCadastro = time.Now().Format("2006-01-02")
 
 		result, err := cr.SalvarCliente(&cliente)
@@ -50,7 +56,7 @@ func BuscarClientes(cr *repository.ClienteRepository) http.HandlerFunc {
 		offset := (page - 1) * limit
 		clientes, err := cr.BuscarClientes(filters, limit, offset)
 		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprint("Erro ao buscar clientes: %v", err))
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar clientes: %v", err))
 			return
 		}
 		RespondOK(w, clientes)
@@ -67,6 +73,10 @@ func DeleteCliente(cr *repository.ClienteRepository) http.HandlerFunc {
 			return
 		}
 		err = cr.DeletarCliente(id)
+		if errors.Is(err, repository.ErrClienteEmUso) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
 		if err != nil {
 			log.Printf("Erro ao executar query de delete: %v", err)
 			RespondWithError(w, http.StatusInternalServerError, "Erro interno")
@@ -92,14 +102,31 @@ func UpdateCliente(cr *repository.ClienteRepository) http.HandlerFunc {
 			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
 			return
 		}
-		result, err := cr.AtualizarCliente(id, domain.Cliente{Nome: *input.Nome, Telefone: *input.Telefone})
+		var dados domain.Cliente
+		if input.Nome != nil {
+			dados.Nome = *input.Nome
+		}
+		if input.Telefone != nil {
+			dados.Telefone = *input.Telefone
+		}
+		if dados.Nome == "" && dados.Telefone == "" {
+			RespondWithError(w, http.StatusBadRequest, "Nenhum campo para atualizar")
+			return
+		}
+		result, err := cr.AtualizarCliente(id, dados)
 		if err != nil {
 			RespondWithError(w, http.StatusInternalServerError, "Erro ao executar update cliente")
+			return
 		}
 		if row, _ := result.RowsAffected(); row == 0 {
 			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		}
+		cliente, err := cr.BuscarClientePorId(id)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Erro ao buscar cliente atualizado")
+			return
 		}
-		cliente, _ := cr.BuscarClientePorId(id)
 
 		RespondOK(w, cliente)
 
@@ -117,9 +144,78 @@ func GetClienteById(cr *repository.ClienteRepository) http.HandlerFunc {
 		}
 
 		cliente, err := cr.BuscarClientePorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		}
 		if err != nil {
 			RespondWithError(w, http.StatusInternalServerError, "Erro ao executar busca de cliente por id")
+			return
 		}
 		RespondOK(w, cliente)
 	}
 }
+
+// GetCreditoCliente retorna http.HandlerFunc com limite, saldo em aberto e
+// crédito disponível do cliente
+func GetCreditoCliente(cr *repository.ClienteRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		situacao, err := cr.BuscarSituacaoCredito(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar crédito: %v", err))
+			return
+		}
+		RespondOK(w, situacao)
+	}
+}
+
+// UpdateCreditoCliente retorna http.HandlerFunc que define limite de crédito e
+// bloqueio do cliente. limite_credito null remove o limite. A rota é restrita
+// ao perfil gerente, o mesmo que libera crédito acima do limite.
+func UpdateCreditoCliente(cr *repository.ClienteRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var input struct {
+			LimiteCredito *domain.Decimal `json:"limite_credito"`
+			Bloqueado     bool            `json:"bloqueado"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		cliente := domain.Cliente{ID: id, LimiteCredito: input.LimiteCredito, Bloqueado: input.Bloqueado}
+		if err := cliente.ValidarCredito(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := cr.DefinirCredito(id, cliente.LimiteCredito, cliente.Bloqueado); errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao definir crédito: %v", err))
+			return
+		}
+
+		situacao, err := cr.BuscarSituacaoCredito(id)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar crédito: %v", err))
+			return
+		}
+		RespondOK(w, situacao)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+	"github.com/julio-pupim/lojaestoque/migrations"
+)
+
+// Só o gerente altera limite e bloqueio de crédito: quem mais pudesse fazê-lo
+// contornaria a liberação de crédito restrita ao gerente
+func TestCreditoClienteExigeGerente(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("abrindo banco: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migracoes, err := database.CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatalf("carregando migrações: %v", err)
+	}
+	if _, err := database.AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatalf("aplicando migrações: %v", err)
+	}
+
+	ur := repository.NewUsuarioRepository(db)
+	tokenVendedor, err := ur.CriarUsuario(&domain.Usuario{Nome: "Bia", Perfil: "vendedor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenGerente, err := ur.CriarUsuario(&domain.Usuario{Nome: "Ana", Perfil: domain.PerfilGerente})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr := repository.NewClienteRepository(db)
+	cliente := domain.Cliente{Nome: "Maria", Telefone: "11999990000", DataCadastro: "2024-01-15"}
+	if _, err := cr.SalvarCliente(&cliente); err != nil {
+		t.Fatal(err)
+	}
+	if err := cr.DefinirCredito(1, nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(middleware.Usuario(ur))
+	r.With(middleware.RequerPerfil(domain.PerfilGerente)).Put("/clientes/{id}/credito", UpdateCreditoCliente(cr))
+	put := func(token string) int {
+		req := httptest.NewRequest(http.MethodPut, "/clientes/1/credito",
+			strings.NewReader(`{"limite_credito": "5000", "bloqueado": false}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for nome, token := range map[string]string{"anônimo": "", "vendedor": tokenVendedor} {
+		if got := put(token); got != http.StatusForbidden {
+			t.Errorf("%s: status %d, esperado 403", nome, got)
+		}
+	}
+	if situacao, err := cr.BuscarSituacaoCredito(1); err != nil || !situacao.Bloqueado {
+		t.Fatalf("crédito alterado por quem não é gerente: %+v, err %v", situacao, err)
+	}
+
+	if got := put(tokenGerente); got != http.StatusOK {
+		t.Fatalf("gerente: status %d", got)
+	}
+	if situacao, _ := cr.BuscarSituacaoCredito(1); situacao.Bloqueado {
+		t.Errorf("situação após o gerente = %+v", situacao)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarCompra retorna http.HandlerFunc que registra um pedido de compra
+func CriarCompra(cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var compra domain.Compra
+		if err := json.NewDecoder(r.Body).Decode(&compra); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := compra.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := cr.SalvarCompra(&compra)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Produto não encontrado: %v", err))
+			return
+		case errors.Is(err, repository.ErrProdutoDeOutroFornecedor), errors.Is(err, repository.ErrDepositoInexistente),
+			errors.Is(err, domain.ErrDepositoInativo):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao salvar compra: %v", err))
+			return
+		}
+		RespondCreated(w, compra)
+	}
+}
+
+// BuscarCompras retorna http.HandlerFunc que busca compras com filtros e paginação
+func BuscarCompras(cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("fornecedor_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err == nil {
+				filters["fornecedor_id"] = id
+			}
+		}
+		if v := r.URL.Query().Get("status"); v != "" {
+			filters["status"] = v
+		}
+		if v := r.URL.Query().Get("data_inicio"); v != "" {
+			filters["data_inicio"] = v
+		}
+		if v := r.URL.Query().Get("data_fim"); v != "" {
+			filters["data_fim"] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		compras, err := cr.BuscarCompras(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar compras: %v", err))
+			return
+		}
+		RespondOK(w, compras)
+	}
+}
+
+// GetCompraById retorna http.HandlerFunc que busca uma compra com seus itens
+func GetCompraById(cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		compra, err := cr.BuscarCompraPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Compra não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar compra: %v", err))
+			return
+		}
+		RespondOK(w, compra)
+	}
+}
+
+// ReceberCompra retorna http.HandlerFunc que dá entrada da compra no estoque.
+// O corpo é opcional e pode trazer, por produto, o custo efetivamente pago e
+// os lotes recebidos (numero, validade YYYY-MM-DD e quantidade).
+func ReceberCompra(cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var dto struct {
+			Items []struct {
+				ProdutoID     int64           `json:"produto_id"`
+				PrecoUnitario *domain.Decimal `json:"preco_unitario"`
+				Lotes         []domain.Lote   `json:"lotes"`
+			} `json:"items"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		custos := make(map[int64]domain.Decimal, len(dto.Items))
+		lotes := make(map[int64][]domain.Lote)
+		for _, item := range dto.Items {
+			if len(item.Lotes) == 0 && item.PrecoUnitario == nil {
+				RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("informe o custo ou os lotes do produto %d", item.ProdutoID))
+				return
+			}
+			if item.PrecoUnitario != nil {
+				if item.PrecoUnitario.Decimal == nil || item.PrecoUnitario.Sign() < 0 {
+					RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("custo do produto %d não pode ser negativo", item.ProdutoID))
+					return
+				}
+				custos[item.ProdutoID] = *item.PrecoUnitario
+			}
+			for i := range item.Lotes {
+				if err := item.Lotes[i].Validate(); err != nil {
+					RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("produto %d: %v", item.ProdutoID, err))
+					return
+				}
+			}
+			lotes[item.ProdutoID] = append(lotes[item.ProdutoID], item.Lotes...)
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		compra, err := cr.ReceberCompra(id, custos, lotes, usuario)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Compra ou item não encontrado: %v", err))
+			return
+		case errors.Is(err, repository.ErrCompraJaRecebida), errors.Is(err, repository.ErrCompraCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.Is(err, repository.ErrLotesExcedemItem), errors.Is(err, domain.ErrValidadeLoteDivergente):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao receber compra: %v", err))
+			return
+		}
+		RespondOK(w, compra)
+	}
+}
+
+// CancelarCompra retorna http.HandlerFunc que cancela um pedido de compra
+// ainda não recebido. O corpo é opcional e pode trazer o motivo.
+func CancelarCompra(cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var input struct {
+			Motivo string `json:"motivo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		compra, err := cr.CancelarCompra(id, input.Motivo)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Compra não encontrada")
+			return
+		case errors.Is(err, repository.ErrCompraJaRecebida), errors.Is(err, repository.ErrCompraCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao cancelar compra: %v", err))
+			return
+		}
+		RespondOK(w, compra)
+	}
+}
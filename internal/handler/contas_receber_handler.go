@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// SaldosReceber retorna http.HandlerFunc com o saldo em aberto de cada cliente
+// distribuído por faixa de atraso, mais o total geral por faixa
+func SaldosReceber(cr *repository.ContasReceberRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		titulos, err := cr.BuscarTitulosAbertos(0, time.Now())
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar contas a receber: %v", err))
+			return
+		}
+
+		total := domain.NovasFaixasAtraso()
+		for _, t := range titulos {
+			total.Somar(t)
+		}
+		RespondOK(w, map[string]any{
+			"total":    total,
+			"clientes": domain.ResumirPorCliente(titulos),
+		})
+	}
+}
+
+// ClientesVencidos retorna http.HandlerFunc que lista os clientes com valores
+// vencidos. ordem=atraso ordena pelo maior atraso; o padrão é pelo maior valor.
+func ClientesVencidos(cr *repository.ContasReceberRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ordem := r.URL.Query().Get("ordem")
+		if ordem != "" && ordem != "valor" && ordem != "atraso" {
+			RespondWithError(w, http.StatusBadRequest, "ordem deve ser valor ou atraso")
+			return
+		}
+
+		titulos, err := cr.BuscarTitulosAbertos(0, time.Now())
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar contas a receber: %v", err))
+			return
+		}
+		RespondOK(w, domain.ListarVencidos(titulos, ordem == "atraso"))
+	}
+}
+
+// ExtratoCliente retorna http.HandlerFunc com vendas, pagamentos e saldo
+// corrido do cliente, opcionalmente limitado por data_inicio e data_fim
+func ExtratoCliente(cr *repository.ContasReceberRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var inicio, fim time.Time
+		if v := r.URL.Query().Get("data_inicio"); v != "" {
+			if inicio, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+				RespondWithError(w, http.StatusBadRequest, "data_inicio deve estar no formato AAAA-MM-DD")
+				return
+			}
+		}
+		if v := r.URL.Query().Get("data_fim"); v != "" {
+			if fim, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+				RespondWithError(w, http.StatusBadRequest, "data_fim deve estar no formato AAAA-MM-DD")
+				return
+			}
+			fim = fim.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+
+		extrato, err := cr.BuscarExtrato(id, inicio, fim)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao montar extrato: %v", err))
+			return
+		}
+
+		titulos, err := cr.BuscarTitulosAbertos(id, time.Now())
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar contas a receber: %v", err))
+			return
+		}
+		RespondOK(w, map[string]any{
+			"extrato":         extrato,
+			"titulos_abertos": titulos,
+		})
+	}
+}
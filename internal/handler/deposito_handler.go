@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarDeposito retorna http.HandlerFunc que cadastra um local de estoque
+func CriarDeposito(dr *repository.DepositoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var d domain.Deposito
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := d.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := dr.CriarDeposito(&d); errors.Is(err, repository.ErrNomeDepositoEmUso) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao salvar depósito: %v", err))
+			return
+		}
+		RespondCreated(w, d)
+	}
+}
+
+// BuscarDepositos retorna http.HandlerFunc que lista os depósitos, o
+// principal primeiro; ativo=true ou ativo=false filtra pela situação
+func BuscarDepositos(dr *repository.DepositoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("ativo"); v != "" {
+			ativo, err := strconv.ParseBool(v)
+			if err != nil {
+				RespondWithError(w, http.StatusBadRequest, "ativo deve ser true ou false")
+				return
+			}
+			filters["ativo"] = ativo
+		}
+
+		depositos, err := dr.BuscarDepositos(filters)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar depósitos: %v", err))
+			return
+		}
+		RespondOK(w, depositos)
+	}
+}
+
+// UpdateDeposito retorna http.HandlerFunc que renomeia, desativa ou reativa
+// o depósito: {"nome": "...", "ativo": false}
+func UpdateDeposito(dr *repository.DepositoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var dto struct {
+			Nome  *string `json:"nome"`
+			Ativo *bool   `json:"ativo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		d, err := dr.BuscarDepositoPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Depósito não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar depósito: %v", err))
+			return
+		}
+		if dto.Nome != nil {
+			d.Nome = *dto.Nome
+		}
+		if dto.Ativo != nil {
+			d.Ativo = *dto.Ativo
+		}
+		if err := d.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = dr.AtualizarDeposito(&d)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Depósito não encontrado")
+			return
+		case errors.Is(err, repository.ErrNomeDepositoEmUso), errors.Is(err, repository.ErrDepositoComEstoque),
+			errors.Is(err, repository.ErrDesativarPrincipal):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao atualizar depósito: %v", err))
+			return
+		}
+		RespondOK(w, d)
+	}
+}
+
+// EstoqueDeposito retorna http.HandlerFunc que lista os produtos com saldo no depósito
+func EstoqueDeposito(dr *repository.DepositoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+		if _, err := dr.BuscarDepositoPorId(id); errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Depósito não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar depósito: %v", err))
+			return
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		saldos, err := dr.BuscarEstoqueDeposito(id, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar estoque: %v", err))
+			return
+		}
+		RespondOK(w, saldos)
+	}
+}
+
+// EstoqueProdutoPorDeposito retorna http.HandlerFunc com o saldo do produto
+// em cada depósito onde há mercadoria
+func EstoqueProdutoPorDeposito(dr *repository.DepositoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		saldos, err := dr.BuscarEstoqueProduto(id)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar estoque: %v", err))
+			return
+		}
+		RespondOK(w, saldos)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// BuscarLimitesDesconto retorna http.HandlerFunc que lista o desconto máximo
+// de cada perfil
+func BuscarLimitesDesconto(dr *repository.DescontoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limites, err := dr.BuscarLimites()
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar limites de desconto: %v", err))
+			return
+		}
+		RespondOK(w, limites)
+	}
+}
+
+// DefinirLimiteDesconto retorna http.HandlerFunc que cria ou altera o
+// desconto máximo, em percentual, do perfil da URL; a rota é restrita ao
+// perfil gerente
+func DefinirLimiteDesconto(dr *repository.DescontoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			PercentualMaximo domain.Decimal `json:"percentual_maximo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		limite := domain.LimiteDesconto{Perfil: chi.URLParam(r, "perfil"), PercentualMaximo: input.PercentualMaximo}
+		if err := limite.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := dr.DefinirLimite(limite); err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao definir limite de desconto: %v", err))
+			return
+		}
+		RespondOK(w, limite)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// RegistrarDevolucaoFornecedor retorna http.HandlerFunc que devolve ao
+// fornecedor itens de uma compra recebida, baixando o estoque
+func RegistrarDevolucaoFornecedor(dr *repository.DevolucaoFornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var devolucao domain.DevolucaoFornecedor
+		if err := json.NewDecoder(r.Body).Decode(&devolucao); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := devolucao.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		devolucao.CompraID = id
+		devolucao.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+
+		err = dr.RegistrarDevolucao(&devolucao)
+		var excede *domain.QuantidadeDevolucaoError
+		var semEstoque *repository.EstoqueInsuficienteError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Compra não encontrada")
+		case errors.Is(err, repository.ErrCompraNaoRecebida):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.As(err, &excede):
+			RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"error": excede.Error(),
+				"item":  excede,
+			})
+		case errors.As(err, &semEstoque):
+			RespondWithJSON(w, http.StatusConflict, map[string]any{
+				"error": "Estoque insuficiente",
+				"itens": semEstoque.Itens,
+			})
+		case errors.Is(err, repository.ErrAvariadaInsuficiente):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao registrar devolução: %v", err))
+		default:
+			RespondCreated(w, devolucao)
+		}
+	}
+}
+
+// BuscarDevolucoesFornecedor retorna http.HandlerFunc que lista as devoluções
+// ao fornecedor de uma compra
+func BuscarDevolucoesFornecedor(dr *repository.DevolucaoFornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		devolucoes, err := dr.BuscarDevolucoes(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Compra não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar devoluções: %v", err))
+			return
+		}
+		RespondOK(w, devolucoes)
+	}
+}
+
+// BaixarCreditoFornecedor retorna http.HandlerFunc que registra o recebimento
+// do crédito de uma devolução. O corpo é opcional e pode trazer recebido_em.
+func BaixarCreditoFornecedor(dr *repository.DevolucaoFornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		compraID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+		devolucaoID, err := strconv.ParseInt(chi.URLParam(r, "devolucaoId"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID da devolução inválido")
+			return
+		}
+
+		var input struct {
+			RecebidoEm time.Time `json:"recebido_em"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		devolucao, err := dr.BaixarCredito(compraID, devolucaoID, input.RecebidoEm)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Devolução não encontrada")
+			return
+		case errors.Is(err, repository.ErrCreditoJaRecebido):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao baixar crédito: %v", err))
+			return
+		}
+		RespondOK(w, devolucao)
+	}
+}
+
+// CreditosFornecedor retorna http.HandlerFunc com as devoluções do fornecedor
+// e o total de crédito ainda não recebido
+func CreditosFornecedor(dr *repository.DevolucaoFornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		creditos, err := dr.BuscarCreditos(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Fornecedor não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar créditos: %v", err))
+			return
+		}
+		RespondOK(w, creditos)
+	}
+}
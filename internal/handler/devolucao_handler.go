@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// RegistrarDevolucao retorna http.HandlerFunc que registra a devolução de
+// itens de uma venda, com reembolso ou crédito na loja do valor já pago
+func RegistrarDevolucao(dr *repository.DevolucaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var devolucao domain.Devolucao
+		if err := json.NewDecoder(r.Body).Decode(&devolucao); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := devolucao.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		devolucao.VendaID = id
+		devolucao.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+
+		err = dr.RegistrarDevolucao(&devolucao)
+		var excede *domain.QuantidadeDevolucaoError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+		case errors.Is(err, repository.ErrVendaCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		case errors.As(err, &excede):
+			RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+				"error": excede.Error(),
+				"item":  excede,
+			})
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao registrar devolução: %v", err))
+		default:
+			RespondCreated(w, devolucao)
+		}
+	}
+}
+
+// BuscarDevolucoes retorna http.HandlerFunc que lista as devoluções da venda
+func BuscarDevolucoes(dr *repository.DevolucaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		devolucoes, err := dr.BuscarDevolucoes(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar devoluções: %v", err))
+			return
+		}
+		RespondOK(w, devolucoes)
+	}
+}
+
+// CreditoLojaCliente retorna http.HandlerFunc com saldo e histórico do
+// crédito do cliente na loja
+func CreditoLojaCliente(dr *repository.DevolucaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		conta, err := dr.BuscarCreditoLoja(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Cliente não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar crédito na loja: %v", err))
+			return
+		}
+		RespondOK(w, conta)
+	}
+}
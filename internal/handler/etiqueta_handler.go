@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/etiqueta"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// maxEtiquetas limita o tamanho de um pedido de impressão
+const maxEtiquetas = 5000
+
+// GerarEtiquetas retorna http.HandlerFunc que desenha as etiquetas dos
+// produtos com nome, preço e código de barras. Parâmetros:
+//   - produtos=1,2,3 ou compra_id=N: com a compra (já recebida) sai uma
+//     etiqueta por unidade recebida de cada produto
+//   - copias: etiquetas por produto; com compra_id substitui a quantidade recebida
+//   - formato: pdf (padrão), folha A4 de adesivos, ou zpl, impressora térmica
+//   - inicio: posição (1 a 24) da primeira etiqueta na folha A4, para
+//     reaproveitar folhas já usadas
+func GerarEtiquetas(pr *repository.ProdutoRepository, cr *repository.CompraRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		formato := q.Get("formato")
+		if formato == "" {
+			formato = "pdf"
+		}
+		if formato != "pdf" && formato != "zpl" {
+			RespondWithError(w, http.StatusBadRequest, "formato deve ser pdf ou zpl")
+			return
+		}
+		copias := 0
+		if v := q.Get("copias"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxEtiquetas {
+				RespondWithError(w, http.StatusBadRequest,
+					fmt.Sprintf("copias deve ser um inteiro entre 1 e %d", maxEtiquetas))
+				return
+			}
+			copias = n
+		}
+		inicio := 1
+		if v := q.Get("inicio"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > etiqueta.FolhaA4.PorFolha() {
+				RespondWithError(w, http.StatusBadRequest,
+					fmt.Sprintf("inicio deve estar entre 1 e %d", etiqueta.FolhaA4.PorFolha()))
+				return
+			}
+			inicio = n
+		}
+
+		// ids na ordem de impressão e quantas etiquetas de cada; o total é
+		// conferido a cada soma, antes que possa estourar
+		var ids []int64
+		quantidades := map[int64]int64{}
+		var total int64
+		somar := func(id, n int64) bool {
+			if _, ok := quantidades[id]; !ok {
+				ids = append(ids, id)
+			}
+			quantidades[id] += n
+			total += n
+			if total > maxEtiquetas {
+				RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("no máximo %d etiquetas por pedido", maxEtiquetas))
+				return false
+			}
+			return true
+		}
+		switch {
+		case q.Get("produtos") != "" && q.Get("compra_id") != "":
+			RespondWithError(w, http.StatusBadRequest, "informe produtos ou compra_id, não ambos")
+			return
+		case q.Get("produtos") != "":
+			for _, s := range strings.Split(q.Get("produtos"), ",") {
+				id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+				if err != nil || id <= 0 {
+					RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("produto inválido: %q", s))
+					return
+				}
+				if !somar(id, int64(max(copias, 1))) {
+					return
+				}
+			}
+		case q.Get("compra_id") != "":
+			compraID, err := strconv.ParseInt(q.Get("compra_id"), 10, 64)
+			if err != nil {
+				RespondWithError(w, http.StatusBadRequest, "compra_id inválido")
+				return
+			}
+			compra, err := cr.BuscarCompraPorId(compraID)
+			if errors.Is(err, sql.ErrNoRows) {
+				RespondWithError(w, http.StatusNotFound, "Compra não encontrada")
+				return
+			} else if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar compra: %v", err))
+				return
+			}
+			if compra.Status != domain.StatusCompraRecebida {
+				RespondWithError(w, http.StatusConflict, fmt.Sprintf("compra %s: etiquetas só de compra recebida", compra.Status))
+				return
+			}
+			for _, item := range compra.Items {
+				n := item.Quantidade
+				if copias > 0 {
+					if _, ok := quantidades[item.ProdutoID]; ok {
+						continue
+					}
+					n = int64(copias)
+				}
+				if !somar(item.ProdutoID, n) {
+					return
+				}
+			}
+		default:
+			RespondWithError(w, http.StatusBadRequest, "informe produtos ou compra_id")
+			return
+		}
+
+		produtos, err := pr.BuscarPorIds(ids)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar produtos: %v", err))
+			return
+		}
+		etiquetas := make([]etiqueta.Etiqueta, 0, total)
+		for _, id := range ids {
+			p, ok := produtos[id]
+			if !ok {
+				RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Produto %d não encontrado", id))
+				return
+			}
+			e, err := etiqueta.Nova(p)
+			if err != nil {
+				RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("produto %d: %v", id, err))
+				return
+			}
+			for range quantidades[id] {
+				etiquetas = append(etiquetas, e)
+			}
+		}
+
+		if formato == "zpl" {
+			w.Header().Set("Content-Type", "application/zpl; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="etiquetas.zpl"`)
+			err = etiqueta.GerarZPL(w, etiquetas, etiqueta.RoloPadrao)
+		} else {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", `inline; filename="etiquetas.pdf"`)
+			err = etiqueta.GerarPDF(w, etiquetas, etiqueta.FolhaA4, inicio-1)
+		}
+		if err != nil {
+			log.Printf("Erro ao escrever etiquetas: %v", err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Pedidos grandes demais são recusados antes de consultar produtos ou montar
+// as etiquetas, por isso os repositórios podem ficar nulos
+func TestGerarEtiquetasLimite(t *testing.T) {
+	h := GerarEtiquetas(nil, nil)
+	consultas := []string{
+		fmt.Sprintf("produtos=1&copias=%d", maxEtiquetas+1),
+		"produtos=1&copias=9223372036854775807",
+		fmt.Sprintf("produtos=1,2&copias=%d", maxEtiquetas),
+		fmt.Sprintf("produtos=1,1,1,1&copias=%d", maxEtiquetas/2),
+	}
+	for _, consulta := range consultas {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/etiquetas?"+consulta, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, esperado 400", consulta, rec.Code)
+		}
+	}
+}
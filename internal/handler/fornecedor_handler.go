@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarFornecedor retorna http.HandlerFunc que cadastra um fornecedor
+func CriarFornecedor(fr *repository.FornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var f domain.Fornecedor
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		f.Id = 0
+		if err := f.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := fr.Salvar(&f); errors.Is(err, repository.ErrCNPJDuplicado) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao salvar fornecedor: %v", err))
+			return
+		}
+
+		RespondCreated(w, f)
+	}
+}
+
+// BuscarFornecedores retorna http.HandlerFunc que busca com filtros e paginação
+func BuscarFornecedores(fr *repository.FornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("nome"); v != "" {
+			filters["nome"] = v
+		}
+		if v := r.URL.Query().Get("cnpj"); v != "" {
+			filters["cnpj"] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		fornecedores, err := fr.Buscar(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar fornecedores: %v", err))
+			return
+		}
+		RespondOK(w, fornecedores)
+	}
+}
+
+// GetFornecedorById retorna http.HandlerFunc que busca um fornecedor pelo ID
+func GetFornecedorById(fr *repository.FornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		f, err := fr.BuscarPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Fornecedor não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar fornecedor: %v", err))
+			return
+		}
+		RespondOK(w, f)
+	}
+}
+
+// UpdateFornecedor retorna http.HandlerFunc que altera os campos enviados
+func UpdateFornecedor(fr *repository.FornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var dto struct {
+			Nome               *string `json:"nome"`
+			CNPJ               *string `json:"cnpj"`
+			Contato            *string `json:"contato"`
+			Telefone           *string `json:"telefone"`
+			Email              *string `json:"email"`
+			CondicoesPagamento *string `json:"condicoes_pagamento"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		f, err := fr.BuscarPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Fornecedor não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar fornecedor: %v", err))
+			return
+		}
+
+		// Aplica mudanças
+		if dto.Nome != nil {
+			f.Nome = *dto.Nome
+		}
+		if dto.CNPJ != nil {
+			f.CNPJ = *dto.CNPJ
+		}
+		if dto.Contato != nil {
+			f.Contato = *dto.Contato
+		}
+		if dto.Telefone != nil {
+			f.Telefone = *dto.Telefone
+		}
+		if dto.Email != nil {
+			f.Email = *dto.Email
+		}
+		if dto.CondicoesPagamento != nil {
+			f.CondicoesPagamento = *dto.CondicoesPagamento
+		}
+		if err := f.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := fr.Atualizar(&f); errors.Is(err, repository.ErrCNPJDuplicado) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao atualizar: %v", err))
+			return
+		}
+		RespondOK(w, f)
+	}
+}
+
+// DeleteFornecedor retorna http.HandlerFunc que exclui pelo ID
+func DeleteFornecedor(fr *repository.FornecedorRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		err = fr.Deletar(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Fornecedor não encontrado")
+			return
+		case errors.Is(err, repository.ErrFornecedorEmUso):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao deletar: %v", err))
+			return
+		}
+
+		RespondNoContent(w)
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// AbrirInventario retorna http.HandlerFunc que abre uma sessão de contagem,
+// congelando o estoque atual de cada produto no depósito. O corpo é opcional
+// e pode trazer a descricao e o deposito_id (padrão: o principal).
+func AbrirInventario(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var inventario domain.Inventario
+		if err := json.NewDecoder(r.Body).Decode(&inventario); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		inventario.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+
+		err := ir.AbrirInventario(&inventario)
+		switch {
+		case errors.Is(err, domain.ErrInventarioEmAndamento):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.Is(err, repository.ErrDepositoInexistente), errors.Is(err, domain.ErrDepositoInativo):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao abrir inventário: %v", err))
+			return
+		}
+		RespondCreated(w, inventario)
+	}
+}
+
+// BuscarInventarios retorna http.HandlerFunc que lista as sessões por status e deposito_id
+func BuscarInventarios(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("status"); v != "" {
+			filters["status"] = v
+		}
+		if v := r.URL.Query().Get("deposito_id"); v != "" {
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["deposito_id"] = id
+			}
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		inventarios, err := ir.BuscarInventarios(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar inventários: %v", err))
+			return
+		}
+		RespondOK(w, inventarios)
+	}
+}
+
+// GetInventarioById retorna http.HandlerFunc que busca a sessão com todos os
+// itens, contados ou não
+func GetInventarioById(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inventario, ok := buscarInventario(w, r, ir)
+		if !ok {
+			return
+		}
+		RespondOK(w, inventario)
+	}
+}
+
+// DivergenciasInventario retorna http.HandlerFunc que lista só os produtos
+// contados cuja quantidade difere da esperada, para revisão antes da aprovação
+func DivergenciasInventario(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inventario, ok := buscarInventario(w, r, ir)
+		if !ok {
+			return
+		}
+		RespondOK(w, inventario.Divergencias())
+	}
+}
+
+// RegistrarContagens retorna http.HandlerFunc que grava um lote de
+// contagens, cada uma por produto_id ou codigo_barras:
+// {"contagens": [{"codigo_barras": "789...", "quantidade": 3}]}
+func RegistrarContagens(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var dto struct {
+			Contagens []domain.Contagem `json:"contagens"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if len(dto.Contagens) == 0 {
+			RespondWithError(w, http.StatusBadRequest, "informe ao menos uma contagem")
+			return
+		}
+		for _, c := range dto.Contagens {
+			if err := c.Validate(); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		inventario, err := ir.RegistrarContagens(id, dto.Contagens, usuario)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Inventário não encontrado")
+			return
+		case errors.Is(err, domain.ErrSituacaoInventario):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.Is(err, repository.ErrCodigoBarrasInexistente), errors.Is(err, domain.ErrProdutoForaDoInventario):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao registrar contagens: %v", err))
+			return
+		}
+		RespondOK(w, inventario)
+	}
+}
+
+// AprovarInventario retorna http.HandlerFunc que fecha a sessão e ajusta o
+// estoque de todos os produtos com diferença numa única transação
+func AprovarInventario(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		inventario, err := ir.AprovarInventario(id, usuario)
+		var semEstoque *repository.EstoqueInsuficienteError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Inventário não encontrado")
+			return
+		case errors.Is(err, domain.ErrSituacaoInventario):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.As(err, &semEstoque):
+			RespondWithJSON(w, http.StatusConflict, map[string]any{
+				"error": "Estoque insuficiente para aplicar a contagem",
+				"itens": semEstoque.Itens,
+			})
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao aprovar inventário: %v", err))
+			return
+		}
+		RespondOK(w, inventario)
+	}
+}
+
+// CancelarInventario retorna http.HandlerFunc que descarta a sessão aberta
+func CancelarInventario(ir *repository.InventarioRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		err = ir.CancelarInventario(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Inventário não encontrado")
+			return
+		case errors.Is(err, domain.ErrSituacaoInventario):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao cancelar inventário: %v", err))
+			return
+		}
+		RespondNoContent(w)
+	}
+}
+
+// buscarInventario lê a sessão do {id} da rota, respondendo o erro quando falha
+func buscarInventario(w http.ResponseWriter, r *http.Request, ir *repository.InventarioRepository) (domain.Inventario, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID inválido")
+		return domain.Inventario{}, false
+	}
+
+	inventario, err := ir.BuscarInventarioPorId(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondWithError(w, http.StatusNotFound, "Inventário não encontrado")
+		return domain.Inventario{}, false
+	} else if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar inventário: %v", err))
+		return domain.Inventario{}, false
+	}
+	return inventario, true
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// BuscarLotesProduto retorna http.HandlerFunc com os lotes do produto que
+// ainda têm saldo, do que vence primeiro ao último; deposito_id filtra o
+// depósito e todos=true inclui os lotes esgotados
+func BuscarLotesProduto(lr *repository.LoteRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		filters := map[string]any{"produto_id": id}
+		if v := r.URL.Query().Get("deposito_id"); v != "" {
+			if depositoID, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["deposito_id"] = depositoID
+			}
+		}
+		if r.URL.Query().Get("todos") == "true" {
+			filters["todos"] = true
+		}
+
+		lotes, err := lr.BuscarLotes(filters)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar lotes: %v", err))
+			return
+		}
+		RespondOK(w, lotes)
+	}
+}
+
+// RelatorioVencimentos retorna http.HandlerFunc com os lotes que vencem nos
+// próximos dias (padrão 30), incluindo os vencidos, para serem remarcados
+// antes da perda; deposito_id filtra o depósito
+func RelatorioVencimentos(lr *repository.LoteRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dias := 30
+		if v := r.URL.Query().Get("dias"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				RespondWithError(w, http.StatusBadRequest, "dias inválido")
+				return
+			}
+			dias = n
+		}
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("deposito_id"); v != "" {
+			if depositoID, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["deposito_id"] = depositoID
+			}
+		}
+
+		relatorio, err := lr.RelatorioVencimentos(dias, time.Now(), filters)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao gerar relatório: %v", err))
+			return
+		}
+		RespondOK(w, relatorio)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// BuscarMovimentos retorna http.HandlerFunc que lista o razão de estoque de um produto
+func BuscarMovimentos(mr *repository.MovimentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("tipo"); v != "" {
+			filters["tipo"] = v
+		}
+		if v := r.URL.Query().Get("deposito_id"); v != "" {
+			if depositoID, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["deposito_id"] = depositoID
+			}
+		}
+		if v := r.URL.Query().Get("data_inicio"); v != "" {
+			filters["data_inicio"] = v
+		}
+		if v := r.URL.Query().Get("data_fim"); v != "" {
+			filters["data_fim"] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		movimentos, err := mr.BuscarMovimentos(id, filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar movimentos: %v", err))
+			return
+		}
+		RespondOK(w, movimentos)
+	}
+}
+
+// VerificarConsistenciaEstoque retorna http.HandlerFunc que compara o estoque de
+// cada produto com o saldo recalculado a partir do razão
+func VerificarConsistenciaEstoque(mr *repository.MovimentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		divergencias, err := mr.VerificarConsistencia()
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao verificar estoque: %v", err))
+			return
+		}
+		RespondOK(w, map[string]any{
+			"consistente":  len(divergencias) == 0,
+			"divergencias": divergencias,
+		})
+	}
+}
+
+// AjustarEstoque retorna http.HandlerFunc que lança um ajuste manual no
+// estoque do produto: {"quantidade": -2, "motivo": "QUEBRA", "observacao": "..."};
+// deposito_id é opcional e, ausente, o ajuste vale para o depósito principal;
+// lote_id aplica o ajuste a um lote, como a baixa de um lote vencido
+func AjustarEstoque(mr *repository.MovimentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var ajuste domain.AjusteEstoque
+		if err := json.NewDecoder(r.Body).Decode(&ajuste); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		ajuste.ProdutoID = id
+		if err := ajuste.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		movimento, err := mr.AjustarEstoque(ajuste, usuario)
+		var semEstoque *repository.EstoqueInsuficienteError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Produto não encontrado")
+			return
+		case errors.Is(err, repository.ErrDepositoInexistente), errors.Is(err, domain.ErrDepositoInativo),
+			errors.Is(err, repository.ErrLoteInexistente):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.As(err, &semEstoque):
+			RespondWithJSON(w, http.StatusConflict, map[string]any{
+				"error": "Estoque insuficiente",
+				"itens": semEstoque.Itens,
+			})
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao ajustar estoque: %v", err))
+			return
+		}
+		RespondCreated(w, movimento)
+	}
+}
+
+// RelatorioAjustes retorna http.HandlerFunc que resume os ajustes manuais por
+// motivo no período (data_inicio e data_fim, YYYY-MM-DD), opcionalmente de
+// um produto_id, para medir a quebra de estoque
+func RelatorioAjustes(mr *repository.MovimentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		for _, campo := range []string{"data_inicio", "data_fim"} {
+			v := r.URL.Query().Get(campo)
+			if v == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				RespondWithError(w, http.StatusBadRequest, campo+" deve estar no formato YYYY-MM-DD")
+				return
+			}
+			filters[campo] = v
+		}
+		if v := r.URL.Query().Get("produto_id"); v != "" {
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["produto_id"] = id
+			}
+		}
+
+		relatorio, err := mr.RelatorioAjustes(filters)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao gerar relatório de ajustes: %v", err))
+			return
+		}
+		RespondOK(w, relatorio)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarOrcamento retorna http.HandlerFunc que registra um orçamento aberto
+func CriarOrcamento(orc *repository.OrcamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var orcamento domain.Orcamento
+		if err := json.NewDecoder(r.Body).Decode(&orcamento); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		autorizarPrecos(r, orcamento.Items)
+		if err := orcamento.Validate(time.Now()); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		orcamento.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+		orcamento.Perfil, _ = r.Context().Value(middleware.PerfilKey).(string)
+
+		if err := orc.SalvarOrcamento(&orcamento); err != nil {
+			respondErroVenda(w, err, "Erro ao inserir orçamento")
+			return
+		}
+		RespondCreated(w, orcamento)
+	}
+}
+
+// BuscarOrcamentos retorna http.HandlerFunc que lista orçamentos por cliente e status
+func BuscarOrcamentos(orc *repository.OrcamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("cliente_id"); v != "" {
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["cliente_id"] = id
+			}
+		}
+		if v := r.URL.Query().Get("status"); v != "" {
+			filters["status"] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		orcamentos, err := orc.BuscarOrcamentos(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar orçamentos: %v", err))
+			return
+		}
+		RespondOK(w, orcamentos)
+	}
+}
+
+// GetOrcamentoById retorna http.HandlerFunc que busca um orçamento com cliente e itens
+func GetOrcamentoById(orc *repository.OrcamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		orcamento, err := orc.BuscarOrcamentoPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Orçamento não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar orçamento: %v", err))
+			return
+		}
+		RespondOK(w, orcamento)
+	}
+}
+
+// AprovarOrcamento retorna http.HandlerFunc que registra a aprovação do cliente
+func AprovarOrcamento(orc *repository.OrcamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		orcamento, err := orc.AprovarOrcamento(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Orçamento não encontrado")
+			return
+		case errors.Is(err, domain.ErrOrcamentoExpirado), errors.Is(err, domain.ErrSituacaoOrcamento):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao aprovar orçamento: %v", err))
+			return
+		}
+		RespondOK(w, orcamento)
+	}
+}
+
+// ConverterOrcamento retorna http.HandlerFunc que transforma um orçamento
+// aprovado em venda. O corpo é opcional: pagamentos no ato, liberar_credito
+// (em nome do usuário autenticado), deposito_id (de onde sai a mercadoria) e
+// precos_atuais, que aceita preços alterados desde o orçamento em vez de
+// recusar a conversão.
+func ConverterOrcamento(orc *repository.OrcamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var input struct {
+			PrecosAtuais   bool               `json:"precos_atuais"`
+			Pagamentos     []domain.Pagamento `json:"pagamentos"`
+			LiberarCredito bool               `json:"liberar_credito"`
+			DepositoID     int64              `json:"deposito_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		for i := range input.Pagamentos {
+			if err := input.Pagamentos[i].Validate(); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		complemento := domain.Sale{Pagamentos: input.Pagamentos, DepositoID: input.DepositoID}
+		if complemento.CreditoLiberadoPor, err = liberacaoCredito(r, input.LiberarCredito); err != nil {
+			respondErroVenda(w, err, "Erro ao converter orçamento")
+			return
+		}
+		complemento.Perfil, _ = r.Context().Value(middleware.PerfilKey).(string)
+		venda, err := orc.ConverterOrcamento(id, input.PrecosAtuais, complemento, usuario)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Orçamento ou produto não encontrado: %v", err))
+		case errors.Is(err, domain.ErrOrcamentoExpirado), errors.Is(err, domain.ErrSituacaoOrcamento):
+			RespondWithError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			respondErroVenda(w, err, "Erro ao converter orçamento")
+		default:
+			RespondCreated(w, venda)
+		}
+	}
+}
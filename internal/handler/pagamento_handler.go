@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// RegistrarPagamento retorna http.HandlerFunc que registra um valor recebido
+// da venda e devolve o resumo de pagamentos atualizado
+func RegistrarPagamento(pr *repository.PagamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var pagamento domain.Pagamento
+		if err := json.NewDecoder(r.Body).Decode(&pagamento); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := pagamento.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		pagamento.VendaID = id
+		pagamento.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+
+		err = pr.RegistrarPagamento(&pagamento)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		case errors.Is(err, repository.ErrVendaCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.Is(err, domain.ErrPagamentoExcedeSaldo), errors.Is(err, domain.ErrCreditoLojaInsuficiente):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao registrar pagamento: %v", err))
+			return
+		}
+
+		resumo, err := pr.BuscarResumo(id)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar pagamentos: %v", err))
+			return
+		}
+		RespondCreated(w, resumo)
+	}
+}
+
+// BuscarPagamentos retorna http.HandlerFunc que lista pagamentos e parcelas da venda
+func BuscarPagamentos(pr *repository.PagamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		resumo, err := pr.BuscarResumo(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar pagamentos: %v", err))
+			return
+		}
+		RespondOK(w, resumo)
+	}
+}
+
+// DefinirParcelas retorna http.HandlerFunc que substitui o plano de parcelas da
+// venda. Aceita a lista de parcelas ou a quantidade a dividir em partes iguais:
+//
+//	{"parcelas": [{"valor": "50.00", "vencimento": "2024-03-10"}, ...]}
+//	{"quantidade": 3, "primeiro_vencimento": "2024-03-10", "intervalo_dias": 30}
+func DefinirParcelas(pr *repository.PagamentoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var dto struct {
+			Parcelas           []domain.Parcela `json:"parcelas"`
+			Quantidade         int              `json:"quantidade"`
+			PrimeiroVencimento string           `json:"primeiro_vencimento"`
+			IntervaloDias      int              `json:"intervalo_dias"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		parcelas := dto.Parcelas
+		if dto.Quantidade > 0 {
+			primeiro, err := time.Parse("2006-01-02", dto.PrimeiroVencimento)
+			if err != nil {
+				RespondWithError(w, http.StatusBadRequest, "primeiro_vencimento deve estar no formato AAAA-MM-DD")
+				return
+			}
+			if dto.IntervaloDias == 0 {
+				dto.IntervaloDias = 30
+			}
+			resumo, err := pr.BuscarResumo(id)
+			if errors.Is(err, sql.ErrNoRows) {
+				RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+				return
+			} else if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar venda: %v", err))
+				return
+			}
+			parcelas, err = domain.DividirParcelas(resumo.Total, dto.Quantidade, primeiro, dto.IntervaloDias)
+			if err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		err = pr.DefinirParcelas(id, parcelas)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		case errors.Is(err, repository.ErrVendaCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case errors.Is(err, domain.ErrParcelasInvalidas):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao definir parcelas: %v", err))
+			return
+		}
+
+		resumo, err := pr.BuscarResumo(id)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar pagamentos: %v", err))
+			return
+		}
+		RespondOK(w, resumo)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/cockroachdb/apd/v3"
 	"github.com/go-chi/chi/v5"
@@ -15,23 +16,39 @@ import (
 	"github.com/julio-pupim/lojaestoque/internal/repository"
 )
 
-// CreateOrAddProduto retorna um http.HandlerFunc que faz INSERT ou atualiza estoque
-func CreateOrAddProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
+// CreateOrAddProduto retorna um http.HandlerFunc que faz INSERT ou atualiza o
+// produto já cadastrado com o mesmo fornecedor e código. Neste caso a
+// quantidade_estoque deve ser zero, pois o estoque só muda por ajuste com
+// motivo, e o custo não muda: vem das compras recebidas.
+func CreateOrAddProduto(pr *repository.ProdutoRepository, fr *repository.FornecedorRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var dto struct {
 			Nome              string `json:"nome"`
 			FornecedorID      int64  `json:"fornecedor_id"`
 			CodigoFornecedor  string `json:"codigo_fornecedor"`
+			CodigoBarras      string `json:"codigo_barras"`
 			QuantidadeEstoque int64  `json:"quantidade_estoque"`
+			QtdMinima         int64  `json:"qtd_minima"`
+			QtdMaxima         int64  `json:"qtd_maxima"`
 			Preco             string `json:"preco"`
+			Custo             string `json:"custo"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
 			return
 		}
 
+		// Fornecedor precisa existir
+		forn, err := fr.BuscarPorId(dto.FornecedorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusUnprocessableEntity, "Fornecedor não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar fornecedor: %v", err))
+			return
+		}
+
 		// Constrói domain model
-		forn := domain.Fornecedor{Id: dto.FornecedorID}
 		p, err := domain.NewProduto(
 			0,
 			dto.Nome,
@@ -44,9 +61,32 @@ func CreateOrAddProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 			RespondWithError(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if err := p.SetNiveisEstoque(dto.QtdMinima, dto.QtdMaxima); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if dto.Custo != "" {
+			if err := p.SetCusto(dto.Custo); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		if err := p.SetCodigoBarras(dto.CodigoBarras); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		// Persiste
-		if err := pr.Save(p); err != nil {
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		err = pr.Save(p, usuario)
+		switch {
+		case errors.Is(err, repository.ErrEstoqueSomenteAjuste), errors.Is(err, repository.ErrCustoPelasCompras):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, repository.ErrCodigoBarrasEmUso):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
 			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao salvar produto: %v", err))
 			return
 		}
@@ -73,6 +113,16 @@ func SearchProdutos(pr *repository.ProdutoRepository) http.HandlerFunc {
 		if v := r.URL.Query().Get("codigo_fornecedor"); v != "" {
 			filters["codigo_fornecedor"] = v
 		}
+		if v := r.URL.Query().Get("codigo_barras"); v != "" {
+			filters["codigo_barras"] = v
+		}
+		// Só produtos com saldo no depósito
+		if v := r.URL.Query().Get("deposito_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err == nil {
+				filters["deposito_id"] = id
+			}
+		}
 		// Preço mínimo e máximo
 		if v := r.URL.Query().Get("preco_min"); v != "" {
 			dec := apd.New(0, 0)
@@ -116,6 +166,9 @@ func DeleteProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 		if err := pr.Delete(id); errors.Is(err, sql.ErrNoRows) {
 			RespondWithError(w, http.StatusNotFound, "Produto não encontrado")
 			return
+		} else if errors.Is(err, repository.ErrProdutoEmUso) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
 		} else if err != nil {
 			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao deletar: %v", err))
 			return
@@ -139,8 +192,12 @@ func UpdateProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 		var dto struct {
 			Nome              *string `json:"nome"`
 			CodigoFornecedor  *string `json:"codigo_fornecedor"`
+			CodigoBarras      *string `json:"codigo_barras"`
 			QuantidadeEstoque *int64  `json:"quantidade_estoque"`
+			QtdMinima         *int64  `json:"qtd_minima"`
+			QtdMaxima         *int64  `json:"qtd_maxima"`
 			Preco             *string `json:"preco"`
+			Custo             *string `json:"custo"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
@@ -167,13 +224,20 @@ func UpdateProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 			p.CodigoFornecedor = *dto.CodigoFornecedor
 		}
 
-		if dto.QuantidadeEstoque != nil {
-			if err := p.SetQuantidadeEstoque(*dto.QuantidadeEstoque); err != nil {
+		// código de barras vazio remove o código do produto
+		if dto.CodigoBarras != nil {
+			if err := p.SetCodigoBarras(*dto.CodigoBarras); err != nil {
 				RespondWithError(w, http.StatusBadRequest, err.Error())
 				return
 			}
 		}
 
+		// o estoque só muda por ajuste com motivo; repetir a quantidade atual é aceito
+		if dto.QuantidadeEstoque != nil && *dto.QuantidadeEstoque != p.QuantidadeEstoque {
+			RespondWithError(w, http.StatusUnprocessableEntity, repository.ErrEstoqueSomenteAjuste.Error())
+			return
+		}
+
 		if dto.Preco != nil {
 			if err := p.SetPreco(*dto.Preco); err != nil {
 				RespondWithError(w, http.StatusBadRequest, err.Error())
@@ -181,13 +245,42 @@ func UpdateProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 			}
 		}
 
+		if dto.Custo != nil {
+			if err := p.SetCusto(*dto.Custo); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		if dto.QtdMinima != nil || dto.QtdMaxima != nil {
+			minima, maxima := p.QuantidadeMinima, p.QuantidadeMaxima
+			if dto.QtdMinima != nil {
+				minima = *dto.QtdMinima
+			}
+			if dto.QtdMaxima != nil {
+				maxima = *dto.QtdMaxima
+			}
+			if err := p.SetNiveisEstoque(minima, maxima); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
 		// Valida e persiste update
 		if err := p.ValidateAndUpdate(); err != nil {
 			RespondWithError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		if err := pr.Update(p); err != nil {
+		err = pr.Update(p)
+		switch {
+		case errors.Is(err, repository.ErrEstoqueSomenteAjuste), errors.Is(err, repository.ErrCustoPelasCompras):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, repository.ErrCodigoBarrasEmUso):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
 			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao atualizar: %v", err))
 			return
 		}
@@ -195,3 +288,158 @@ func UpdateProduto(pr *repository.ProdutoRepository) http.HandlerFunc {
 		RespondOK(w, p)
 	}
 }
+
+// PrecoSugerido retorna http.HandlerFunc que sugere o preço de venda do
+// produto aplicando ao custo médio o markup (percentual) informado
+func PrecoSugerido(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+		markup, err := domain.ParseDecimal(r.URL.Query().Get("markup"))
+		if err != nil || markup.Sign() < 0 {
+			RespondWithError(w, http.StatusBadRequest, "markup deve ser um percentual não negativo")
+			return
+		}
+
+		produtos, err := pr.Find(map[string]any{"id": id}, 1, 0)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar produto: %v", err))
+			return
+		}
+		if len(produtos) == 0 {
+			RespondWithError(w, http.StatusNotFound, "Produto não encontrado")
+			return
+		}
+		p := produtos[0]
+		if p.Custo.Sign() == 0 {
+			RespondWithError(w, http.StatusUnprocessableEntity, "Produto sem custo registrado")
+			return
+		}
+		RespondOK(w, map[string]any{
+			"produto_id":     p.ID,
+			"custo":          p.Custo,
+			"preco":          p.Preco,
+			"markup":         markup,
+			"preco_sugerido": p.PrecoSugerido(markup),
+		})
+	}
+}
+
+// ProdutosAbaixoDoMinimo retorna http.HandlerFunc que lista produtos com estoque abaixo do mínimo
+func ProdutosAbaixoDoMinimo(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		prods, err := pr.BuscarAbaixoDoMinimo(limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro na busca: %v", err))
+			return
+		}
+		RespondOK(w, prods)
+	}
+}
+
+// SugestaoReposicao retorna http.HandlerFunc que sugere compras por fornecedor.
+// Parâmetros: dias (janela de vendas analisada, padrão 30) e cobertura (dias de
+// venda que a compra deve cobrir, padrão igual a dias).
+func SugestaoReposicao(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dias := 30
+		if v := r.URL.Query().Get("dias"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				RespondWithError(w, http.StatusBadRequest, "dias inválido")
+				return
+			}
+			dias = n
+		}
+		cobertura := dias
+		if v := r.URL.Query().Get("cobertura"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				RespondWithError(w, http.StatusBadRequest, "cobertura inválida")
+				return
+			}
+			cobertura = n
+		}
+
+		itens, err := pr.BuscarCandidatosReposicao(time.Now().AddDate(0, 0, -dias))
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro na busca: %v", err))
+			return
+		}
+		for i := range itens {
+			itens[i].CalcularQuantidade(dias, cobertura)
+		}
+		RespondOK(w, domain.AgruparReposicao(itens))
+	}
+}
+
+// GetProdutoPorCodigoBarras retorna http.HandlerFunc que busca o produto lido
+// pelo leitor do caixa
+func GetProdutoPorCodigoBarras(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		codigo := chi.URLParam(r, "codigo")
+		if !domain.GTINValido(codigo) {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("%v: %q", domain.ErrCodigoBarrasInvalido, codigo))
+			return
+		}
+
+		produto, err := pr.BuscarPorCodigoBarras(codigo)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Produto não encontrado")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar produto: %v", err))
+			return
+		}
+		RespondOK(w, produto)
+	}
+}
+
+// GerarCodigoBarras retorna http.HandlerFunc que atribui um EAN-13 interno
+// (prefixo 200) ao produto que chegou sem código de barras
+func GerarCodigoBarras(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		produto, err := pr.GerarCodigoBarras(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Produto não encontrado")
+			return
+		case errors.Is(err, repository.ErrProdutoComCodigoBarras), errors.Is(err, repository.ErrCodigoBarrasEmUso):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao gerar código de barras: %v", err))
+			return
+		}
+		RespondOK(w, produto)
+	}
+}
+
+// GerarCodigosBarrasFaltantes retorna http.HandlerFunc que atribui códigos
+// internos a todos os produtos sem código de barras
+func GerarCodigosBarrasFaltantes(pr *repository.ProdutoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gerados, err := pr.GerarCodigosBarrasFaltantes()
+		if errors.Is(err, repository.ErrCodigoBarrasEmUso) {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao gerar códigos de barras: %v", err))
+			return
+		}
+		RespondOK(w, map[string]int{"gerados": gerados})
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarPromocao retorna http.HandlerFunc que cadastra uma regra de preço
+func CriarPromocao(pr *repository.PromocaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var promocao domain.Promocao
+		if err := json.NewDecoder(r.Body).Decode(&promocao); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := promocao.Validate(time.Now()); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := pr.SalvarPromocao(&promocao)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Fornecedor ou produto não encontrado: %v", err))
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao salvar promoção: %v", err))
+			return
+		}
+		RespondCreated(w, promocao)
+	}
+}
+
+// BuscarPromocoes retorna http.HandlerFunc que lista promoções por tipo,
+// produto, fornecedor e vigência
+func BuscarPromocoes(pr *repository.PromocaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		if v := r.URL.Query().Get("tipo"); v != "" {
+			filters["tipo"] = v
+		}
+		if v := r.URL.Query().Get("produto_id"); v != "" {
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["produto_id"] = id
+			}
+		}
+		if v := r.URL.Query().Get("fornecedor_id"); v != "" {
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				filters["fornecedor_id"] = id
+			}
+		}
+		if v := r.URL.Query().Get("vigente_em"); v != "" {
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				RespondWithError(w, http.StatusBadRequest, "vigente_em deve estar no formato YYYY-MM-DD")
+				return
+			}
+			filters["vigente_em"] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		promocoes, err := pr.BuscarPromocoes(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar promoções: %v", err))
+			return
+		}
+		RespondOK(w, promocoes)
+	}
+}
+
+// GetPromocaoById retorna http.HandlerFunc que busca uma promoção com suas faixas
+func GetPromocaoById(pr *repository.PromocaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		promocao, err := pr.BuscarPromocaoPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Promoção não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar promoção: %v", err))
+			return
+		}
+		RespondOK(w, promocao)
+	}
+}
+
+// EncerrarPromocao retorna http.HandlerFunc que termina a vigência da
+// promoção, que deixa de valer a partir de hoje
+func EncerrarPromocao(pr *repository.PromocaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		promocao, err := pr.EncerrarPromocao(id, time.Now())
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Promoção não encontrada")
+			return
+		case errors.Is(err, domain.ErrPromocaoEncerrada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao encerrar promoção: %v", err))
+			return
+		}
+		RespondOK(w, promocao)
+	}
+}
+
+// DeletePromocao retorna http.HandlerFunc que remove promoção nunca aplicada
+func DeletePromocao(pr *repository.PromocaoRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		err = pr.DeletarPromocao(id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Promoção não encontrada")
+			return
+		case errors.Is(err, repository.ErrPromocaoEmUso):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao remover promoção: %v", err))
+			return
+		}
+		RespondNoContent(w)
+	}
+}
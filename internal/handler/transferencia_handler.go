@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+)
+
+// CriarTransferencia retorna http.HandlerFunc que move mercadoria entre
+// depósitos: {"origem_id": 2, "destino_id": 1, "itens": [{"produto_id": 7, "quantidade": 12}]}.
+// Ou todos os itens são transferidos, ou nenhum.
+func CriarTransferencia(tr *repository.TransferenciaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var t domain.Transferencia
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if err := t.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		t.ID, t.Data = 0, time.Time{}
+		t.Usuario, _ = r.Context().Value(middleware.UsuarioKey).(string)
+
+		err := tr.Transferir(&t)
+		var semEstoque *repository.EstoqueInsuficienteError
+		switch {
+		case errors.As(err, &semEstoque):
+			RespondWithJSON(w, http.StatusConflict, map[string]any{
+				"error": "Estoque insuficiente na origem",
+				"itens": semEstoque.Itens,
+			})
+			return
+		case errors.Is(err, repository.ErrDepositoInexistente), errors.Is(err, domain.ErrDepositoInativo):
+			RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Produto não encontrado: %v", err))
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao transferir: %v", err))
+			return
+		}
+		RespondCreated(w, t)
+	}
+}
+
+// BuscarTransferencias retorna http.HandlerFunc que lista as transferências
+// filtrando por deposito_id (origem ou destino), produto_id, data_inicio e data_fim
+func BuscarTransferencias(tr *repository.TransferenciaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters := make(map[string]any)
+		for _, campo := range []string{"deposito_id", "produto_id"} {
+			if v := r.URL.Query().Get(campo); v != "" {
+				if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+					filters[campo] = id
+				}
+			}
+		}
+		for _, campo := range []string{"data_inicio", "data_fim"} {
+			v := r.URL.Query().Get(campo)
+			if v == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", v); err != nil {
+				RespondWithError(w, http.StatusBadRequest, campo+" deve estar no formato YYYY-MM-DD")
+				return
+			}
+			filters[campo] = v
+		}
+
+		page := r.Context().Value(middleware.PageKey).(int)
+		limit := r.Context().Value(middleware.LimitKey).(int)
+		offset := (page - 1) * limit
+
+		transferencias, err := tr.BuscarTransferencias(filters, limit, offset)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar transferências: %v", err))
+			return
+		}
+		RespondOK(w, transferencias)
+	}
+}
+
+// GetTransferenciaById retorna http.HandlerFunc que busca a transferência com os itens
+func GetTransferenciaById(tr *repository.TransferenciaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		t, err := tr.BuscarTransferenciaPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Transferência não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar transferência: %v", err))
+			return
+		}
+		RespondOK(w, t)
+	}
+}
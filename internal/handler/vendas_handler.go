@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -13,18 +16,34 @@ import (
 	"github.com/julio-pupim/lojaestoque/internal/repository"
 )
 
+// CriarVenda retorna http.HandlerFunc que registra a venda. liberar_credito
+// true vende acima do limite do cliente, em nome do usuário autenticado, se o
+// perfil dele permitir.
 func CriarVenda(vr *repository.VendasRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var venda domain.Sale
-		if err := json.NewDecoder(r.Body).Decode(&venda); err != nil {
+		var input struct {
+			domain.Sale
+			LiberarCredito bool `json:"liberar_credito"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
 			RespondWithError(w, http.StatusBadRequest, "Erro ao decodificar JSON")
 			return
 		}
+		venda := input.Sale
+		autorizarPrecos(r, venda.Items)
+		if err := venda.Validate(); err != nil {
+			RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
-		err := vr.SalvarVenda(&venda)
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		venda.Perfil, _ = r.Context().Value(middleware.PerfilKey).(string)
+		if venda.CreditoLiberadoPor, err = liberacaoCredito(r, input.LiberarCredito); err == nil {
+			err = vr.SalvarVenda(&venda, usuario)
+		}
 		if err != nil {
-			log.Printf("Erro ao executar query: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Erro ao inserir cliente")
+			respondErroVenda(w, err, "Erro ao inserir venda")
 			return
 		}
 		RespondCreated(w, venda)
@@ -35,7 +54,7 @@ func BuscarVenda(vr *repository.VendasRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		filters := make(map[string]any)
 		if v := r.URL.Query().Get("nome_cliente"); v != "" {
-			filters["nome"] = v
+			filters["nome_cliente"] = v
 		}
 		if v := r.URL.Query().Get("nome_produto"); v != "" {
 			filters["nome_produto"] = v
@@ -52,31 +71,219 @@ func BuscarVenda(vr *repository.VendasRepository) http.HandlerFunc {
 		if v := r.URL.Query().Get("status_pagamento"); v != "" {
 			filters["status_pagamento"] = v
 		}
+		if v := r.URL.Query().Get("status"); v != "" {
+			filters["status"] = v
+		}
 		page := r.Context().Value(middleware.PageKey).(int)
 		limit := r.Context().Value(middleware.LimitKey).(int)
 		offset := (page - 1) * limit
 		vendas, err := vr.BuscarVendas(filters, limit, offset)
 		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, fmt.Sprint("Erro ao buscar vendas: %v", err))
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar vendas: %v", err))
 			return
 		}
 		RespondOK(w, vendas)
 	}
 }
-func DeletarVenda(vr *repository.VendasRepository) http.HandlerFunc {
+
+// GetVendaById retorna http.HandlerFunc que devolve a venda com cliente e itens
+func GetVendaById(vr *repository.VendasRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		idParam := chi.URLParam(r, "id")
-		id, err := strconv.ParseInt(idParam, 10, 64)
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
 			RespondWithError(w, http.StatusBadRequest, "ID inválido")
 			return
 		}
-		err = vr.DeletarVenda(id)
+
+		venda, err := vr.BuscarVendaPorId(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		} else if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao buscar venda: %v", err))
+			return
+		}
+		RespondOK(w, venda)
+	}
+}
+
+// UpdateVenda retorna http.HandlerFunc que altera cliente e/ou itens de uma
+// venda com pagamento pendente. Os itens enviados substituem os atuais, e o
+// desconto da venda acompanha os itens. liberar_credito libera o limite do
+// cliente em nome do usuário autenticado.
+func UpdateVenda(vr *repository.VendasRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 		if err != nil {
-			log.Printf("Erro ao executar query de delete: %v", err)
-			RespondWithError(w, http.StatusInternalServerError, "Erro interno")
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var input struct {
+			ClienteID      *int64            `json:"cliente_id"`
+			Total          domain.Decimal    `json:"total"`
+			Items          []domain.SaleItem `json:"items"`
+			Desconto       *domain.Desconto  `json:"desconto"`
+			LiberarCredito bool              `json:"liberar_credito"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+		if input.ClienteID == nil && input.Items == nil && !input.LiberarCredito {
+			RespondWithError(w, http.StatusBadRequest, "Nenhum campo para atualizar")
+			return
+		}
+		if input.Desconto != nil && input.Items == nil {
+			RespondWithError(w, http.StatusBadRequest, "desconto da venda exige os itens")
+			return
+		}
+		alteracao := domain.Sale{Total: input.Total, Items: input.Items, Desconto: input.Desconto}
+		if alteracao.CreditoLiberadoPor, err = liberacaoCredito(r, input.LiberarCredito); err != nil {
+			respondErroVenda(w, err, "Erro ao atualizar venda")
+			return
+		}
+		if input.ClienteID != nil {
+			if *input.ClienteID <= 0 {
+				RespondWithError(w, http.StatusBadRequest, "cliente inválido")
+				return
+			}
+			alteracao.ClientID = *input.ClienteID
+		}
+		if input.Desconto != nil {
+			if err := input.Desconto.Validate(); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		if input.Items != nil {
+			autorizarPrecos(r, alteracao.Items)
+			if err := alteracao.ValidarItens(); err != nil {
+				RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		alteracao.Perfil, _ = r.Context().Value(middleware.PerfilKey).(string)
+		venda, err := vr.AtualizarVenda(id, alteracao, usuario)
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Venda ou produto não encontrado: %v", err))
 			return
 		}
-		RespondNoContent(w)
+		if err != nil {
+			respondErroVenda(w, err, "Erro ao atualizar venda")
+			return
+		}
+		RespondOK(w, venda)
+	}
+}
+
+// CancelarVenda retorna http.HandlerFunc que cancela a venda, devolve os itens
+// ao estoque e o valor pago ao crédito do cliente na loja. O corpo é opcional e
+// pode trazer o motivo do cancelamento.
+func CancelarVenda(vr *repository.VendasRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "ID inválido")
+			return
+		}
+
+		var input struct {
+			Motivo string `json:"motivo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			RespondWithError(w, http.StatusBadRequest, "JSON inválido")
+			return
+		}
+
+		usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+		venda, err := vr.CancelarVenda(id, input.Motivo, usuario)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondWithError(w, http.StatusNotFound, "Venda não encontrada")
+			return
+		case errors.Is(err, repository.ErrVendaCancelada):
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			RespondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Erro ao cancelar venda: %v", err))
+			return
+		}
+		RespondOK(w, venda)
+	}
+}
+
+// liberacaoCredito devolve quem libera o crédito quando a requisição pede a
+// liberação: sempre o usuário autenticado, nunca um nome vindo do corpo, e
+// só se o perfil dele puder autorizá-la
+func liberacaoCredito(r *http.Request, pedida bool) (string, error) {
+	if !pedida {
+		return "", nil
+	}
+	usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+	perfil, _ := r.Context().Value(middleware.PerfilKey).(string)
+	if usuario == "" || !domain.PodeAutorizar(perfil) {
+		return "", domain.ErrLiberacaoNaoAutorizada
+	}
+	return usuario, nil
+}
+
+// autorizarPrecos registra o usuário autenticado como quem autorizou os preços
+// alterados dos itens, descartando o autorizado_por enviado no corpo; se o
+// perfil dele não puder autorizar, a precificação recusa a venda
+func autorizarPrecos(r *http.Request, itens []domain.SaleItem) {
+	usuario, _ := r.Context().Value(middleware.UsuarioKey).(string)
+	for i := range itens {
+		if itens[i].PriceOverride != nil {
+			itens[i].AuthorizedBy = usuario
+		}
+	}
+}
+
+// respondErroVenda traduz os erros de gravação de venda em respostas HTTP
+func respondErroVenda(w http.ResponseWriter, err error, msg string) {
+	var semEstoque *repository.EstoqueInsuficienteError
+	var divergente *domain.TotalDivergenteError
+	var semCredito *domain.CreditoInsuficienteError
+	var acimaDoLimite *domain.DescontoAcimaDoLimiteError
+	switch {
+	case errors.As(err, &acimaDoLimite):
+		RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":    acimaDoLimite.Error(),
+			"desconto": acimaDoLimite,
+		})
+	case errors.As(err, &semCredito):
+		RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":   semCredito.Error(),
+			"credito": semCredito,
+		})
+	case errors.As(err, &divergente):
+		RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":       divergente.Error(),
+			"divergencia": divergente,
+		})
+	case errors.Is(err, domain.ErrLiberacaoNaoAutorizada), errors.Is(err, domain.ErrPrecoNaoAutorizado):
+		RespondWithError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrPrecoSemAutorizacao), errors.Is(err, domain.ErrDescontoMaiorQueValor):
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.As(err, &semEstoque):
+		RespondWithJSON(w, http.StatusConflict, map[string]any{
+			"error": "Estoque insuficiente",
+			"itens": semEstoque.Itens,
+		})
+	case errors.Is(err, repository.ErrVendaCancelada), errors.Is(err, repository.ErrVendaNaoPendente),
+		errors.Is(err, repository.ErrVendaComDevolucao):
+		RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, repository.ErrClienteInexistente), errors.Is(err, domain.ErrPagamentoExcedeSaldo),
+		errors.Is(err, domain.ErrCreditoLojaInsuficiente), errors.Is(err, repository.ErrDepositoInexistente),
+		errors.Is(err, domain.ErrDepositoInativo):
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		RespondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Produto não encontrado: %v", err))
+	default:
+		log.Printf("Erro ao executar query: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, msg)
 	}
 }
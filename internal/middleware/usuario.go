@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+const (
+	UsuarioKey ctxKey = "usuario"
+	PerfilKey  ctxKey = "perfil"
+)
+
+// Autenticador identifica o usuário dono de um token
+type Autenticador interface {
+	Autenticar(token string) (domain.Usuario, error)
+}
+
+// Usuario guarda no contexto o nome e o perfil do usuário dono do token em
+// "Authorization: Bearer <token>", para que as movimentações registrem quem
+// as fez e o perfil defina o que ele pode conceder. Sem token a requisição
+// segue anônima, sem perfil; token desconhecido é recusado com 401.
+func Usuario(a Autenticador) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var usuario domain.Usuario
+			if cabecalho := r.Header.Get("Authorization"); cabecalho != "" {
+				token, ok := strings.CutPrefix(cabecalho, "Bearer ")
+				var err error
+				if ok {
+					usuario, err = a.Autenticar(strings.TrimSpace(token))
+				}
+				if !ok || err != nil {
+					responderErro(w, http.StatusUnauthorized, "token inválido")
+					return
+				}
+			}
+			ctx := context.WithValue(r.Context(), UsuarioKey, usuario.Nome)
+			ctx = context.WithValue(ctx, PerfilKey, usuario.Perfil)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequerPerfil restringe a rota aos usuários autenticados com um dos perfis
+func RequerPerfil(perfis ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			perfil, _ := r.Context().Value(PerfilKey).(string)
+			for _, p := range perfis {
+				if perfil != "" && perfil == p {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			responderErro(w, http.StatusForbidden, "perfil sem permissão para esta operação")
+		})
+	}
+}
+
+func responderErro(w http.ResponseWriter, status int, mensagem string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": mensagem})
+}
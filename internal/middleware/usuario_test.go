@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	handler "github.com/julio-pupim/lojaestoque/internal/handler"
+	"github.com/julio-pupim/lojaestoque/internal/middleware"
+	"github.com/julio-pupim/lojaestoque/internal/repository"
+	"github.com/julio-pupim/lojaestoque/migrations"
+)
+
+// Só o gerente autenticado altera limites de desconto; o perfil enviado em
+// cabeçalho pelo cliente não vale nada
+func TestLimiteDescontoExigeGerente(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("abrindo banco: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migracoes, err := database.CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatalf("carregando migrações: %v", err)
+	}
+	if _, err := database.AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatalf("aplicando migrações: %v", err)
+	}
+
+	ur := repository.NewUsuarioRepository(db)
+	tokenVendedor, err := ur.CriarUsuario(&domain.Usuario{Nome: "Bia", Perfil: "vendedor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenGerente, err := ur.CriarUsuario(&domain.Usuario{Nome: "Ana", Perfil: domain.PerfilGerente})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dr := repository.NewDescontoRepository(db)
+	r := chi.NewRouter()
+	r.Use(middleware.Usuario(ur))
+	r.With(middleware.RequerPerfil(domain.PerfilGerente)).Put("/descontos/limites/{perfil}", handler.DefinirLimiteDesconto(dr))
+
+	put := func(cabecalhos map[string]string) int {
+		req := httptest.NewRequest(http.MethodPut, "/descontos/limites/vendedor",
+			strings.NewReader(`{"percentual_maximo": "90"}`))
+		for k, v := range cabecalhos {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	casos := []struct {
+		nome       string
+		cabecalhos map[string]string
+		status     int
+	}{
+		{"anônimo", nil, http.StatusForbidden},
+		{"anônimo com X-Perfil", map[string]string{"X-Perfil": "gerente", "X-Usuario": "Ana"}, http.StatusForbidden},
+		{"vendedor", map[string]string{"Authorization": "Bearer " + tokenVendedor}, http.StatusForbidden},
+		{"vendedor com X-Perfil", map[string]string{"Authorization": "Bearer " + tokenVendedor, "X-Perfil": "gerente"},
+			http.StatusForbidden},
+		{"token inventado", map[string]string{"Authorization": "Bearer gerente"}, http.StatusUnauthorized},
+	}
+	for _, c := range casos {
+		if got := put(c.cabecalhos); got != c.status {
+			t.Errorf("%s: status %d, esperado %d", c.nome, got, c.status)
+		}
+	}
+	if limites, _ := dr.BuscarLimites(); len(limites) != 0 {
+		t.Fatalf("limite alterado por quem não é gerente: %+v", limites)
+	}
+
+	if got := put(map[string]string{"Authorization": "Bearer " + tokenGerente}); got != http.StatusOK {
+		t.Fatalf("gerente: status %d", got)
+	}
+	if limites, _ := dr.BuscarLimites(); len(limites) != 1 || limites[0].PercentualMaximo.String() != "90" {
+		t.Errorf("limites após o gerente = %+v", limites)
+	}
+}
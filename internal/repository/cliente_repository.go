@@ -2,12 +2,15 @@ package repository
 
 import (
 	"database/sql"
-	"fmt"
+	"errors"
 	"strings"
 
 	"github.com/julio-pupim/lojaestoque/internal/domain"
 )
 
+// ErrClienteEmUso indica cliente que já tem vendas registradas
+var ErrClienteEmUso = errors.New("cliente possui vendas e não pode ser removido")
+
 type ClienteRepository struct {
 	db *sql.DB
 }
@@ -17,12 +20,13 @@ func NewClienteRepository(db *sql.DB) *ClienteRepository {
 }
 
 func (cr *ClienteRepository) BuscarClientes(filters map[string]any, limit, offset int) ([]domain.Cliente, error) {
-	sql := "SELECT * FROM clientes "
+	sql := "SELECT " + clienteColunas + " FROM clientes"
 	var clauses []string
 	var args []any
 
+	// LIKE do SQLite já ignora maiúsculas/minúsculas em ASCII
 	if v, ok := filters["nome"]; ok {
-		clauses = append(clauses, "nome ILIKE ?")
+		clauses = append(clauses, "nome LIKE ?")
 		args = append(args, "%"+v.(string)+"%")
 	}
 	if v, ok := filters["telefone"]; ok {
@@ -32,7 +36,7 @@ func (cr *ClienteRepository) BuscarClientes(filters map[string]any, limit, offse
 	if len(clauses) > 0 {
 		sql += " WHERE " + strings.Join(clauses, " AND ")
 	}
-	sql += " LIMIT ? OFFSET ?"
+	sql += " ORDER BY nome LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := cr.db.Query(sql, args...)
@@ -41,25 +45,22 @@ func (cr *ClienteRepository) BuscarClientes(filters map[string]any, limit, offse
 	}
 	defer rows.Close()
 
-	var clientes []domain.Cliente
+	clientes := []domain.Cliente{}
 	for rows.Next() {
-		var (
-			id                           int64
-			nome, telefone, dataCadastro string
-		)
-		if err := rows.Scan(&id, &nome, &telefone, &dataCadastro); err != nil {
-			fmt.Print(err)
+		c, err := scanCliente(rows)
+		if err != nil {
 			return nil, err
 		}
-		clientes = append(clientes, domain.Cliente{ID: id, Nome: nome, Telefone: telefone, DataCadastro: dataCadastro})
+		clientes = append(clientes, c)
 	}
 
-	return clientes, nil
+	return clientes, rows.Err()
 }
 
 func (cr *ClienteRepository) SalvarCliente(c *domain.Cliente) (sql.Result, error) {
-	result, err := cr.db.Exec("INSERT INTO clientes (nome, telefone, data_cadastro) VALUES (?,?,?)",
-		c.Nome, c.Telefone, c.DataCadastro)
+	result, err := cr.db.Exec(
+		"INSERT INTO clientes (nome, telefone, data_cadastro, limite_credito, bloqueado) VALUES (?,?,?,?,?)",
+		c.Nome, c.Telefone, c.DataCadastro, nullDecimal(c.LimiteCredito), c.Bloqueado)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +69,9 @@ func (cr *ClienteRepository) SalvarCliente(c *domain.Cliente) (sql.Result, error
 
 func (cr *ClienteRepository) DeletarCliente(id int64) error {
 	_, err := cr.db.Exec("DELETE FROM clientes WHERE id = ?", id)
+	if violaChaveEstrangeira(err) {
+		return ErrClienteEmUso
+	}
 	if err != nil {
 		return err
 	}
@@ -75,39 +79,60 @@ func (cr *ClienteRepository) DeletarCliente(id int64) error {
 }
 
 func (cr *ClienteRepository) BuscarClientePorId(id int64) (domain.Cliente, error) {
-	row := cr.db.QueryRow("SELECT * FROM clientes WHERE id = ? ", id)
-	fmt.Printf("\n%v\n", id)
-	if row.Err() != nil {
-		fmt.Printf("\n Aqui:%v\n", row.Err().Error())
-		return domain.Cliente{}, row.Err()
-	}
-	var (
-		nome, telefone, dataCadastro string
-	)
-	err := row.Scan(&id, &nome, &telefone, &dataCadastro)
-	if err != nil {
-		fmt.Printf("\n Ou aqui:%v\n", err)
-		return domain.Cliente{}, err
-	}
-	fmt.Print(id)
-	return domain.Cliente{ID: id, Nome: nome, Telefone: telefone, DataCadastro: dataCadastro}, nil
+	row := cr.db.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", id)
+	return scanCliente(row)
 }
 
+// AtualizarCliente altera apenas os campos não vazios de cliente
 func (cr *ClienteRepository) AtualizarCliente(id int64, cliente domain.Cliente) (sql.Result, error) {
 	var clauses []string
+	var args []any
 
 	if v := cliente.Nome; v != "" {
 		clauses = append(clauses, "nome = ?")
+		args = append(args, v)
 	}
 	if v := cliente.Telefone; v != "" {
 		clauses = append(clauses, "telefone = ?")
+		args = append(args, v)
+	}
+	if len(clauses) == 0 {
+		return nil, errors.New("nenhum campo para atualizar")
 	}
 	sql := "UPDATE clientes SET "
 	sql += strings.Join(clauses, ", ")
-	sql += "WHERE id = ? "
-	result, err := cr.db.Exec(sql, cliente.Nome, cliente.Telefone, id)
+	sql += " WHERE id = ?"
+	args = append(args, id)
+	result, err := cr.db.Exec(sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
+
+// DefinirCredito altera limite de crédito (nil remove o limite) e bloqueio do cliente
+func (cr *ClienteRepository) DefinirCredito(id int64, limite *domain.Decimal, bloqueado bool) error {
+	res, err := cr.db.Exec("UPDATE clientes SET limite_credito = ?, bloqueado = ? WHERE id = ?",
+		nullDecimal(limite), bloqueado, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// BuscarSituacaoCredito devolve limite, saldo em aberto e crédito disponível
+// do cliente ou sql.ErrNoRows
+func (cr *ClienteRepository) BuscarSituacaoCredito(id int64) (domain.SituacaoCredito, error) {
+	cliente, err := cr.BuscarClientePorId(id)
+	if err != nil {
+		return domain.SituacaoCredito{}, err
+	}
+	saldo, err := saldoEmAberto(cr.db, id, 0)
+	if err != nil {
+		return domain.SituacaoCredito{}, err
+	}
+	return cliente.SituacaoCredito(saldo), nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestClienteRepository(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewClienteRepository(db)
+
+	maria := criarCliente(t, db, "Maria Souza")
+	criarCliente(t, db, "João Lima")
+
+	got, err := cr.BuscarClientePorId(maria.ID)
+	if err != nil {
+		t.Fatalf("BuscarClientePorId: %v", err)
+	}
+	if got != maria {
+		t.Errorf("BuscarClientePorId = %+v, esperado %+v", got, maria)
+	}
+	if _, err := cr.BuscarClientePorId(999); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("cliente inexistente: err = %v, esperado sql.ErrNoRows", err)
+	}
+
+	clientes, err := cr.BuscarClientes(map[string]any{"nome": "maria"}, 10, 0)
+	if err != nil {
+		t.Fatalf("BuscarClientes: %v", err)
+	}
+	if len(clientes) != 1 || clientes[0].ID != maria.ID {
+		t.Errorf("BuscarClientes(nome=maria) = %+v", clientes)
+	}
+	clientes, err = cr.BuscarClientes(map[string]any{"telefone": "0000"}, 10, 0)
+	if err != nil || len(clientes) != 2 {
+		t.Errorf("BuscarClientes(telefone) = %d clientes, err %v", len(clientes), err)
+	}
+
+	res, err := cr.AtualizarCliente(maria.ID, domain.Cliente{Telefone: "1133334444"})
+	if err != nil {
+		t.Fatalf("AtualizarCliente: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("AtualizarCliente afetou %d linhas", n)
+	}
+	got, _ = cr.BuscarClientePorId(maria.ID)
+	if got.Nome != maria.Nome || got.Telefone != "1133334444" {
+		t.Errorf("após atualizar telefone: %+v", got)
+	}
+	if _, err := cr.AtualizarCliente(maria.ID, domain.Cliente{}); err == nil {
+		t.Error("AtualizarCliente sem campos deveria falhar")
+	}
+
+	if err := cr.DeletarCliente(maria.ID); err != nil {
+		t.Fatalf("DeletarCliente: %v", err)
+	}
+	if _, err := cr.BuscarClientePorId(maria.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("cliente removido ainda encontrado: %v", err)
+	}
+}
+
+func TestDeletarClienteComVendas(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 5, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 1}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	if err := NewClienteRepository(db).DeletarCliente(c.ID); !errors.Is(err, ErrClienteEmUso) {
+		t.Errorf("DeletarCliente com vendas: err = %v, esperado ErrClienteEmUso", err)
+	}
+}
+
+func TestCreditoCliente(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewClienteRepository(db)
+	c := criarCliente(t, db, "Cliente")
+
+	limite := decimal(t, "150.00")
+	if err := cr.DefinirCredito(c.ID, &limite, true); err != nil {
+		t.Fatalf("DefinirCredito: %v", err)
+	}
+	got, _ := cr.BuscarClientePorId(c.ID)
+	if got.LimiteCredito == nil || !got.LimiteCredito.Equal(limite) || !got.Bloqueado {
+		t.Errorf("cliente após DefinirCredito = %+v", got)
+	}
+	if err := cr.DefinirCredito(999, nil, false); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("cliente inexistente: err = %v", err)
+	}
+
+	situacao, err := cr.BuscarSituacaoCredito(c.ID)
+	if err != nil {
+		t.Fatalf("BuscarSituacaoCredito: %v", err)
+	}
+	if !situacao.SaldoAberto.Equal(decimal(t, "0")) || situacao.Disponivel == nil || !situacao.Disponivel.Equal(limite) {
+		t.Errorf("situação = %+v", situacao)
+	}
+
+	if err := cr.DefinirCredito(c.ID, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if situacao, _ = cr.BuscarSituacaoCredito(c.ID); situacao.Limite != nil || situacao.Disponivel != nil {
+		t.Errorf("sem limite = %+v", situacao)
+	}
+}
@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// Este arquivo concentra o mapeamento entre colunas do banco e o domínio: cada
+// tabela tem a lista explícita de colunas lidas e a função que as escaneia na
+// mesma ordem. Consultas nunca usam SELECT *; ao mudar o esquema, ajuste aqui.
+
+// scanner é satisfeito por *sql.Row e *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// queryer é satisfeito tanto por *sql.DB quanto por *sql.Tx
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// comExtras permite usar uma função scanX numa consulta que traz, depois das
+// colunas da tabela, colunas calculadas lidas em extras
+func comExtras(s scanner, extras ...any) scanner {
+	return scannerComExtras{s, extras}
+}
+
+type scannerComExtras struct {
+	s      scanner
+	extras []any
+}
+
+func (e scannerComExtras) Scan(dest ...any) error {
+	return e.s.Scan(append(dest, e.extras...)...)
+}
+
+const clienteColunas = `id, nome, COALESCE(telefone, ''), COALESCE(date(data_cadastro), ''),
+       limite_credito, bloqueado`
+
+func scanCliente(s scanner) (domain.Cliente, error) {
+	var (
+		c      domain.Cliente
+		limite domain.Decimal
+	)
+	if err := s.Scan(&c.ID, &c.Nome, &c.Telefone, &c.DataCadastro, &limite, &c.Bloqueado); err != nil {
+		return domain.Cliente{}, err
+	}
+	if limite.Decimal != nil {
+		c.LimiteCredito = &limite
+	}
+	return c, nil
+}
+
+const fornecedorColunas = `id, nome, cnpj, contato, telefone, email, condicoes_pagamento`
+
+func scanFornecedor(s scanner) (domain.Fornecedor, error) {
+	var (
+		f                                            domain.Fornecedor
+		cnpj, contato, telefone, email, condicoesPgt sql.NullString
+	)
+	if err := s.Scan(&f.Id, &f.Nome, &cnpj, &contato, &telefone, &email, &condicoesPgt); err != nil {
+		return domain.Fornecedor{}, err
+	}
+	f.CNPJ = cnpj.String
+	f.Contato = contato.String
+	f.Telefone = telefone.String
+	f.Email = email.String
+	f.CondicoesPagamento = condicoesPgt.String
+	return f, nil
+}
+
+// produtoColunas deve ser usada com produtoFrom, que junta o fornecedor
+const (
+	produtoColunas = `p.id, p.nome, p.fornecedor_id, f.nome, p.codigo_fornecedor, p.qtd_estoque,
+	       COALESCE(p.qtd_minima, 0), COALESCE(p.qtd_maxima, 0), p.preco_unitario, p.qtd_avariada, p.custo,
+	       COALESCE(p.codigo_barras, '')`
+	produtoFrom = `produtos p JOIN fornecedores f ON f.id = p.fornecedor_id`
+)
+
+func scanProduto(s scanner) (domain.Produto, error) {
+	var p domain.Produto
+	if err := s.Scan(&p.ID, &p.Nome, &p.Fornecedor.Id, &p.Fornecedor.Nome, &p.CodigoFornecedor,
+		&p.QuantidadeEstoque, &p.QuantidadeMinima, &p.QuantidadeMaxima, &p.Preco, &p.QuantidadeAvariada, &p.Custo,
+		&p.CodigoBarras); err != nil {
+		return domain.Produto{}, err
+	}
+	p.CalcularMargens()
+	return p, nil
+}
+
+const depositoColunas = `id, nome, ativo`
+
+func scanDeposito(s scanner) (domain.Deposito, error) {
+	var d domain.Deposito
+	err := s.Scan(&d.ID, &d.Nome, &d.Ativo)
+	return d, err
+}
+
+const transferenciaColunas = `t.id, t.origem_id, t.destino_id, t.data, t.observacao, t.usuario`
+
+func scanTransferencia(s scanner) (domain.Transferencia, error) {
+	var (
+		t                   domain.Transferencia
+		observacao, usuario sql.NullString
+	)
+	if err := s.Scan(&t.ID, &t.OrigemID, &t.DestinoID, &t.Data, &observacao, &usuario); err != nil {
+		return domain.Transferencia{}, err
+	}
+	t.Observacao, t.Usuario = observacao.String, usuario.String
+	return t, nil
+}
+
+const loteColunas = `l.id, l.produto_id, p.nome, l.deposito_id, d.nome, l.numero, COALESCE(date(l.validade), ''),
+       l.quantidade, l.criado_em`
+
+func scanLote(s scanner) (domain.Lote, error) {
+	var l domain.Lote
+	err := s.Scan(&l.ID, &l.ProdutoID, &l.Produto, &l.DepositoID, &l.Deposito, &l.Numero, &l.Validade,
+		&l.Quantidade, &l.CriadoEm)
+	return l, err
+}
+
+const compraColunas = `id, fornecedor_id, data, total, status, data_recebimento, cancelada_em, motivo_cancelamento,
+       deposito_id`
+
+func scanCompra(s scanner) (domain.Compra, error) {
+	var (
+		c                            domain.Compra
+		dataRecebimento, canceladaEm sql.NullTime
+		motivo                       sql.NullString
+	)
+	if err := s.Scan(&c.ID, &c.FornecedorID, &c.Data, &c.Total, &c.Status, &dataRecebimento,
+		&canceladaEm, &motivo, &c.DepositoID); err != nil {
+		return domain.Compra{}, err
+	}
+	if dataRecebimento.Valid {
+		c.DataRecebimento = &dataRecebimento.Time
+	}
+	if canceladaEm.Valid {
+		c.CanceladaEm = &canceladaEm.Time
+	}
+	c.MotivoCancelamento = motivo.String
+	return c, nil
+}
+
+const compraItemColunas = `compra_id, produto_id, quantidade, preco_unitario`
+
+func scanCompraItem(s scanner) (domain.CompraItem, error) {
+	var item domain.CompraItem
+	if err := s.Scan(&item.CompraID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario); err != nil {
+		return domain.CompraItem{}, err
+	}
+	item.Total = item.PrecoUnitario.MulInt(item.Quantidade).RoundTo(2)
+	return item, nil
+}
+
+const vendaColunas = `v.id, v.cliente_id, v.data_venda, v.total, v.data_pagamento, v.status_pagamento,
+       v.status, v.cancelada_em, v.motivo_cancelamento, v.credito_liberado_por,
+       v.bruto, v.valor_desconto, v.desconto_tipo, v.desconto_valor, v.deposito_id`
+
+func scanVenda(s scanner) (domain.Sale, error) {
+	var (
+		v                          domain.Sale
+		dataPagamento, canceladaEm sql.NullTime
+		motivo, creditoLiberadoPor sql.NullString
+		descontoTipo               sql.NullString
+		descontoValor              domain.Decimal
+	)
+	if err := s.Scan(&v.ID, &v.ClientID, &v.DataVenda, &v.Total, &dataPagamento, &v.PaymentStatus,
+		&v.Status, &canceladaEm, &motivo, &creditoLiberadoPor,
+		&v.Bruto, &v.ValorDesconto, &descontoTipo, &descontoValor, &v.DepositoID); err != nil {
+		return domain.Sale{}, err
+	}
+	v.Desconto = lerDesconto(descontoTipo, descontoValor)
+	if dataPagamento.Valid {
+		v.PaymentDate = &dataPagamento.Time
+	}
+	if canceladaEm.Valid {
+		v.CanceladaEm = &canceladaEm.Time
+	}
+	v.MotivoCancelamento = motivo.String
+	v.CreditoLiberadoPor = creditoLiberadoPor.String
+	return v, nil
+}
+
+// promocaoItemColunas traz a promoção aplicada ao item com nome e tipo
+// atuais; promoções usadas em itens não podem ser removidas
+const promocaoItemColunas = `promocao_id, valor_promocao,
+       (SELECT nome FROM promocoes WHERE promocoes.id = promocao_id),
+       (SELECT tipo FROM promocoes WHERE promocoes.id = promocao_id)`
+
+const vendaItemColunas = `venda_id, produto_id, quantidade, preco_tabela, preco_unitario, total, autorizado_por,
+       bruto, valor_desconto, desconto_tipo, desconto_valor, ` + promocaoItemColunas + `,
+       custo_unitario, lucro_bruto`
+
+func scanVendaItem(s scanner) (domain.SaleItem, error) {
+	var (
+		item                        domain.SaleItem
+		autorizadoPor, descontoTipo sql.NullString
+		descontoValor               domain.Decimal
+		promocaoID                  sql.NullInt64
+		valorPromocao               domain.Decimal
+		promocaoNome, promocaoTipo  sql.NullString
+		custo, lucro                domain.Decimal
+	)
+	if err := s.Scan(&item.SaleID, &item.ProductID, &item.Quantity, &item.ListPrice,
+		&item.UnitPrice, &item.Total, &autorizadoPor,
+		&item.Bruto, &item.ValorDesconto, &descontoTipo, &descontoValor,
+		&promocaoID, &valorPromocao, &promocaoNome, &promocaoTipo, &custo, &lucro); err != nil {
+		return domain.SaleItem{}, err
+	}
+	if custo.Decimal != nil {
+		item.CustoUnitario, item.LucroBruto = &custo, &lucro
+	}
+	item.Desconto = lerDesconto(descontoTipo, descontoValor)
+	if promocaoID.Valid {
+		item.Promocao = &domain.PromocaoAplicada{ID: promocaoID.Int64, Nome: promocaoNome.String,
+			Tipo: domain.TipoPromocao(promocaoTipo.String), Valor: valorPromocao}
+	}
+	item.AuthorizedBy = autorizadoPor.String
+	if item.AuthorizedBy != "" {
+		preco := item.UnitPrice
+		item.PriceOverride = &preco
+	}
+	return item, nil
+}
+
+const orcamentoColunas = `id, cliente_id, data, date(valido_ate), status, total, aprovado_em, venda_id, usuario,
+       bruto, valor_desconto, desconto_tipo, desconto_valor`
+
+func scanOrcamento(s scanner) (domain.Orcamento, error) {
+	var (
+		o                     domain.Orcamento
+		aprovadoEm            sql.NullTime
+		vendaID               sql.NullInt64
+		usuario, descontoTipo sql.NullString
+		descontoValor         domain.Decimal
+	)
+	if err := s.Scan(&o.ID, &o.ClientID, &o.Data, &o.ValidoAte, &o.Status, &o.Total,
+		&aprovadoEm, &vendaID, &usuario, &o.Bruto, &o.ValorDesconto, &descontoTipo, &descontoValor); err != nil {
+		return domain.Orcamento{}, err
+	}
+	o.Desconto = lerDesconto(descontoTipo, descontoValor)
+	if aprovadoEm.Valid {
+		o.AprovadoEm = &aprovadoEm.Time
+	}
+	if vendaID.Valid {
+		o.VendaID = &vendaID.Int64
+	}
+	o.Usuario = usuario.String
+	return o, nil
+}
+
+// orcamentoItemColunas segue a ordem de vendaItemColunas para reaproveitar
+// scanVendaItem; orçamento não guarda custo nem lucro
+const orcamentoItemColunas = `orcamento_id, produto_id, quantidade, preco_tabela, preco_unitario, total, autorizado_por,
+       bruto, valor_desconto, desconto_tipo, desconto_valor, ` + promocaoItemColunas + `,
+       NULL, NULL`
+
+const pagamentoColunas = `id, venda_id, valor, data_pagamento, forma, usuario, criado_em`
+
+func scanPagamento(s scanner) (domain.Pagamento, error) {
+	var (
+		p       domain.Pagamento
+		usuario sql.NullString
+	)
+	if err := s.Scan(&p.ID, &p.VendaID, &p.Valor, &p.Data, &p.Forma, &usuario, &p.CriadoEm); err != nil {
+		return domain.Pagamento{}, err
+	}
+	p.Usuario = usuario.String
+	return p, nil
+}
+
+const parcelaColunas = `id, venda_id, numero, valor, date(vencimento)`
+
+func scanParcela(s scanner) (domain.Parcela, error) {
+	var p domain.Parcela
+	err := s.Scan(&p.ID, &p.VendaID, &p.Numero, &p.Valor, &p.Vencimento)
+	return p, err
+}
+
+const devolucaoColunas = `id, venda_id, data, motivo, destino, forma_reembolso, valor, abatido,
+       reembolso, credito, usuario`
+
+func scanDevolucao(s scanner) (domain.Devolucao, error) {
+	var (
+		d                      domain.Devolucao
+		motivo, forma, usuario sql.NullString
+	)
+	if err := s.Scan(&d.ID, &d.VendaID, &d.Data, &motivo, &d.Destino, &forma, &d.Valor, &d.Abatido,
+		&d.Reembolso, &d.Credito, &usuario); err != nil {
+		return domain.Devolucao{}, err
+	}
+	d.Motivo, d.FormaReembolso, d.Usuario = motivo.String, domain.FormaPagamento(forma.String), usuario.String
+	return d, nil
+}
+
+const devolucaoItemColunas = `produto_id, quantidade, quantidade_avariada, preco_unitario, total`
+
+func scanDevolucaoItem(s scanner) (domain.ItemDevolucao, error) {
+	var item domain.ItemDevolucao
+	err := s.Scan(&item.ProdutoID, &item.Quantidade, &item.QuantidadeAvariada, &item.PrecoUnitario, &item.Total)
+	return item, err
+}
+
+const devolucaoFornecedorColunas = `id, compra_id, fornecedor_id, data, motivo, credito, credito_recebido_em, usuario`
+
+func scanDevolucaoFornecedor(s scanner) (domain.DevolucaoFornecedor, error) {
+	var (
+		d               domain.DevolucaoFornecedor
+		motivo, usuario sql.NullString
+		recebidoEm      sql.NullTime
+	)
+	if err := s.Scan(&d.ID, &d.CompraID, &d.FornecedorID, &d.Data, &motivo, &d.Credito,
+		&recebidoEm, &usuario); err != nil {
+		return domain.DevolucaoFornecedor{}, err
+	}
+	if recebidoEm.Valid {
+		d.CreditoRecebidoEm = &recebidoEm.Time
+	}
+	d.Motivo, d.Usuario = motivo.String, usuario.String
+	return d, nil
+}
+
+const devolucaoFornecedorItemColunas = `produto_id, quantidade, quantidade_avariada, custo_unitario`
+
+func scanDevolucaoFornecedorItem(s scanner) (domain.ItemDevolucaoFornecedor, error) {
+	var item domain.ItemDevolucaoFornecedor
+	if err := s.Scan(&item.ProdutoID, &item.Quantidade, &item.QuantidadeAvariada, &item.CustoUnitario); err != nil {
+		return domain.ItemDevolucaoFornecedor{}, err
+	}
+	item.Total = item.CustoUnitario.MulInt(item.Quantidade).RoundTo(2)
+	return item, nil
+}
+
+const creditoColunas = `id, cliente_id, valor, documento, usuario, criado_em`
+
+func scanCredito(s scanner) (domain.MovimentoCredito, error) {
+	var (
+		m       domain.MovimentoCredito
+		usuario sql.NullString
+	)
+	if err := s.Scan(&m.ID, &m.ClienteID, &m.Valor, &m.Documento, &usuario, &m.CriadoEm); err != nil {
+		return domain.MovimentoCredito{}, err
+	}
+	m.Usuario = usuario.String
+	return m, nil
+}
+
+const movimentoColunas = `id, produto_id, tipo, quantidade, saldo, motivo, documento, usuario, criado_em,
+       motivo_ajuste, deposito_id, COALESCE(saldo_deposito, saldo)`
+
+func scanMovimento(s scanner) (domain.MovimentoEstoque, error) {
+	var (
+		m                                  domain.MovimentoEstoque
+		motivo, doc, usuario, motivoAjuste sql.NullString
+	)
+	if err := s.Scan(&m.ID, &m.ProdutoID, &m.Tipo, &m.Quantidade, &m.Saldo,
+		&motivo, &doc, &usuario, &m.CriadoEm, &motivoAjuste, &m.DepositoID, &m.SaldoDeposito); err != nil {
+		return domain.MovimentoEstoque{}, err
+	}
+	m.Motivo, m.Documento, m.Usuario = motivo.String, doc.String, usuario.String
+	m.MotivoAjuste = domain.MotivoAjuste(motivoAjuste.String)
+	return m, nil
+}
+
+const promocaoColunas = `id, nome, tipo, fornecedor_id, produto_id, percentual, leve, pague,
+       date(inicio), date(fim), criada_em`
+
+func scanPromocao(s scanner) (domain.Promocao, error) {
+	var (
+		p                     domain.Promocao
+		fornecedorID, produto sql.NullInt64
+		leve, pague           sql.NullInt64
+		percentual            domain.Decimal
+		fim                   sql.NullString
+	)
+	if err := s.Scan(&p.ID, &p.Nome, &p.Tipo, &fornecedorID, &produto, &percentual, &leve, &pague,
+		&p.Inicio, &fim, &p.CriadaEm); err != nil {
+		return domain.Promocao{}, err
+	}
+	if percentual.Decimal != nil {
+		p.Percentual = &percentual
+	}
+	p.FornecedorID, p.ProdutoID = fornecedorID.Int64, produto.Int64
+	p.Leve, p.Pague = int(leve.Int64), int(pague.Int64)
+	p.Fim = fim.String
+	return p, nil
+}
+
+const limiteDescontoColunas = `perfil, percentual_maximo`
+
+func scanLimiteDesconto(s scanner) (domain.LimiteDesconto, error) {
+	var l domain.LimiteDesconto
+	err := s.Scan(&l.Perfil, &l.PercentualMaximo)
+	return l, err
+}
+
+// inventarioColunas traz os totais da sessão para listagens sem os itens
+const inventarioColunas = `i.id, i.deposito_id, i.descricao, i.status, i.aberto_em, i.usuario, i.aprovado_em, i.aprovado_por,
+       (SELECT COUNT(*) FROM inventarios_produtos ip WHERE ip.inventario_id = i.id),
+       (SELECT COUNT(*) FROM inventarios_produtos ip WHERE ip.inventario_id = i.id AND ip.contada IS NOT NULL),
+       (SELECT COUNT(*) FROM inventarios_produtos ip WHERE ip.inventario_id = i.id AND ip.contada <> ip.esperada)`
+
+func scanInventario(s scanner) (domain.Inventario, error) {
+	var (
+		inv                             domain.Inventario
+		descricao, usuario, aprovadoPor sql.NullString
+		aprovadoEm                      sql.NullTime
+	)
+	if err := s.Scan(&inv.ID, &inv.DepositoID, &descricao, &inv.Status, &inv.AbertoEm, &usuario, &aprovadoEm, &aprovadoPor,
+		&inv.Produtos, &inv.Contados, &inv.Divergentes); err != nil {
+		return domain.Inventario{}, err
+	}
+	inv.Descricao, inv.Usuario, inv.AprovadoPor = descricao.String, usuario.String, aprovadoPor.String
+	if aprovadoEm.Valid {
+		inv.AprovadoEm = &aprovadoEm.Time
+	}
+	return inv, nil
+}
+
+// inventarioItemColunas deve ser usada com inventarios_produtos ip JOIN produtos p
+const inventarioItemColunas = `ip.produto_id, p.nome, ip.esperada, ip.contada, ip.contado_em, ip.contado_por`
+
+func scanInventarioItem(s scanner) (domain.ItemInventario, error) {
+	var (
+		item       domain.ItemInventario
+		contada    sql.NullInt64
+		contadoEm  sql.NullTime
+		contadoPor sql.NullString
+	)
+	if err := s.Scan(&item.ProdutoID, &item.Nome, &item.Esperada, &contada, &contadoEm, &contadoPor); err != nil {
+		return domain.ItemInventario{}, err
+	}
+	if contada.Valid {
+		item.Contada = &contada.Int64
+	}
+	if contadoEm.Valid {
+		item.ContadoEm = &contadoEm.Time
+	}
+	item.ContadoPor = contadoPor.String
+	return item, nil
+}
+
+// nullString grava strings vazias como NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullDecimal grava ponteiro nulo como NULL
+func nullDecimal(d *domain.Decimal) any {
+	if d == nil || d.Decimal == nil {
+		return nil
+	}
+	return d.String()
+}
+
+// colunasDesconto devolve desconto_tipo e desconto_valor; sem desconto, ambos NULL
+func colunasDesconto(d *domain.Desconto) (sql.NullString, any) {
+	if d == nil {
+		return sql.NullString{}, nil
+	}
+	return nullString(string(d.Tipo)), d.Valor.String()
+}
+
+// colunasPromocao devolve promocao_id e valor_promocao do item
+func colunasPromocao(p *domain.PromocaoAplicada) (sql.NullInt64, string) {
+	if p == nil {
+		return sql.NullInt64{}, "0"
+	}
+	return nullInt64(p.ID), p.Valor.String()
+}
+
+// lerDesconto monta o desconto concedido a partir de desconto_tipo e desconto_valor
+func lerDesconto(tipo sql.NullString, valor domain.Decimal) *domain.Desconto {
+	if !tipo.Valid {
+		return nil
+	}
+	return &domain.Desconto{Tipo: domain.TipoDesconto(tipo.String), Valor: valor}
+}
+
+// nullInt64 grava zero como NULL
+func nullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
+
+// violaChaveEstrangeira indica que o comando falhou porque outros registros
+// ainda referenciam a linha
+func violaChaveEstrangeira(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
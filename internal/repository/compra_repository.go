@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrCompraJaRecebida indica que a compra não está mais pendente de recebimento
+var ErrCompraJaRecebida = errors.New("compra já foi recebida")
+
+// ErrCompraCancelada indica operação sobre compra que foi cancelada
+var ErrCompraCancelada = errors.New("compra cancelada")
+
+// ErrProdutoDeOutroFornecedor indica item que não pertence ao fornecedor da compra
+var ErrProdutoDeOutroFornecedor = errors.New("produto não pertence ao fornecedor da compra")
+
+// ErrLotesExcedemItem indica lotes do recebimento que somam mais que a quantidade comprada
+var ErrLotesExcedemItem = errors.New("lotes somam mais que a quantidade do item")
+
+// CompraRepository encapsula acessos ao banco para compras e seus itens
+type CompraRepository struct {
+	db *sql.DB
+}
+
+// NewCompraRepository cria uma instância de CompraRepository
+func NewCompraRepository(db *sql.DB) *CompraRepository {
+	return &CompraRepository{db: db}
+}
+
+// SalvarCompra registra um pedido de compra pendente com seus itens, a ser
+// recebido no depósito c.DepositoID (o principal quando zero)
+func (cr *CompraRepository) SalvarCompra(c *domain.Compra) error {
+	tx, err := cr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if c.DepositoID == 0 {
+		c.DepositoID = domain.DepositoPrincipal
+	}
+	if err := conferirDeposito(tx, c.DepositoID); err != nil {
+		return err
+	}
+
+	for _, item := range c.Items {
+		var fornecedorID int64
+		err := tx.QueryRow("SELECT fornecedor_id FROM produtos WHERE id = ?", item.ProdutoID).Scan(&fornecedorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("produto %d: %w", item.ProdutoID, sql.ErrNoRows)
+		} else if err != nil {
+			return err
+		}
+		if fornecedorID != c.FornecedorID {
+			return fmt.Errorf("produto %d: %w", item.ProdutoID, ErrProdutoDeOutroFornecedor)
+		}
+	}
+
+	if c.Data.IsZero() {
+		c.Data = time.Now()
+	}
+	c.Status = domain.StatusCompraPendente
+	c.DataRecebimento = nil
+	c.CalcularTotal()
+
+	res, err := tx.Exec(
+		`INSERT INTO compras (fornecedor_id, data, total, status, deposito_id) VALUES (?, ?, ?, ?, ?)`,
+		c.FornecedorID, c.Data, c.Total.String(), c.Status, c.DepositoID,
+	)
+	if err != nil {
+		return err
+	}
+	c.ID, _ = res.LastInsertId()
+
+	valueStrings := make([]string, 0, len(c.Items))
+	valueArgs := make([]any, 0, len(c.Items)*4)
+	for i := range c.Items {
+		c.Items[i].CompraID = c.ID
+		valueStrings = append(valueStrings, "(?, ?, ?, ?)")
+		valueArgs = append(valueArgs, c.ID, c.Items[i].ProdutoID, c.Items[i].Quantidade, c.Items[i].PrecoUnitario.String())
+	}
+	query := fmt.Sprintf(`
+  INSERT INTO compras_produtos
+    (compra_id, produto_id, quantidade, preco_unitario)
+  VALUES %s`, strings.Join(valueStrings, ","))
+	if _, err := tx.Exec(query, valueArgs...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BuscarCompras consulta compras (com itens) usando filtros opcionais
+func (cr *CompraRepository) BuscarCompras(filters map[string]any, limit, offset int) ([]domain.Compra, error) {
+	query := "SELECT " + compraColunas + " FROM compras"
+	var clauses []string
+	var args []any
+
+	if v, ok := filters["fornecedor_id"]; ok {
+		clauses = append(clauses, "fornecedor_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["status"]; ok {
+		clauses = append(clauses, "status = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_inicio"]; ok {
+		clauses = append(clauses, "date(data) >= date(?)")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_fim"]; ok {
+		clauses = append(clauses, "date(data) <= date(?)")
+		args = append(args, v)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY data DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := cr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	compras := []domain.Compra{}
+	for rows.Next() {
+		c, err := scanCompra(rows)
+		if err != nil {
+			return nil, err
+		}
+		compras = append(compras, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range compras {
+		if compras[i].Items, err = buscarItensCompra(cr.db, compras[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return compras, nil
+}
+
+// BuscarCompraPorId retorna a compra com seus itens ou sql.ErrNoRows
+func (cr *CompraRepository) BuscarCompraPorId(id int64) (domain.Compra, error) {
+	return buscarCompra(cr.db, id)
+}
+
+// ReceberCompra dá entrada no estoque de todos os itens da compra, no
+// depósito da compra, numa única transação.
+// custos permite informar o custo efetivamente pago por produto; itens ausentes
+// mantêm o custo do pedido. lotes reparte, por produto, as unidades recebidas
+// em lotes com número e/ou validade; o que não for repartido entra sem lote e
+// lotes que somam mais que o item devolvem ErrLotesExcedemItem.
+func (cr *CompraRepository) ReceberCompra(id int64, custos map[int64]domain.Decimal, lotes map[int64][]domain.Lote, usuario string) (domain.Compra, error) {
+	tx, err := cr.db.Begin()
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	defer tx.Rollback()
+
+	c, err := buscarCompra(tx, id)
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	if c.Status == domain.StatusCompraCancelada {
+		return domain.Compra{}, ErrCompraCancelada
+	}
+	if c.Status != domain.StatusCompraPendente {
+		return domain.Compra{}, ErrCompraJaRecebida
+	}
+	for produtoID := range custos {
+		if !compraTemProduto(c, produtoID) {
+			return domain.Compra{}, fmt.Errorf("produto %d não faz parte da compra: %w", produtoID, sql.ErrNoRows)
+		}
+	}
+	for produtoID := range lotes {
+		if !compraTemProduto(c, produtoID) {
+			return domain.Compra{}, fmt.Errorf("produto %d não faz parte da compra: %w", produtoID, sql.ErrNoRows)
+		}
+	}
+
+	for i := range c.Items {
+		item := &c.Items[i]
+		if custo, ok := custos[item.ProdutoID]; ok {
+			item.PrecoUnitario = custo
+		}
+		if _, err := tx.Exec(
+			`UPDATE compras_produtos SET preco_unitario = ? WHERE compra_id = ? AND produto_id = ?`,
+			item.PrecoUnitario.String(), c.ID, item.ProdutoID,
+		); err != nil {
+			return domain.Compra{}, err
+		}
+		if err := atualizarCustoMedio(tx, item.ProdutoID, item.Quantidade, item.PrecoUnitario); err != nil {
+			return domain.Compra{}, err
+		}
+		m := domain.MovimentoEstoque{
+			ProdutoID:  item.ProdutoID,
+			DepositoID: c.DepositoID,
+			Tipo:       domain.MovimentoCompra,
+			Quantidade: item.Quantidade,
+			Documento:  documento("compra", c.ID),
+			Usuario:    usuario,
+		}
+		if err := movimentarEstoque(tx, &m); err != nil {
+			return domain.Compra{}, err
+		}
+		var emLotes int64
+		for _, lote := range lotes[item.ProdutoID] {
+			emLotes += lote.Quantidade
+			if emLotes > item.Quantidade {
+				return domain.Compra{}, fmt.Errorf("produto %d: %w", item.ProdutoID, ErrLotesExcedemItem)
+			}
+			if err := entrarLote(tx, &m, &lote); err != nil {
+				return domain.Compra{}, err
+			}
+			item.Lotes = append(item.Lotes, lote)
+		}
+	}
+
+	c.CalcularTotal()
+	agora := time.Now()
+	res, err := tx.Exec(
+		`UPDATE compras SET status = ?, data_recebimento = ?, total = ? WHERE id = ? AND status = ?`,
+		domain.StatusCompraRecebida, agora, c.Total.String(), c.ID, domain.StatusCompraPendente,
+	)
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Compra{}, ErrCompraJaRecebida
+	}
+	c.Status = domain.StatusCompraRecebida
+	c.DataRecebimento = &agora
+
+	return c, tx.Commit()
+}
+
+// CancelarCompra cancela um pedido ainda não recebido. Como nada entrou no
+// estoque, nenhum movimento é lançado.
+func (cr *CompraRepository) CancelarCompra(id int64, motivo string) (domain.Compra, error) {
+	c, err := buscarCompra(cr.db, id)
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	switch c.Status {
+	case domain.StatusCompraCancelada:
+		return domain.Compra{}, ErrCompraCancelada
+	case domain.StatusCompraRecebida:
+		return domain.Compra{}, ErrCompraJaRecebida
+	}
+
+	agora := time.Now()
+	res, err := cr.db.Exec(
+		`UPDATE compras SET status = ?, cancelada_em = ?, motivo_cancelamento = ? WHERE id = ? AND status = ?`,
+		domain.StatusCompraCancelada, agora, nullString(motivo), id, domain.StatusCompraPendente,
+	)
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// recebida ou cancelada entre a leitura e a atualização
+		return domain.Compra{}, ErrCompraJaRecebida
+	}
+	c.Status = domain.StatusCompraCancelada
+	c.CanceladaEm = &agora
+	c.MotivoCancelamento = motivo
+	return c, nil
+}
+
+func buscarCompra(q queryer, id int64) (domain.Compra, error) {
+	c, err := scanCompra(q.QueryRow("SELECT "+compraColunas+" FROM compras WHERE id = ?", id))
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	if c.Items, err = buscarItensCompra(q, id); err != nil {
+		return domain.Compra{}, err
+	}
+	lotes, err := lotesRecebidos(q, documento("compra", id))
+	if err != nil {
+		return domain.Compra{}, err
+	}
+	for i := range c.Items {
+		c.Items[i].Lotes = lotes[c.Items[i].ProdutoID]
+	}
+	return c, nil
+}
+
+func buscarItensCompra(q queryer, compraID int64) ([]domain.CompraItem, error) {
+	rows, err := q.Query(
+		"SELECT "+compraItemColunas+" FROM compras_produtos WHERE compra_id = ? ORDER BY produto_id",
+		compraID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.CompraItem{}
+	for rows.Next() {
+		item, err := scanCompraItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+func compraTemProduto(c domain.Compra, produtoID int64) bool {
+	for _, item := range c.Items {
+		if item.ProdutoID == produtoID {
+			return true
+		}
+	}
+	return false
+}
+
+// atualizarCustoMedio recalcula o custo médio ponderado do produto com a
+// entrada de quantidade unidades a custo; chamar antes de lançar a entrada
+func atualizarCustoMedio(tx *sql.Tx, produtoID, quantidade int64, custo domain.Decimal) error {
+	var (
+		estoque    int64
+		custoAtual domain.Decimal
+	)
+	if err := tx.QueryRow("SELECT qtd_estoque, custo FROM produtos WHERE id = ?", produtoID).
+		Scan(&estoque, &custoAtual); err != nil {
+		return err
+	}
+	novo := domain.CustoMedio(estoque, custoAtual, quantidade, custo)
+	_, err := tx.Exec("UPDATE produtos SET custo = ? WHERE id = ?", novo.String(), produtoID)
+	return err
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestCompraRepository(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewCompraRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 2, "10.00")
+	b := criarProduto(t, db, f, "B2", 0, "5.00")
+
+	c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: a.ID, Quantidade: 10, PrecoUnitario: decimal(t, "6.00")},
+		{ProdutoID: b.ID, Quantidade: 4, PrecoUnitario: decimal(t, "2.50")},
+	}}
+	if err := cr.SalvarCompra(&c); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	if c.Status != domain.StatusCompraPendente || !c.Total.Equal(decimal(t, "70")) {
+		t.Errorf("SalvarCompra: status %s total %s", c.Status, c.Total)
+	}
+
+	got, err := cr.BuscarCompraPorId(c.ID)
+	if err != nil {
+		t.Fatalf("BuscarCompraPorId: %v", err)
+	}
+	if got.FornecedorID != f.Id || len(got.Items) != 2 || !got.Total.Equal(c.Total) || got.DataRecebimento != nil {
+		t.Errorf("BuscarCompraPorId = %+v", got)
+	}
+
+	lista, err := cr.BuscarCompras(map[string]any{
+		"fornecedor_id": f.Id,
+		"status":        string(domain.StatusCompraPendente),
+		"data_inicio":   c.Data.Format("2006-01-02"),
+		"data_fim":      c.Data.Format("2006-01-02"),
+	}, 10, 0)
+	if err != nil {
+		t.Fatalf("BuscarCompras: %v", err)
+	}
+	if len(lista) != 1 || len(lista[0].Items) != 2 {
+		t.Errorf("BuscarCompras = %+v", lista)
+	}
+
+	recebida, err := cr.ReceberCompra(c.ID, map[int64]domain.Decimal{a.ID: decimal(t, "5.50")}, nil, "teste")
+	if err != nil {
+		t.Fatalf("ReceberCompra: %v", err)
+	}
+	if recebida.Status != domain.StatusCompraRecebida || !recebida.Total.Equal(decimal(t, "65")) {
+		t.Errorf("ReceberCompra: status %s total %s", recebida.Status, recebida.Total)
+	}
+	produtos, _ := NewProdutoRepository(db).Find(map[string]any{"id": a.ID}, 1, 0)
+	if len(produtos) != 1 || produtos[0].QuantidadeEstoque != 12 {
+		t.Errorf("estoque após recebimento = %+v", produtos)
+	}
+
+	if _, err := cr.ReceberCompra(c.ID, nil, nil, "teste"); !errors.Is(err, ErrCompraJaRecebida) {
+		t.Errorf("receber de novo: err = %v, esperado ErrCompraJaRecebida", err)
+	}
+	if _, err := cr.ReceberCompra(999, nil, nil, "teste"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("compra inexistente: err = %v", err)
+	}
+}
+
+func TestSalvarCompraProdutoDeOutroFornecedor(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	outro := criarFornecedor(t, db, "Outro")
+	p := criarProduto(t, db, outro, "A1", 0, "1.00")
+
+	c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: p.ID, Quantidade: 1, PrecoUnitario: decimal(t, "1")},
+	}}
+	if err := NewCompraRepository(db).SalvarCompra(&c); !errors.Is(err, ErrProdutoDeOutroFornecedor) {
+		t.Errorf("err = %v, esperado ErrProdutoDeOutroFornecedor", err)
+	}
+}
+
+func TestCancelarCompra(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewCompraRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 2, "10.00")
+
+	c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: a.ID, Quantidade: 10, PrecoUnitario: decimal(t, "6.00")},
+	}}
+	if err := cr.SalvarCompra(&c); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	cancelada, err := cr.CancelarCompra(c.ID, "pedido em duplicidade")
+	if err != nil {
+		t.Fatalf("CancelarCompra: %v", err)
+	}
+	if cancelada.Status != domain.StatusCompraCancelada || cancelada.CanceladaEm == nil ||
+		cancelada.MotivoCancelamento != "pedido em duplicidade" {
+		t.Errorf("CancelarCompra = %+v", cancelada)
+	}
+	if got := estoque(t, db, a.ID); got != 2 {
+		t.Errorf("estoque após cancelamento = %d, esperado 2", got)
+	}
+	if _, err := cr.ReceberCompra(c.ID, nil, nil, "teste"); !errors.Is(err, ErrCompraCancelada) {
+		t.Errorf("receber compra cancelada: err = %v", err)
+	}
+	if _, err := cr.CancelarCompra(c.ID, ""); !errors.Is(err, ErrCompraCancelada) {
+		t.Errorf("cancelar duas vezes: err = %v", err)
+	}
+
+	recebida := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: a.ID, Quantidade: 1, PrecoUnitario: decimal(t, "6.00")},
+	}}
+	if err := cr.SalvarCompra(&recebida); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.ReceberCompra(recebida.ID, nil, nil, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.CancelarCompra(recebida.ID, ""); !errors.Is(err, ErrCompraJaRecebida) {
+		t.Errorf("cancelar compra recebida: err = %v", err)
+	}
+}
+
+func TestCustoMedioELucroBruto(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewCompraRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := domain.Produto{Nome: "Produto A1", Fornecedor: f, CodigoFornecedor: "A1",
+		QuantidadeEstoque: 10, Preco: decimal(t, "8.00"), Custo: decimal(t, "4.00")}
+	if err := NewProdutoRepository(db).Save(&p, "teste"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: p.ID, Quantidade: 10, PrecoUnitario: decimal(t, "5.00")},
+	}}
+	if err := cr.SalvarCompra(&c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.ReceberCompra(c.ID, nil, nil, "teste"); err != nil {
+		t.Fatalf("ReceberCompra: %v", err)
+	}
+	produtos, err := NewProdutoRepository(db).Find(map[string]any{"id": p.ID}, 1, 0)
+	if err != nil || len(produtos) != 1 {
+		t.Fatalf("Find: %v", err)
+	}
+	if got := produtos[0]; !got.Custo.Equal(decimal(t, "4.50")) || got.Markup == nil ||
+		!got.Markup.Equal(decimal(t, "77.78")) {
+		t.Errorf("produto após recebimento: custo %s markup %v", got.Custo, got.Markup)
+	}
+
+	vr := NewVendasRepository(db)
+	cliente := criarCliente(t, db, "Cliente")
+	venda := domain.Sale{ClientID: cliente.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 4}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatal(err)
+	}
+
+	// um novo custo não altera o lucro já registrado na venda
+	if _, err := db.Exec("UPDATE produtos SET custo = 7 WHERE id = ?", p.ID); err != nil {
+		t.Fatal(err)
+	}
+	gravada, err := vr.BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := gravada.Items[0]
+	if item.CustoUnitario == nil || !item.CustoUnitario.Equal(decimal(t, "4.50")) ||
+		!item.LucroBruto.Equal(decimal(t, "14.00")) {
+		t.Errorf("item vendido: custo %v lucro %v", item.CustoUnitario, item.LucroBruto)
+	}
+}
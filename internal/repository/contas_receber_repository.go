@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ContasReceberRepository consulta o que os clientes devem, a partir das
+// vendas ativas, dos pagamentos e dos planos de parcelas
+type ContasReceberRepository struct {
+	db *sql.DB
+}
+
+// NewContasReceberRepository cria uma instância de ContasReceberRepository
+func NewContasReceberRepository(db *sql.DB) *ContasReceberRepository {
+	return &ContasReceberRepository{db: db}
+}
+
+// BuscarTitulosAbertos lista os títulos em aberto na data hoje das vendas
+// ativas ainda não quitadas; clienteID zero traz os de todos os clientes
+func (cr *ContasReceberRepository) BuscarTitulosAbertos(clienteID int64, hoje time.Time) ([]domain.TituloAberto, error) {
+	query := "SELECT " + vendaColunas + `, c.nome,
+	       ROUND(COALESCE((SELECT SUM(pg.valor) FROM pagamentos pg WHERE pg.venda_id = v.id), 0), 2)
+	  FROM vendas v
+	  JOIN clientes c ON c.id = v.cliente_id
+	 WHERE v.status = ? AND v.status_pagamento <> ?`
+	args := []any{domain.StatusVendaAtiva, domain.PaymentStatusPaid}
+	if clienteID != 0 {
+		query += " AND v.cliente_id = ?"
+		args = append(args, clienteID)
+	}
+	query += " ORDER BY v.data_venda, v.id"
+
+	rows, err := cr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type vendaAberta struct {
+		venda domain.Sale
+		pago  domain.Decimal
+	}
+	var abertas []vendaAberta
+	for rows.Next() {
+		var (
+			a       vendaAberta
+			cliente domain.Cliente
+		)
+		if a.venda, err = scanVenda(comExtras(rows, &cliente.Nome, &a.pago)); err != nil {
+			return nil, err
+		}
+		cliente.ID = a.venda.ClientID
+		a.venda.Cliente = &cliente
+		abertas = append(abertas, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	titulos := []domain.TituloAberto{}
+	for _, a := range abertas {
+		parcelas, err := buscarParcelas(cr.db, a.venda.ID)
+		if err != nil {
+			return nil, err
+		}
+		titulos = append(titulos, domain.TitulosDaVenda(a.venda, a.pago, parcelas, hoje)...)
+	}
+	return titulos, nil
+}
+
+// BuscarExtrato monta o extrato do cliente com vendas, cancelamentos,
+// devoluções e pagamentos entre inicio e fim (datas zeradas não limitam) ou
+// sql.ErrNoRows. O cancelamento estorna só o que a venda ainda devia: o que
+// foi pago ou abatido por devolução já saiu do saldo.
+func (cr *ContasReceberRepository) BuscarExtrato(clienteID int64, inicio, fim time.Time) (domain.Extrato, error) {
+	cliente, err := scanCliente(cr.db.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", clienteID))
+	if err != nil {
+		return domain.Extrato{}, err
+	}
+
+	var lancamentos []domain.LancamentoExtrato
+	cancelamentos := make(map[int64]int) // venda -> posição do cancelamento em lancamentos
+	rows, err := cr.db.Query("SELECT "+vendaColunas+" FROM vendas v WHERE v.cliente_id = ?", clienteID)
+	if err != nil {
+		return domain.Extrato{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v, err := scanVenda(rows)
+		if err != nil {
+			return domain.Extrato{}, err
+		}
+		lancamentos = append(lancamentos, domain.LancamentoExtrato{
+			Data:    v.DataVenda,
+			Tipo:    domain.LancamentoVenda,
+			VendaID: v.ID,
+			Valor:   v.Total,
+		})
+		if v.Status == domain.StatusVendaCancelada && v.CanceladaEm != nil {
+			cancelamentos[v.ID] = len(lancamentos)
+			lancamentos = append(lancamentos, domain.LancamentoExtrato{
+				Data:    *v.CanceladaEm,
+				Tipo:    domain.LancamentoCancelamento,
+				VendaID: v.ID,
+				Valor:   domain.DecimalFromInt(0).Sub(v.Total),
+				Detalhe: v.MotivoCancelamento,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return domain.Extrato{}, err
+	}
+	rows.Close()
+
+	rows, err = cr.db.Query("SELECT "+pagamentoColunas+` FROM pagamentos
+	   WHERE venda_id IN (SELECT id FROM vendas WHERE cliente_id = ?)`, clienteID)
+	if err != nil {
+		return domain.Extrato{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p, err := scanPagamento(rows)
+		if err != nil {
+			return domain.Extrato{}, err
+		}
+		tipo := domain.LancamentoPagamento
+		if p.Forma == domain.FormaDevolucao {
+			tipo = domain.LancamentoDevolucao
+		}
+		lancamentos = append(lancamentos, domain.LancamentoExtrato{
+			Data:    p.Data,
+			Tipo:    tipo,
+			VendaID: p.VendaID,
+			Valor:   domain.DecimalFromInt(0).Sub(p.Valor),
+			Detalhe: string(p.Forma),
+		})
+		if i, ok := cancelamentos[p.VendaID]; ok {
+			lancamentos[i].Valor = lancamentos[i].Valor.Add(p.Valor)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return domain.Extrato{}, err
+	}
+
+	return domain.MontarExtrato(cliente, lancamentos, inicio, fim), nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestContasReceberRepository(t *testing.T) {
+	db := novoBanco(t)
+	crr := NewContasReceberRepository(db)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 100, "10.00")
+	maria := criarCliente(t, db, "Maria")
+	joao := criarCliente(t, db, "João")
+	hoje := time.Now()
+
+	vender := func(c domain.Cliente, qtd int, data time.Time, pagamentos ...domain.Pagamento) domain.Sale {
+		t.Helper()
+		v := domain.Sale{ClientID: c.ID, DataVenda: data, Pagamentos: pagamentos,
+			Items: []domain.SaleItem{{ProductID: p.ID, Quantity: qtd}}}
+		if err := vr.SalvarVenda(&v, "teste"); err != nil {
+			t.Fatalf("SalvarVenda: %v", err)
+		}
+		return v
+	}
+
+	antiga := vender(maria, 5, hoje.AddDate(0, 0, -45), domain.Pagamento{Valor: decimal(t, "20"), Forma: domain.FormaPix})
+	vender(maria, 1, hoje.AddDate(0, 0, -100))
+	parcelada := vender(joao, 9, hoje.AddDate(0, 0, -5))
+	vender(joao, 2, hoje, domain.Pagamento{Valor: decimal(t, "20"), Forma: domain.FormaDinheiro})
+	cancelada := vender(joao, 3, hoje.AddDate(0, 0, -200))
+	if _, err := vr.CancelarVenda(cancelada.ID, "erro", "teste"); err != nil {
+		t.Fatal(err)
+	}
+	parcelas, _ := domain.DividirParcelas(parcelada.Total, 3, hoje.AddDate(0, 0, -2), 30)
+	if err := NewPagamentoRepository(db).DefinirParcelas(parcelada.ID, parcelas); err != nil {
+		t.Fatal(err)
+	}
+
+	titulos, err := crr.BuscarTitulosAbertos(0, hoje)
+	if err != nil {
+		t.Fatalf("BuscarTitulosAbertos: %v", err)
+	}
+	if len(titulos) != 5 {
+		t.Fatalf("títulos = %+v, esperado 5 (2 de Maria e 3 parcelas de João)", titulos)
+	}
+
+	saldos := domain.ResumirPorCliente(titulos)
+	if len(saldos) != 2 || saldos[0].ClienteID != joao.ID || !saldos[0].Saldo.Equal(decimal(t, "90")) ||
+		!saldos[0].Faixas.Ate30.Equal(decimal(t, "30")) || !saldos[0].Faixas.AVencer.Equal(decimal(t, "60")) {
+		t.Errorf("saldo de João = %+v", saldos[0])
+	}
+	if saldos[1].Nome != "Maria" || !saldos[1].Saldo.Equal(decimal(t, "40")) ||
+		!saldos[1].Faixas.De31a60.Equal(decimal(t, "30")) || !saldos[1].Faixas.Acima90.Equal(decimal(t, "10")) {
+		t.Errorf("saldo de Maria = %+v", saldos[1])
+	}
+
+	somenteMaria, err := crr.BuscarTitulosAbertos(maria.ID, hoje)
+	if err != nil || len(somenteMaria) != 2 || somenteMaria[0].VendaID == antiga.ID {
+		t.Errorf("títulos de Maria = %+v, err %v", somenteMaria, err)
+	}
+
+	extrato, err := crr.BuscarExtrato(joao.ID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("BuscarExtrato: %v", err)
+	}
+	// o cancelamento entra na data em que foi feito, não na data da venda
+	tipos := []domain.TipoLancamento{
+		domain.LancamentoVenda, domain.LancamentoVenda, domain.LancamentoVenda,
+		domain.LancamentoPagamento, domain.LancamentoCancelamento,
+	}
+	if len(extrato.Lancamentos) != len(tipos) {
+		t.Fatalf("lançamentos = %+v", extrato.Lancamentos)
+	}
+	for i, tipo := range tipos {
+		if extrato.Lancamentos[i].Tipo != tipo {
+			t.Errorf("lançamento %d = %s, esperado %s", i, extrato.Lancamentos[i].Tipo, tipo)
+		}
+	}
+	if !extrato.SaldoFinal.Equal(decimal(t, "90")) || extrato.Cliente.ID != joao.ID {
+		t.Errorf("saldo final = %s", extrato.SaldoFinal)
+	}
+
+	recente, err := crr.BuscarExtrato(joao.ID, hoje.AddDate(0, 0, -1), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recente.Lancamentos) != 3 || !recente.SaldoAnterior.Equal(decimal(t, "120")) {
+		t.Errorf("extrato do período = %+v", recente)
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrDepositoInexistente indica documento que aponta um depósito não cadastrado
+var ErrDepositoInexistente = errors.New("depósito não encontrado")
+
+// ErrNomeDepositoEmUso indica outro depósito com o mesmo nome
+var ErrNomeDepositoEmUso = errors.New("já existe depósito com esse nome")
+
+// ErrDepositoComEstoque indica tentativa de desativar depósito que ainda guarda mercadoria
+var ErrDepositoComEstoque = errors.New("depósito com estoque não pode ser desativado; transfira os produtos antes")
+
+// ErrDesativarPrincipal indica tentativa de desativar a loja principal
+var ErrDesativarPrincipal = errors.New("o depósito principal não pode ser desativado")
+
+// DepositoRepository grava os locais de estoque e consulta o saldo de cada um
+type DepositoRepository struct {
+	db *sql.DB
+}
+
+// NewDepositoRepository cria uma instância de DepositoRepository
+func NewDepositoRepository(db *sql.DB) *DepositoRepository {
+	return &DepositoRepository{db: db}
+}
+
+// CriarDeposito grava um depósito ativo
+func (dr *DepositoRepository) CriarDeposito(d *domain.Deposito) error {
+	d.Ativo = true
+	res, err := dr.db.Exec("INSERT INTO depositos (nome, ativo) VALUES (?, ?)", d.Nome, d.Ativo)
+	if err != nil {
+		return traduzErroDeposito(err)
+	}
+	d.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// BuscarDepositos lista os depósitos por nome; filtro opcional "ativo" (bool)
+func (dr *DepositoRepository) BuscarDepositos(filters map[string]any) ([]domain.Deposito, error) {
+	query := "SELECT " + depositoColunas + " FROM depositos"
+	var args []any
+	if v, ok := filters["ativo"]; ok {
+		query += " WHERE ativo = ?"
+		args = append(args, v)
+	}
+	query += " ORDER BY id = 1 DESC, nome"
+
+	rows, err := dr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depositos := []domain.Deposito{}
+	for rows.Next() {
+		d, err := scanDeposito(rows)
+		if err != nil {
+			return nil, err
+		}
+		depositos = append(depositos, d)
+	}
+	return depositos, rows.Err()
+}
+
+// BuscarDepositoPorId retorna o depósito ou sql.ErrNoRows
+func (dr *DepositoRepository) BuscarDepositoPorId(id int64) (domain.Deposito, error) {
+	return scanDeposito(dr.db.QueryRow("SELECT "+depositoColunas+" FROM depositos WHERE id = ?", id))
+}
+
+// AtualizarDeposito altera nome e situação. Só desativa depósito vazio, e
+// nunca o principal.
+func (dr *DepositoRepository) AtualizarDeposito(d *domain.Deposito) error {
+	tx, err := dr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !d.Ativo {
+		if d.ID == domain.DepositoPrincipal {
+			return ErrDesativarPrincipal
+		}
+		var unidades int64
+		if err := tx.QueryRow("SELECT COALESCE(SUM(quantidade), 0) FROM estoque_depositos WHERE deposito_id = ?",
+			d.ID).Scan(&unidades); err != nil {
+			return err
+		}
+		if unidades > 0 {
+			return ErrDepositoComEstoque
+		}
+	}
+
+	res, err := tx.Exec("UPDATE depositos SET nome = ?, ativo = ? WHERE id = ?", d.Nome, d.Ativo, d.ID)
+	if err != nil {
+		return traduzErroDeposito(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// BuscarEstoqueDeposito lista os produtos com saldo no depósito, por nome
+func (dr *DepositoRepository) BuscarEstoqueDeposito(id int64, limit, offset int) ([]domain.EstoqueDeposito, error) {
+	return buscarEstoqueDepositos(dr.db, "ed.deposito_id = ? AND ed.quantidade <> 0",
+		"p.nome, p.id LIMIT ? OFFSET ?", id, limit, offset)
+}
+
+// BuscarEstoqueProduto devolve o saldo do produto em cada depósito onde há mercadoria
+func (dr *DepositoRepository) BuscarEstoqueProduto(produtoID int64) ([]domain.EstoqueDeposito, error) {
+	return buscarEstoqueDepositos(dr.db, "ed.produto_id = ? AND ed.quantidade <> 0",
+		"d.id = 1 DESC, d.nome", produtoID)
+}
+
+func buscarEstoqueDepositos(q queryer, where, orderBy string, args ...any) ([]domain.EstoqueDeposito, error) {
+	rows, err := q.Query(`
+		SELECT ed.deposito_id, d.nome, ed.produto_id, p.nome, ed.quantidade
+		  FROM estoque_depositos ed
+		  JOIN depositos d ON d.id = ed.deposito_id
+		  JOIN produtos p ON p.id = ed.produto_id
+		 WHERE `+where+`
+		 ORDER BY `+orderBy, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saldos := []domain.EstoqueDeposito{}
+	for rows.Next() {
+		var e domain.EstoqueDeposito
+		if err := rows.Scan(&e.DepositoID, &e.Deposito, &e.ProdutoID, &e.Produto, &e.Quantidade); err != nil {
+			return nil, err
+		}
+		saldos = append(saldos, e)
+	}
+	return saldos, rows.Err()
+}
+
+// conferirDeposito recusa documento num depósito inexistente ou inativo
+func conferirDeposito(q queryer, id int64) error {
+	var ativo bool
+	err := q.QueryRow("SELECT ativo FROM depositos WHERE id = ?", id).Scan(&ativo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("depósito %d: %w", id, ErrDepositoInexistente)
+	} else if err != nil {
+		return err
+	}
+	if !ativo {
+		return fmt.Errorf("depósito %d: %w", id, domain.ErrDepositoInativo)
+	}
+	return nil
+}
+
+func traduzErroDeposito(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: depositos.nome") {
+		return ErrNomeDepositoEmUso
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func criarDeposito(t *testing.T, dr *DepositoRepository, nome string) domain.Deposito {
+	t.Helper()
+	d := domain.Deposito{Nome: nome}
+	if err := dr.CriarDeposito(&d); err != nil {
+		t.Fatalf("CriarDeposito: %v", err)
+	}
+	return d
+}
+
+func TestDepositoRepository(t *testing.T) {
+	db := novoBanco(t)
+	dr := NewDepositoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A", 5, "1.00")
+
+	fundos := criarDeposito(t, dr, "Fundos")
+	if !fundos.Ativo || fundos.ID == domain.DepositoPrincipal {
+		t.Errorf("CriarDeposito = %+v", fundos)
+	}
+	if err := dr.CriarDeposito(&domain.Deposito{Nome: "Fundos"}); !errors.Is(err, ErrNomeDepositoEmUso) {
+		t.Errorf("nome repetido: err = %v", err)
+	}
+
+	depositos, err := dr.BuscarDepositos(nil)
+	if err != nil || len(depositos) != 2 || depositos[0].ID != domain.DepositoPrincipal {
+		t.Fatalf("BuscarDepositos = %+v, %v", depositos, err)
+	}
+
+	// o saldo inicial do cadastro vai para o depósito principal
+	saldos, err := dr.BuscarEstoqueProduto(p.ID)
+	if err != nil || len(saldos) != 1 || saldos[0].DepositoID != domain.DepositoPrincipal || saldos[0].Quantidade != 5 {
+		t.Errorf("BuscarEstoqueProduto = %+v, %v", saldos, err)
+	}
+	if saldos, err := dr.BuscarEstoqueDeposito(fundos.ID, 10, 0); err != nil || len(saldos) != 0 {
+		t.Errorf("estoque de depósito vazio = %+v, %v", saldos, err)
+	}
+
+	principal, _ := dr.BuscarDepositoPorId(domain.DepositoPrincipal)
+	principal.Ativo = false
+	if err := dr.AtualizarDeposito(&principal); !errors.Is(err, ErrDesativarPrincipal) {
+		t.Errorf("desativar principal: err = %v", err)
+	}
+
+	mr := NewMovimentoRepository(db)
+	if _, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, DepositoID: fundos.ID, Quantidade: 2,
+		Motivo: domain.AjusteCorrecao}, "teste"); err != nil {
+		t.Fatalf("AjustarEstoque no depósito: %v", err)
+	}
+	fundos.Ativo = false
+	if err := dr.AtualizarDeposito(&fundos); !errors.Is(err, ErrDepositoComEstoque) {
+		t.Errorf("desativar com estoque: err = %v", err)
+	}
+	if _, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, DepositoID: fundos.ID, Quantidade: -2,
+		Motivo: domain.AjusteCorrecao}, "teste"); err != nil {
+		t.Fatalf("AjustarEstoque zerando: %v", err)
+	}
+	fundos.Nome = "Fundos (fechado)"
+	if err := dr.AtualizarDeposito(&fundos); err != nil {
+		t.Fatalf("AtualizarDeposito: %v", err)
+	}
+	if got, _ := dr.BuscarDepositoPorId(fundos.ID); got.Ativo || got.Nome != "Fundos (fechado)" {
+		t.Errorf("depósito atualizado = %+v", got)
+	}
+	if ativos, _ := dr.BuscarDepositos(map[string]any{"ativo": true}); len(ativos) != 1 {
+		t.Errorf("depósitos ativos = %+v", ativos)
+	}
+
+	// documentos não movimentam depósito inativo nem inexistente
+	_, err = mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, DepositoID: fundos.ID, Quantidade: 1,
+		Motivo: domain.AjusteCorrecao}, "teste")
+	if !errors.Is(err, domain.ErrDepositoInativo) {
+		t.Errorf("ajuste em depósito inativo: err = %v", err)
+	}
+	c := criarCliente(t, db, "Cliente")
+	venda := domain.Sale{ClientID: c.ID, DepositoID: 99, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 1}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "teste"); !errors.Is(err, ErrDepositoInexistente) {
+		t.Errorf("venda em depósito inexistente: err = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// DescontoRepository mantém o desconto máximo de cada perfil de usuário
+type DescontoRepository struct {
+	db *sql.DB
+}
+
+// NewDescontoRepository cria uma instância de DescontoRepository
+func NewDescontoRepository(db *sql.DB) *DescontoRepository {
+	return &DescontoRepository{db: db}
+}
+
+// BuscarLimites lista os limites de desconto cadastrados, por perfil
+func (dr *DescontoRepository) BuscarLimites() ([]domain.LimiteDesconto, error) {
+	rows, err := dr.db.Query("SELECT " + limiteDescontoColunas + " FROM limites_desconto ORDER BY perfil")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limites := []domain.LimiteDesconto{}
+	for rows.Next() {
+		l, err := scanLimiteDesconto(rows)
+		if err != nil {
+			return nil, err
+		}
+		limites = append(limites, l)
+	}
+	return limites, rows.Err()
+}
+
+// DefinirLimite cria ou substitui o desconto máximo do perfil
+func (dr *DescontoRepository) DefinirLimite(l domain.LimiteDesconto) error {
+	_, err := dr.db.Exec(
+		`INSERT INTO limites_desconto (perfil, percentual_maximo) VALUES (?, ?)
+		 ON CONFLICT (perfil) DO UPDATE SET percentual_maximo = excluded.percentual_maximo`,
+		l.Perfil, l.PercentualMaximo.String())
+	return err
+}
+
+// limiteDesconto devolve o desconto máximo do perfil; perfil sem limite
+// cadastrado não pode conceder desconto
+func limiteDesconto(q queryer, perfil string) (domain.Decimal, error) {
+	var maximo domain.Decimal
+	err := q.QueryRow("SELECT percentual_maximo FROM limites_desconto WHERE perfil = ?", perfil).Scan(&maximo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.DecimalFromInt(0), nil
+	}
+	return maximo, err
+}
+
+// conferirDesconto recusa venda já totalizada com desconto acima do limite
+// do perfil que a registra. Venda de orçamento leva os descontos que o limite
+// de quem fez o orçamento já aceitou.
+func conferirDesconto(q queryer, sale *domain.Sale) error {
+	if sale.AutorizadaNoOrcamento {
+		return nil
+	}
+	temDesconto := sale.ValorDesconto.Sign() > 0
+	for _, item := range sale.Items {
+		temDesconto = temDesconto || item.Desconto != nil
+	}
+	if !temDesconto {
+		return nil
+	}
+	maximo, err := limiteDesconto(q, sale.Perfil)
+	if err != nil {
+		return err
+	}
+	return sale.ConferirDesconto(sale.Perfil, maximo)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestLimitesDesconto(t *testing.T) {
+	db := novoBanco(t)
+	dr := NewDescontoRepository(db)
+	for _, l := range []domain.LimiteDesconto{
+		{Perfil: "vendedor", PercentualMaximo: decimal(t, "5")},
+		{Perfil: "gerente", PercentualMaximo: decimal(t, "10")},
+		{Perfil: "gerente", PercentualMaximo: decimal(t, "20")},
+	} {
+		if err := dr.DefinirLimite(l); err != nil {
+			t.Fatalf("DefinirLimite(%+v): %v", l, err)
+		}
+	}
+	limites, err := dr.BuscarLimites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limites) != 2 || limites[0].Perfil != "gerente" || !limites[0].PercentualMaximo.Equal(decimal(t, "20")) {
+		t.Errorf("BuscarLimites = %+v", limites)
+	}
+}
+
+func TestSalvarVendaComDesconto(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	if err := NewDescontoRepository(db).DefinirLimite(domain.LimiteDesconto{
+		Perfil: "vendedor", PercentualMaximo: decimal(t, "10")}); err != nil {
+		t.Fatal(err)
+	}
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	novaVenda := func(perfil, percentual string) domain.Sale {
+		return domain.Sale{ClientID: c.ID, Perfil: perfil,
+			Desconto: &domain.Desconto{Tipo: domain.DescontoPercentual, Valor: decimal(t, percentual)},
+			Items:    []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	}
+
+	var acima *domain.DescontoAcimaDoLimiteError
+	venda := novaVenda("vendedor", "15")
+	if err := vr.SalvarVenda(&venda, "teste"); !errors.As(err, &acima) || acima.Perfil != "vendedor" {
+		t.Fatalf("desconto acima do limite: err = %v", err)
+	}
+	venda = novaVenda("caixa", "1")
+	if err := vr.SalvarVenda(&venda, "teste"); !errors.As(err, &acima) {
+		t.Fatalf("perfil sem limite: err = %v", err)
+	}
+	if got := estoque(t, db, p.ID); got != 10 {
+		t.Errorf("estoque após vendas recusadas = %d, esperado 10", got)
+	}
+
+	venda = novaVenda("vendedor", "10")
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	gravada, err := vr.BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gravada.Bruto.Equal(decimal(t, "30.00")) || !gravada.ValorDesconto.Equal(decimal(t, "3.00")) ||
+		!gravada.Total.Equal(decimal(t, "27.00")) || gravada.Desconto == nil ||
+		gravada.Desconto.Tipo != domain.DescontoPercentual {
+		t.Errorf("venda gravada = %+v", gravada)
+	}
+	item := gravada.Items[0]
+	if !item.Bruto.Equal(decimal(t, "30.00")) || !item.ValorDesconto.Equal(decimal(t, "3.00")) ||
+		!item.Total.Equal(decimal(t, "27.00")) || item.Desconto != nil {
+		t.Errorf("item gravado = %+v", item)
+	}
+}
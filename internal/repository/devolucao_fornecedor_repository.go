@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrCompraNaoRecebida indica devolução de compra que ainda não entrou no estoque
+var ErrCompraNaoRecebida = errors.New("somente compras recebidas podem ter devolução ao fornecedor")
+
+// ErrAvariadaInsuficiente indica devolução de mais unidades avariadas do que
+// o produto tem separadas
+var ErrAvariadaInsuficiente = errors.New("quantidade avariada insuficiente")
+
+// ErrCreditoJaRecebido indica baixa repetida do crédito de uma devolução
+var ErrCreditoJaRecebido = errors.New("crédito da devolução já foi recebido")
+
+// DevolucaoFornecedorRepository registra devoluções de mercadoria aos
+// fornecedores e acompanha o crédito esperado de cada uma
+type DevolucaoFornecedorRepository struct {
+	db *sql.DB
+}
+
+// NewDevolucaoFornecedorRepository cria uma instância de DevolucaoFornecedorRepository
+func NewDevolucaoFornecedorRepository(db *sql.DB) *DevolucaoFornecedorRepository {
+	return &DevolucaoFornecedorRepository{db: db}
+}
+
+// RegistrarDevolucao grava a devolução de itens da compra d.CompraID numa
+// única transação: as unidades avariadas saem de qtd_avariada e as demais do
+// estoque com movimentos DEVOLUCAO_FORNECEDOR, dos lotes que a compra
+// recebeu. O crédito fica pendente até BaixarCredito.
+func (dr *DevolucaoFornecedorRepository) RegistrarDevolucao(d *domain.DevolucaoFornecedor) error {
+	tx, err := dr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	compra, err := buscarCompra(tx, d.CompraID)
+	if err != nil {
+		return err
+	}
+	if compra.Status != domain.StatusCompraRecebida {
+		return ErrCompraNaoRecebida
+	}
+	devolvidos, err := quantidadesDevolvidasFornecedor(tx, compra.ID)
+	if err != nil {
+		return err
+	}
+	if err := d.Calcular(compra.Items, devolvidos); err != nil {
+		return err
+	}
+
+	d.FornecedorID = compra.FornecedorID
+	d.Data = time.Now()
+	d.CreditoRecebidoEm = nil
+	res, err := tx.Exec(
+		`INSERT INTO devolucoes_fornecedor (compra_id, fornecedor_id, data, motivo, credito, usuario)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		d.CompraID, d.FornecedorID, d.Data, nullString(d.Motivo), d.Credito.String(), nullString(d.Usuario))
+	if err != nil {
+		return err
+	}
+	d.ID, _ = res.LastInsertId()
+
+	for _, item := range d.Items {
+		if _, err := tx.Exec(
+			`INSERT INTO devolucoes_fornecedor_produtos (devolucao_id, produto_id, quantidade, quantidade_avariada, custo_unitario)
+			 VALUES (?, ?, ?, ?, ?)`,
+			d.ID, item.ProdutoID, item.Quantidade, item.QuantidadeAvariada, item.CustoUnitario.String()); err != nil {
+			return err
+		}
+		if boas := item.Quantidade - item.QuantidadeAvariada; boas > 0 {
+			m := domain.MovimentoEstoque{
+				ProdutoID:  item.ProdutoID,
+				DepositoID: compra.DepositoID,
+				Tipo:       domain.MovimentoDevolucaoFornecedor,
+				Quantidade: -boas,
+				Motivo:     d.Motivo,
+				Documento:  documento("devolucao_fornecedor", d.ID),
+				Usuario:    d.Usuario,
+			}
+			if err := lancarMovimento(tx, &m); err != nil {
+				return err
+			}
+			if err := baixarLotesRecebidos(tx, &m, documento("compra", compra.ID)); err != nil {
+				return err
+			}
+		}
+		if item.QuantidadeAvariada > 0 {
+			res, err := tx.Exec(
+				"UPDATE produtos SET qtd_avariada = qtd_avariada - ? WHERE id = ? AND qtd_avariada >= ?",
+				item.QuantidadeAvariada, item.ProdutoID, item.QuantidadeAvariada)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				return fmt.Errorf("produto %d: %w", item.ProdutoID, ErrAvariadaInsuficiente)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// BuscarDevolucoes lista as devoluções ao fornecedor da compra com seus itens
+// ou sql.ErrNoRows quando a compra não existe
+func (dr *DevolucaoFornecedorRepository) BuscarDevolucoes(compraID int64) ([]domain.DevolucaoFornecedor, error) {
+	if _, err := buscarCompra(dr.db, compraID); err != nil {
+		return nil, err
+	}
+	return buscarDevolucoesFornecedor(dr.db, "compra_id = ?", compraID)
+}
+
+// BuscarCreditos lista as devoluções do fornecedor e soma o crédito ainda não
+// recebido ou sql.ErrNoRows
+func (dr *DevolucaoFornecedorRepository) BuscarCreditos(fornecedorID int64) (domain.CreditosFornecedor, error) {
+	if _, err := scanFornecedor(dr.db.QueryRow("SELECT "+fornecedorColunas+" FROM fornecedores WHERE id = ?", fornecedorID)); err != nil {
+		return domain.CreditosFornecedor{}, err
+	}
+	devolucoes, err := buscarDevolucoesFornecedor(dr.db, "fornecedor_id = ?", fornecedorID)
+	if err != nil {
+		return domain.CreditosFornecedor{}, err
+	}
+	creditos := domain.CreditosFornecedor{FornecedorID: fornecedorID, Pendente: domain.DecimalFromInt(0), Devolucoes: devolucoes}
+	for _, d := range devolucoes {
+		if d.CreditoRecebidoEm == nil {
+			creditos.Pendente = creditos.Pendente.Add(d.Credito)
+		}
+	}
+	return creditos, nil
+}
+
+// BaixarCredito marca o crédito da devolução devolucaoID, da compra compraID,
+// como recebido na data informada (zero usa o momento atual)
+func (dr *DevolucaoFornecedorRepository) BaixarCredito(compraID, devolucaoID int64, recebidoEm time.Time) (domain.DevolucaoFornecedor, error) {
+	devolucoes, err := buscarDevolucoesFornecedor(dr.db, "id = ? AND compra_id = ?", devolucaoID, compraID)
+	if err != nil {
+		return domain.DevolucaoFornecedor{}, err
+	}
+	if len(devolucoes) == 0 {
+		return domain.DevolucaoFornecedor{}, sql.ErrNoRows
+	}
+	d := devolucoes[0]
+	if d.CreditoRecebidoEm != nil {
+		return domain.DevolucaoFornecedor{}, ErrCreditoJaRecebido
+	}
+
+	if recebidoEm.IsZero() {
+		recebidoEm = time.Now()
+	}
+	res, err := dr.db.Exec(
+		"UPDATE devolucoes_fornecedor SET credito_recebido_em = ? WHERE id = ? AND credito_recebido_em IS NULL",
+		recebidoEm, devolucaoID)
+	if err != nil {
+		return domain.DevolucaoFornecedor{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.DevolucaoFornecedor{}, ErrCreditoJaRecebido
+	}
+	d.CreditoRecebidoEm = &recebidoEm
+	return d, nil
+}
+
+// buscarDevolucoesFornecedor lista, com itens, as devoluções que atendem a condição
+func buscarDevolucoesFornecedor(q queryer, condicao string, args ...any) ([]domain.DevolucaoFornecedor, error) {
+	rows, err := q.Query("SELECT "+devolucaoFornecedorColunas+" FROM devolucoes_fornecedor WHERE "+condicao+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devolucoes := []domain.DevolucaoFornecedor{}
+	for rows.Next() {
+		d, err := scanDevolucaoFornecedor(rows)
+		if err != nil {
+			return nil, err
+		}
+		devolucoes = append(devolucoes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range devolucoes {
+		if devolucoes[i].Items, err = buscarItensDevolucaoFornecedor(q, devolucoes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return devolucoes, nil
+}
+
+func buscarItensDevolucaoFornecedor(q queryer, devolucaoID int64) ([]domain.ItemDevolucaoFornecedor, error) {
+	rows, err := q.Query("SELECT "+devolucaoFornecedorItemColunas+
+		" FROM devolucoes_fornecedor_produtos WHERE devolucao_id = ? ORDER BY produto_id", devolucaoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.ItemDevolucaoFornecedor{}
+	for rows.Next() {
+		item, err := scanDevolucaoFornecedorItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+// quantidadesDevolvidasFornecedor soma, por produto, o que já foi devolvido
+// ao fornecedor da compra
+func quantidadesDevolvidasFornecedor(q queryer, compraID int64) (map[int64]int64, error) {
+	rows, err := q.Query(`
+		SELECT dp.produto_id, SUM(dp.quantidade)
+		  FROM devolucoes_fornecedor_produtos dp
+		  JOIN devolucoes_fornecedor d ON d.id = dp.devolucao_id
+		 WHERE d.compra_id = ?
+		 GROUP BY dp.produto_id`, compraID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devolvidos := make(map[int64]int64)
+	for rows.Next() {
+		var produtoID, quantidade int64
+		if err := rows.Scan(&produtoID, &quantidade); err != nil {
+			return nil, err
+		}
+		devolvidos[produtoID] = quantidade
+	}
+	return devolvidos, rows.Err()
+}
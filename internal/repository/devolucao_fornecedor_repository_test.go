@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestDevolucaoFornecedorRepository(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewCompraRepository(db)
+	dr := NewDevolucaoFornecedorRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 0, "10.00")
+
+	compra := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: a.ID, Quantidade: 10, PrecoUnitario: decimal(t, "6.00")},
+	}}
+	if err := cr.SalvarCompra(&compra); err != nil {
+		t.Fatal(err)
+	}
+	pendente := domain.DevolucaoFornecedor{CompraID: compra.ID,
+		Items: []domain.ItemDevolucaoFornecedor{{ProdutoID: a.ID, Quantidade: 1}}}
+	if err := dr.RegistrarDevolucao(&pendente); !errors.Is(err, ErrCompraNaoRecebida) {
+		t.Errorf("devolução de compra pendente: err = %v", err)
+	}
+	if _, err := cr.ReceberCompra(compra.ID, nil, nil, "teste"); err != nil {
+		t.Fatal(err)
+	}
+
+	// duas unidades avariadas vieram de uma devolução de cliente
+	if _, err := db.Exec("UPDATE produtos SET qtd_avariada = 2 WHERE id = ?", a.ID); err != nil {
+		t.Fatal(err)
+	}
+	devolucao := domain.DevolucaoFornecedor{CompraID: compra.ID, Motivo: "defeito de fabricação", Usuario: "teste",
+		Items: []domain.ItemDevolucaoFornecedor{{ProdutoID: a.ID, Quantidade: 5, QuantidadeAvariada: 2}}}
+	if err := dr.RegistrarDevolucao(&devolucao); err != nil {
+		t.Fatalf("RegistrarDevolucao: %v", err)
+	}
+	if !devolucao.Credito.Equal(decimal(t, "30")) || devolucao.FornecedorID != f.Id {
+		t.Errorf("devolução = %+v", devolucao)
+	}
+	if got := estoque(t, db, a.ID); got != 7 {
+		t.Errorf("estoque = %d, esperado 7", got)
+	}
+
+	var qtd *domain.QuantidadeDevolucaoError
+	excesso := domain.DevolucaoFornecedor{CompraID: compra.ID,
+		Items: []domain.ItemDevolucaoFornecedor{{ProdutoID: a.ID, Quantidade: 6}}}
+	if err := dr.RegistrarDevolucao(&excesso); !errors.As(err, &qtd) || qtd.Disponivel != 5 {
+		t.Errorf("devolução acima do recebido: err = %v", err)
+	}
+	semAvariada := domain.DevolucaoFornecedor{CompraID: compra.ID,
+		Items: []domain.ItemDevolucaoFornecedor{{ProdutoID: a.ID, Quantidade: 1, QuantidadeAvariada: 1}}}
+	if err := dr.RegistrarDevolucao(&semAvariada); !errors.Is(err, ErrAvariadaInsuficiente) {
+		t.Errorf("avariadas insuficientes: err = %v", err)
+	}
+
+	creditos, err := dr.BuscarCreditos(f.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !creditos.Pendente.Equal(decimal(t, "30")) || len(creditos.Devolucoes) != 1 || len(creditos.Devolucoes[0].Items) != 1 {
+		t.Errorf("créditos = %+v", creditos)
+	}
+
+	baixada, err := dr.BaixarCredito(compra.ID, devolucao.ID, devolucao.Data)
+	if err != nil || baixada.CreditoRecebidoEm == nil {
+		t.Fatalf("BaixarCredito = %+v, %v", baixada, err)
+	}
+	if _, err := dr.BaixarCredito(compra.ID, devolucao.ID, devolucao.Data); !errors.Is(err, ErrCreditoJaRecebido) {
+		t.Errorf("baixa repetida: err = %v", err)
+	}
+	if creditos, _ = dr.BuscarCreditos(f.Id); !creditos.Pendente.Equal(decimal(t, "0")) {
+		t.Errorf("pendente após baixa = %s", creditos.Pendente)
+	}
+}
+
+func TestDevolucaoFornecedorSaiDosLotesDaCompra(t *testing.T) {
+	db := novoBanco(t)
+	cr := NewCompraRepository(db)
+	dr := NewDevolucaoFornecedorRepository(db)
+	lr := NewLoteRepository(db)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 0, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	receber := func(numero, validade string) domain.Compra {
+		t.Helper()
+		compra := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+			{ProdutoID: p.ID, Quantidade: 5, PrecoUnitario: decimal(t, "6.00")},
+		}}
+		if err := cr.SalvarCompra(&compra); err != nil {
+			t.Fatal(err)
+		}
+		lotes := map[int64][]domain.Lote{p.ID: {{Numero: numero, Validade: validade, Quantidade: 5}}}
+		if _, err := cr.ReceberCompra(compra.ID, nil, lotes, "teste"); err != nil {
+			t.Fatal(err)
+		}
+		return compra
+	}
+	receber("ANTIGO", "2030-01-01")
+	nova := receber("NOVO", "2031-01-01")
+
+	// pela validade sairia do ANTIGO, mas o que volta é o que a compra trouxe
+	devolver := func(qtd int64) {
+		t.Helper()
+		d := domain.DevolucaoFornecedor{CompraID: nova.ID,
+			Items: []domain.ItemDevolucaoFornecedor{{ProdutoID: p.ID, Quantidade: qtd}}}
+		if err := dr.RegistrarDevolucao(&d); err != nil {
+			t.Fatalf("RegistrarDevolucao: %v", err)
+		}
+	}
+	devolver(2)
+	if got := saldosLotes(t, lr, p.ID, domain.DepositoPrincipal); !iguais(got, map[string]int64{"ANTIGO": 5, "NOVO": 3}) {
+		t.Errorf("lotes após devolução = %v", got)
+	}
+
+	// a venda esgota o ANTIGO e leva uma do NOVO; a devolução segue no NOVO
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 6}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	devolver(1)
+	if got := saldosLotes(t, lr, p.ID, domain.DepositoPrincipal); !iguais(got, map[string]int64{"ANTIGO": 0, "NOVO": 1}) {
+		t.Errorf("lotes após segunda devolução = %v", got)
+	}
+	conferirLotes(t, db)
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// DevolucaoRepository registra devoluções de clientes e a conta de crédito
+// que elas geram na loja
+type DevolucaoRepository struct {
+	db *sql.DB
+}
+
+// NewDevolucaoRepository cria uma instância de DevolucaoRepository
+func NewDevolucaoRepository(db *sql.DB) *DevolucaoRepository {
+	return &DevolucaoRepository{db: db}
+}
+
+// RegistrarDevolucao grava a devolução de itens da venda d.VendaID numa única
+// transação: as unidades boas voltam ao estoque com movimentos DEVOLUCAO e as
+// avariadas vão para qtd_avariada; o valor abate o saldo em aberto da venda
+// (pagamento com forma DEVOLUCAO) e o excedente vira reembolso ou crédito.
+// Quantidades acima do vendido menos o já devolvido resultam em
+// *domain.QuantidadeDevolucaoError.
+func (dr *DevolucaoRepository) RegistrarDevolucao(d *domain.Devolucao) error {
+	tx, err := dr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	venda, err := buscarVenda(tx, d.VendaID)
+	if err != nil {
+		return err
+	}
+	if venda.Status == domain.StatusVendaCancelada {
+		return ErrVendaCancelada
+	}
+	devolvidos, err := quantidadesDevolvidas(tx, venda.ID)
+	if err != nil {
+		return err
+	}
+	pago, err := somarPagamentos(tx, venda.ID)
+	if err != nil {
+		return err
+	}
+	if err := d.Calcular(venda.Items, devolvidos, venda.Total.Sub(pago)); err != nil {
+		return err
+	}
+
+	d.ClienteID = venda.ClientID
+	d.Data = time.Now()
+	res, err := tx.Exec(
+		`INSERT INTO devolucoes (venda_id, data, motivo, destino, forma_reembolso, valor, abatido,
+		                         reembolso, credito, usuario)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.VendaID, d.Data, nullString(d.Motivo), d.Destino, nullString(string(d.FormaReembolso)),
+		d.Valor.String(), d.Abatido.String(), d.Reembolso.String(), d.Credito.String(), nullString(d.Usuario))
+	if err != nil {
+		return err
+	}
+	d.ID, _ = res.LastInsertId()
+	doc := documento("devolucao", d.ID)
+
+	for _, item := range d.Items {
+		if _, err := tx.Exec(
+			`INSERT INTO devolucoes_produtos (devolucao_id, produto_id, quantidade, quantidade_avariada, preco_unitario, total)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			d.ID, item.ProdutoID, item.Quantidade, item.QuantidadeAvariada, item.PrecoUnitario.String(),
+			item.Total.String()); err != nil {
+			return err
+		}
+		if boas := item.Quantidade - item.QuantidadeAvariada; boas > 0 {
+			m := domain.MovimentoEstoque{
+				ProdutoID:  item.ProdutoID,
+				DepositoID: venda.DepositoID,
+				Tipo:       domain.MovimentoDevolucao,
+				Quantidade: boas,
+				Motivo:     d.Motivo,
+				Documento:  doc,
+				Usuario:    d.Usuario,
+			}
+			if err := movimentarEstoque(tx, &m); err != nil {
+				return err
+			}
+			if err := estornarLotes(tx, &m, documento("venda", venda.ID)); err != nil {
+				return err
+			}
+		}
+		if item.QuantidadeAvariada > 0 {
+			if _, err := tx.Exec("UPDATE produtos SET qtd_avariada = qtd_avariada + ? WHERE id = ?",
+				item.QuantidadeAvariada, item.ProdutoID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.Abatido.Sign() > 0 {
+		if err := registrarPagamento(tx, &venda, &domain.Pagamento{
+			Valor:   d.Abatido,
+			Data:    d.Data,
+			Forma:   domain.FormaDevolucao,
+			Usuario: d.Usuario,
+		}); err != nil {
+			return err
+		}
+	}
+	if d.Credito.Sign() > 0 {
+		if err := lancarCredito(tx, d.ClienteID, d.Credito, doc, d.Usuario); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// BuscarDevolucoes lista as devoluções da venda com seus itens
+func (dr *DevolucaoRepository) BuscarDevolucoes(vendaID int64) ([]domain.Devolucao, error) {
+	venda, err := buscarVenda(dr.db, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := dr.db.Query("SELECT "+devolucaoColunas+" FROM devolucoes WHERE venda_id = ? ORDER BY id", vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devolucoes := []domain.Devolucao{}
+	for rows.Next() {
+		d, err := scanDevolucao(rows)
+		if err != nil {
+			return nil, err
+		}
+		d.ClienteID = venda.ClientID
+		devolucoes = append(devolucoes, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range devolucoes {
+		if devolucoes[i].Items, err = buscarItensDevolucao(dr.db, devolucoes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return devolucoes, nil
+}
+
+// BuscarCreditoLoja devolve saldo e movimentos do crédito do cliente na loja
+// ou sql.ErrNoRows
+func (dr *DevolucaoRepository) BuscarCreditoLoja(clienteID int64) (domain.ContaCredito, error) {
+	if _, err := scanCliente(dr.db.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", clienteID)); err != nil {
+		return domain.ContaCredito{}, err
+	}
+	conta := domain.ContaCredito{ClienteID: clienteID, Movimentos: []domain.MovimentoCredito{}}
+	var err error
+	if conta.Saldo, err = saldoCreditoLoja(dr.db, clienteID); err != nil {
+		return domain.ContaCredito{}, err
+	}
+
+	rows, err := dr.db.Query("SELECT "+creditoColunas+" FROM creditos_clientes WHERE cliente_id = ? ORDER BY id", clienteID)
+	if err != nil {
+		return domain.ContaCredito{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		m, err := scanCredito(rows)
+		if err != nil {
+			return domain.ContaCredito{}, err
+		}
+		conta.Movimentos = append(conta.Movimentos, m)
+	}
+	return conta, rows.Err()
+}
+
+// quantidadesDevolvidas soma, por produto, o que já foi devolvido da venda
+func quantidadesDevolvidas(q queryer, vendaID int64) (map[int64]int64, error) {
+	rows, err := q.Query(`
+		SELECT dp.produto_id, SUM(dp.quantidade)
+		  FROM devolucoes_produtos dp
+		  JOIN devolucoes d ON d.id = dp.devolucao_id
+		 WHERE d.venda_id = ?
+		 GROUP BY dp.produto_id`, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devolvidos := make(map[int64]int64)
+	for rows.Next() {
+		var produtoID, quantidade int64
+		if err := rows.Scan(&produtoID, &quantidade); err != nil {
+			return nil, err
+		}
+		devolvidos[produtoID] = quantidade
+	}
+	return devolvidos, rows.Err()
+}
+
+func buscarItensDevolucao(q queryer, devolucaoID int64) ([]domain.ItemDevolucao, error) {
+	rows, err := q.Query("SELECT "+devolucaoItemColunas+" FROM devolucoes_produtos WHERE devolucao_id = ? ORDER BY produto_id", devolucaoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.ItemDevolucao{}
+	for rows.Next() {
+		item, err := scanDevolucaoItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+// lancarCredito grava uma linha na conta de crédito do cliente; valores
+// negativos consomem crédito e são recusados além do saldo
+func lancarCredito(tx *sql.Tx, clienteID int64, valor domain.Decimal, doc, usuario string) error {
+	if valor.Sign() < 0 {
+		saldo, err := saldoCreditoLoja(tx, clienteID)
+		if err != nil {
+			return err
+		}
+		if saldo.Add(valor).Sign() < 0 {
+			return domain.ErrCreditoLojaInsuficiente
+		}
+	}
+	_, err := tx.Exec(
+		`INSERT INTO creditos_clientes (cliente_id, valor, documento, usuario, criado_em) VALUES (?, ?, ?, ?, ?)`,
+		clienteID, valor.String(), doc, nullString(usuario), time.Now())
+	return err
+}
+
+func saldoCreditoLoja(q queryer, clienteID int64) (domain.Decimal, error) {
+	var saldo domain.Decimal
+	err := q.QueryRow("SELECT ROUND(COALESCE(SUM(valor), 0), 2) FROM creditos_clientes WHERE cliente_id = ?", clienteID).Scan(&saldo)
+	return saldo, err
+}
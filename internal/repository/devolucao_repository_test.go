@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestDevolucaoRepository(t *testing.T) {
+	db := novoBanco(t)
+	dr := NewDevolucaoRepository(db)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 5}},
+		Pagamentos: []domain.Pagamento{{Valor: decimal(t, "30.00"), Forma: domain.FormaDinheiro}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	// 4 unidades (40.00): 20.00 abatem o saldo e 20.00 viram crédito; uma está avariada
+	devolucao := domain.Devolucao{VendaID: venda.ID, Destino: domain.DevolucaoCredito, Motivo: "defeito",
+		Items: []domain.ItemDevolucao{{ProdutoID: p.ID, Quantidade: 4, QuantidadeAvariada: 1}}}
+	if err := dr.RegistrarDevolucao(&devolucao); err != nil {
+		t.Fatalf("RegistrarDevolucao: %v", err)
+	}
+	if !devolucao.Abatido.Equal(decimal(t, "20")) || !devolucao.Credito.Equal(decimal(t, "20")) {
+		t.Errorf("devolução = %+v", devolucao)
+	}
+	if got := estoque(t, db, p.ID); got != 8 {
+		t.Errorf("estoque = %d, esperado 8", got)
+	}
+	var avariada int64
+	if err := db.QueryRow("SELECT qtd_avariada FROM produtos WHERE id = ?", p.ID).Scan(&avariada); err != nil {
+		t.Fatal(err)
+	}
+	if avariada != 1 {
+		t.Errorf("qtd_avariada = %d, esperado 1", avariada)
+	}
+
+	got, err := vr.BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PaymentStatus != domain.PaymentStatusPaid {
+		t.Errorf("status_pagamento = %s, esperado PAGO", got.PaymentStatus)
+	}
+
+	excesso := domain.Devolucao{VendaID: venda.ID, Destino: domain.DevolucaoCredito,
+		Items: []domain.ItemDevolucao{{ProdutoID: p.ID, Quantidade: 2}}}
+	var qtd *domain.QuantidadeDevolucaoError
+	if err := dr.RegistrarDevolucao(&excesso); !errors.As(err, &qtd) || qtd.Disponivel != 1 {
+		t.Errorf("devolução acima do vendido: err = %v", err)
+	}
+
+	devolucoes, err := dr.BuscarDevolucoes(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devolucoes) != 1 || len(devolucoes[0].Items) != 1 || devolucoes[0].Items[0].QuantidadeAvariada != 1 {
+		t.Errorf("devoluções = %+v", devolucoes)
+	}
+
+	// o crédito paga parte de outra venda e não pode ser usado além do saldo
+	outra := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	if err := vr.SalvarVenda(&outra, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	pgr := NewPagamentoRepository(db)
+	acima := domain.Pagamento{VendaID: outra.ID, Valor: decimal(t, "20.01"), Forma: domain.FormaCreditoLoja}
+	if err := pgr.RegistrarPagamento(&acima); !errors.Is(err, domain.ErrCreditoLojaInsuficiente) {
+		t.Errorf("crédito acima do saldo: err = %v", err)
+	}
+	uso := domain.Pagamento{VendaID: outra.ID, Valor: decimal(t, "15.00"), Forma: domain.FormaCreditoLoja}
+	if err := pgr.RegistrarPagamento(&uso); err != nil {
+		t.Fatalf("pagamento com crédito: %v", err)
+	}
+	conta, err := dr.BuscarCreditoLoja(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !conta.Saldo.Equal(decimal(t, "5")) || len(conta.Movimentos) != 2 {
+		t.Errorf("conta de crédito = %+v", conta)
+	}
+
+	// cancelar a venda devolve ao estoque só o que não foi devolvido
+	if _, err := vr.CancelarVenda(venda.ID, "teste", "teste"); err != nil {
+		t.Fatalf("CancelarVenda: %v", err)
+	}
+	if got := estoque(t, db, p.ID); got != 6 {
+		t.Errorf("estoque após cancelamento = %d, esperado 6", got)
+	}
+	// pagou 30 e já recebeu 20 de crédito na devolução: o cancelamento credita 10
+	if conta, _ := dr.BuscarCreditoLoja(c.ID); !conta.Saldo.Equal(decimal(t, "15")) {
+		t.Errorf("crédito após cancelamento = %s, esperado 15", conta.Saldo)
+	}
+	// a devolução já abateu parte do saldo: o cancelamento não credita de novo
+	extrato, err := NewContasReceberRepository(db).BuscarExtrato(c.ID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !extrato.SaldoFinal.Equal(decimal(t, "15")) {
+		t.Errorf("saldo final do extrato = %s, esperado 15 (só o que falta da outra venda)", extrato.SaldoFinal)
+	}
+	if err := dr.RegistrarDevolucao(&domain.Devolucao{VendaID: venda.ID, Destino: domain.DevolucaoCredito,
+		Items: []domain.ItemDevolucao{{ProdutoID: p.ID, Quantidade: 1}}}); !errors.Is(err, ErrVendaCancelada) {
+		t.Errorf("devolução de venda cancelada: err = %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrFornecedorEmUso indica que ainda existem produtos, compras, devoluções ou
+// promoções ligados ao fornecedor
+var ErrFornecedorEmUso = errors.New("fornecedor possui produtos, compras ou promoções e não pode ser removido")
+
+// ErrCNPJDuplicado indica que já existe outro fornecedor com o mesmo CNPJ
+var ErrCNPJDuplicado = errors.New("já existe fornecedor com este CNPJ")
+
+// FornecedorRepository encapsula acessos ao banco para fornecedores
+type FornecedorRepository struct {
+	db *sql.DB
+}
+
+// NewFornecedorRepository cria uma instância de FornecedorRepository
+func NewFornecedorRepository(db *sql.DB) *FornecedorRepository {
+	return &FornecedorRepository{db: db}
+}
+
+// Salvar insere um novo fornecedor e preenche o ID gerado
+func (fr *FornecedorRepository) Salvar(f *domain.Fornecedor) error {
+	res, err := fr.db.Exec(
+		`INSERT INTO fornecedores (nome, cnpj, contato, telefone, email, condicoes_pagamento)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Nome, nullString(f.CNPJ), nullString(f.Contato), nullString(f.Telefone),
+		nullString(f.Email), nullString(f.CondicoesPagamento),
+	)
+	if err != nil {
+		return traduzErroFornecedor(err)
+	}
+	f.Id, err = res.LastInsertId()
+	return err
+}
+
+// Buscar consulta fornecedores com filtros opcionais
+func (fr *FornecedorRepository) Buscar(filters map[string]any, limit, offset int) ([]domain.Fornecedor, error) {
+	query := "SELECT " + fornecedorColunas + " FROM fornecedores"
+	var clauses []string
+	var args []any
+
+	if v, ok := filters["nome"]; ok {
+		clauses = append(clauses, "nome LIKE ?")
+		args = append(args, "%"+v.(string)+"%")
+	}
+	if v, ok := filters["cnpj"]; ok {
+		clauses = append(clauses, "cnpj LIKE ?")
+		args = append(args, "%"+v.(string)+"%")
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY nome LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := fr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fornecedores := []domain.Fornecedor{}
+	for rows.Next() {
+		f, err := scanFornecedor(rows)
+		if err != nil {
+			return nil, err
+		}
+		fornecedores = append(fornecedores, f)
+	}
+	return fornecedores, rows.Err()
+}
+
+// BuscarPorId retorna o fornecedor ou sql.ErrNoRows
+func (fr *FornecedorRepository) BuscarPorId(id int64) (domain.Fornecedor, error) {
+	row := fr.db.QueryRow("SELECT "+fornecedorColunas+" FROM fornecedores WHERE id = ?", id)
+	return scanFornecedor(row)
+}
+
+// Atualizar grava todos os campos do fornecedor (exceto ID)
+func (fr *FornecedorRepository) Atualizar(f *domain.Fornecedor) error {
+	res, err := fr.db.Exec(
+		`UPDATE fornecedores
+		    SET nome = ?, cnpj = ?, contato = ?, telefone = ?, email = ?, condicoes_pagamento = ?
+		  WHERE id = ?`,
+		f.Nome, nullString(f.CNPJ), nullString(f.Contato), nullString(f.Telefone),
+		nullString(f.Email), nullString(f.CondicoesPagamento), f.Id,
+	)
+	if err != nil {
+		return traduzErroFornecedor(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Deletar remove o fornecedor, recusando com ErrFornecedorEmUso quando ainda
+// há produtos, compras, devoluções ou promoções ligados a ele
+func (fr *FornecedorRepository) Deletar(id int64) error {
+	tx, err := fr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var emUso bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM produtos WHERE fornecedor_id = ?)
+		     OR EXISTS (SELECT 1 FROM compras WHERE fornecedor_id = ?)
+		     OR EXISTS (SELECT 1 FROM devolucoes_fornecedor WHERE fornecedor_id = ?)
+		     OR EXISTS (SELECT 1 FROM promocoes WHERE fornecedor_id = ?)`, id, id, id, id).Scan(&emUso)
+	if err != nil {
+		return err
+	}
+	if emUso {
+		return ErrFornecedorEmUso
+	}
+
+	res, err := tx.Exec("DELETE FROM fornecedores WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func traduzErroFornecedor(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: fornecedores.cnpj") {
+		return ErrCNPJDuplicado
+	}
+	return err
+}
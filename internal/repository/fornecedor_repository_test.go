@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestFornecedorRepository(t *testing.T) {
+	db := novoBanco(t)
+	fr := NewFornecedorRepository(db)
+
+	f := domain.Fornecedor{
+		Nome:               "Distribuidora Alfa",
+		CNPJ:               "11222333000181",
+		Contato:            "Ana",
+		Email:              "ana@alfa.com.br",
+		CondicoesPagamento: "30/60",
+	}
+	if err := fr.Salvar(&f); err != nil {
+		t.Fatalf("Salvar: %v", err)
+	}
+	if f.Id == 0 {
+		t.Fatal("Salvar não preencheu o ID")
+	}
+
+	dup := domain.Fornecedor{Nome: "Outro", CNPJ: f.CNPJ}
+	if err := fr.Salvar(&dup); !errors.Is(err, ErrCNPJDuplicado) {
+		t.Errorf("CNPJ repetido: err = %v, esperado ErrCNPJDuplicado", err)
+	}
+
+	got, err := fr.BuscarPorId(f.Id)
+	if err != nil {
+		t.Fatalf("BuscarPorId: %v", err)
+	}
+	if got != f {
+		t.Errorf("BuscarPorId = %+v, esperado %+v", got, f)
+	}
+
+	lista, err := fr.Buscar(map[string]any{"nome": "alfa", "cnpj": "112223"}, 10, 0)
+	if err != nil {
+		t.Fatalf("Buscar: %v", err)
+	}
+	if len(lista) != 1 || lista[0].Id != f.Id {
+		t.Errorf("Buscar = %+v", lista)
+	}
+
+	f.Telefone = "1140028922"
+	f.Email = ""
+	if err := fr.Atualizar(&f); err != nil {
+		t.Fatalf("Atualizar: %v", err)
+	}
+	got, _ = fr.BuscarPorId(f.Id)
+	if got != f {
+		t.Errorf("após Atualizar = %+v, esperado %+v", got, f)
+	}
+	if err := fr.Atualizar(&domain.Fornecedor{Id: 999, Nome: "x"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Atualizar inexistente: err = %v", err)
+	}
+
+	if err := fr.Deletar(f.Id); err != nil {
+		t.Fatalf("Deletar: %v", err)
+	}
+	if err := fr.Deletar(f.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Deletar de novo: err = %v, esperado sql.ErrNoRows", err)
+	}
+}
+
+func TestDeletarFornecedorComProdutos(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	criarProduto(t, db, f, "A1", 0, "1.00")
+
+	if err := NewFornecedorRepository(db).Deletar(f.Id); !errors.Is(err, ErrFornecedorEmUso) {
+		t.Errorf("err = %v, esperado ErrFornecedorEmUso", err)
+	}
+}
+
+// Compras antigas prendem o fornecedor mesmo sem produtos cadastrados
+func TestDeletarFornecedorComCompras(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	if _, err := db.Exec("INSERT INTO compras (fornecedor_id, data, total) VALUES (?, CURRENT_TIMESTAMP, 0)", f.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewFornecedorRepository(db).Deletar(f.Id); !errors.Is(err, ErrFornecedorEmUso) {
+		t.Errorf("err = %v, esperado ErrFornecedorEmUso", err)
+	}
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrCodigoBarrasInexistente indica contagem por código de barras que nenhum produto usa
+var ErrCodigoBarrasInexistente = errors.New("código de barras não cadastrado")
+
+// InventarioRepository grava as sessões de contagem física e aplica o
+// resultado ao estoque
+type InventarioRepository struct {
+	db *sql.DB
+}
+
+// NewInventarioRepository cria uma instância de InventarioRepository
+func NewInventarioRepository(db *sql.DB) *InventarioRepository {
+	return &InventarioRepository{db: db}
+}
+
+// AbrirInventario grava a sessão aberta no depósito inv.DepositoID (o
+// principal quando zero) com o saldo atual de todos os produtos ali como
+// quantidade esperada. Só pode haver uma sessão aberta por depósito.
+func (ir *InventarioRepository) AbrirInventario(inv *domain.Inventario) error {
+	tx, err := ir.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if inv.DepositoID == 0 {
+		inv.DepositoID = domain.DepositoPrincipal
+	}
+	if err := conferirDeposito(tx, inv.DepositoID); err != nil {
+		return err
+	}
+	var abertos int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM inventarios WHERE status = ? AND deposito_id = ?",
+		domain.InventarioAberto, inv.DepositoID).Scan(&abertos); err != nil {
+		return err
+	}
+	if abertos > 0 {
+		return domain.ErrInventarioEmAndamento
+	}
+
+	inv.Status = domain.InventarioAberto
+	inv.AbertoEm = time.Now()
+	inv.AprovadoEm, inv.AprovadoPor = nil, ""
+	res, err := tx.Exec(
+		`INSERT INTO inventarios (deposito_id, descricao, status, aberto_em, usuario) VALUES (?, ?, ?, ?, ?)`,
+		inv.DepositoID, nullString(inv.Descricao), inv.Status, inv.AbertoEm, nullString(inv.Usuario))
+	if err != nil {
+		return err
+	}
+	inv.ID, _ = res.LastInsertId()
+
+	if _, err := tx.Exec(
+		`INSERT INTO inventarios_produtos (inventario_id, produto_id, esperada)
+		 SELECT ?, p.id, COALESCE(ed.quantidade, 0)
+		   FROM produtos p
+		   LEFT JOIN estoque_depositos ed ON ed.produto_id = p.id AND ed.deposito_id = ?`,
+		inv.ID, inv.DepositoID); err != nil {
+		return err
+	}
+	if inv.Itens, err = buscarItensInventario(tx, inv.ID); err != nil {
+		return err
+	}
+	inv.CalcularDiferencas()
+	return tx.Commit()
+}
+
+// BuscarInventarios lista as sessões, sem os itens, filtrando por status e deposito_id
+func (ir *InventarioRepository) BuscarInventarios(filters map[string]any, limit, offset int) ([]domain.Inventario, error) {
+	query := "SELECT " + inventarioColunas + " FROM inventarios i"
+	var clauses []string
+	var args []any
+	if v, ok := filters["status"]; ok {
+		clauses = append(clauses, "i.status = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["deposito_id"]; ok {
+		clauses = append(clauses, "i.deposito_id = ?")
+		args = append(args, v)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY i.aberto_em DESC, i.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := ir.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inventarios := []domain.Inventario{}
+	for rows.Next() {
+		inv, err := scanInventario(rows)
+		if err != nil {
+			return nil, err
+		}
+		inventarios = append(inventarios, inv)
+	}
+	return inventarios, rows.Err()
+}
+
+// BuscarInventarioPorId retorna a sessão com os itens ou sql.ErrNoRows
+func (ir *InventarioRepository) BuscarInventarioPorId(id int64) (domain.Inventario, error) {
+	return buscarInventario(ir.db, id)
+}
+
+// RegistrarContagens grava um lote de contagens na sessão aberta; a
+// contagem de um produto já contado substitui a anterior. Nada é gravado se
+// algum código de barras não existir (ErrCodigoBarrasInexistente) ou algum
+// produto não estiver na sessão (domain.ErrProdutoForaDoInventario).
+func (ir *InventarioRepository) RegistrarContagens(id int64, contagens []domain.Contagem, usuario string) (domain.Inventario, error) {
+	tx, err := ir.db.Begin()
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	defer tx.Rollback()
+
+	var status domain.StatusInventario
+	if err := tx.QueryRow("SELECT status FROM inventarios WHERE id = ?", id).Scan(&status); err != nil {
+		return domain.Inventario{}, err
+	}
+	if status != domain.InventarioAberto {
+		return domain.Inventario{}, fmt.Errorf("contar inventário %s: %w", status, domain.ErrSituacaoInventario)
+	}
+
+	agora := time.Now()
+	for _, c := range contagens {
+		produtoID := c.ProdutoID
+		if c.CodigoBarras != "" {
+			err := tx.QueryRow("SELECT id FROM produtos WHERE codigo_barras = ?", c.CodigoBarras).Scan(&produtoID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.Inventario{}, fmt.Errorf("%w: %q", ErrCodigoBarrasInexistente, c.CodigoBarras)
+			} else if err != nil {
+				return domain.Inventario{}, err
+			}
+		}
+		res, err := tx.Exec(
+			`UPDATE inventarios_produtos SET contada = ?, contado_em = ?, contado_por = ?
+			 WHERE inventario_id = ? AND produto_id = ?`,
+			c.Quantidade, agora, nullString(usuario), id, produtoID)
+		if err != nil {
+			return domain.Inventario{}, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return domain.Inventario{}, fmt.Errorf("produto %d: %w", produtoID, domain.ErrProdutoForaDoInventario)
+		}
+	}
+
+	inv, err := buscarInventario(tx, id)
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	return inv, tx.Commit()
+}
+
+// AprovarInventario fecha a sessão e, na mesma transação, lança um movimento
+// INVENTARIO pela diferença de cada produto contado. A diferença é aplicada
+// sobre o estoque atual, preservando vendas e compras feitas durante a
+// contagem; se algum produto ficaria negativo, nada é gravado.
+func (ir *InventarioRepository) AprovarInventario(id int64, usuario string) (domain.Inventario, error) {
+	tx, err := ir.db.Begin()
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	defer tx.Rollback()
+
+	inv, err := buscarInventario(tx, id)
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	if err := inv.Aprovar(time.Now(), usuario); err != nil {
+		return domain.Inventario{}, err
+	}
+
+	for _, item := range inv.Divergencias() {
+		if err := movimentarEstoque(tx, &domain.MovimentoEstoque{
+			ProdutoID:  item.ProdutoID,
+			DepositoID: inv.DepositoID,
+			Tipo:       domain.MovimentoInventario,
+			Quantidade: item.Diferenca,
+			Motivo:     "contagem de inventário",
+			Documento:  documento("inventario", id),
+			Usuario:    usuario,
+			CriadoEm:   *inv.AprovadoEm,
+		}); err != nil {
+			return domain.Inventario{}, err
+		}
+	}
+
+	res, err := tx.Exec("UPDATE inventarios SET status = ?, aprovado_em = ?, aprovado_por = ? WHERE id = ? AND status = ?",
+		inv.Status, inv.AprovadoEm, nullString(usuario), id, domain.InventarioAberto)
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Inventario{}, fmt.Errorf("aprovar inventário %d: %w", id, domain.ErrSituacaoInventario)
+	}
+	return inv, tx.Commit()
+}
+
+// CancelarInventario descarta a sessão aberta sem mexer no estoque
+func (ir *InventarioRepository) CancelarInventario(id int64) error {
+	res, err := ir.db.Exec("UPDATE inventarios SET status = ? WHERE id = ? AND status = ?",
+		domain.InventarioCancelado, id, domain.InventarioAberto)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	var status domain.StatusInventario
+	if err := ir.db.QueryRow("SELECT status FROM inventarios WHERE id = ?", id).Scan(&status); err != nil {
+		return err
+	}
+	return fmt.Errorf("cancelar inventário %s: %w", status, domain.ErrSituacaoInventario)
+}
+
+func buscarInventario(q queryer, id int64) (domain.Inventario, error) {
+	inv, err := scanInventario(q.QueryRow("SELECT "+inventarioColunas+" FROM inventarios i WHERE i.id = ?", id))
+	if err != nil {
+		return domain.Inventario{}, err
+	}
+	if inv.Itens, err = buscarItensInventario(q, id); err != nil {
+		return domain.Inventario{}, err
+	}
+	inv.CalcularDiferencas()
+	return inv, nil
+}
+
+func buscarItensInventario(q queryer, inventarioID int64) ([]domain.ItemInventario, error) {
+	rows, err := q.Query(
+		`SELECT `+inventarioItemColunas+`
+		   FROM inventarios_produtos ip
+		   JOIN produtos p ON p.id = ip.produto_id
+		  WHERE ip.inventario_id = ?
+		  ORDER BY p.nome, ip.produto_id`, inventarioID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.ItemInventario{}
+	for rows.Next() {
+		item, err := scanInventarioItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
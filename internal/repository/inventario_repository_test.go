@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestInventarioRepository(t *testing.T) {
+	db := novoBanco(t)
+	ir := NewInventarioRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 10, "2.00")
+	b := criarProduto(t, db, f, "B1", 4, "3.00")
+	c := criarProduto(t, db, f, "C1", 6, "1.00")
+	if _, err := db.Exec("UPDATE produtos SET codigo_barras = '7891234567895' WHERE id = ?", b.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	inv := domain.Inventario{Descricao: "trimestral", Usuario: "estoquista"}
+	if err := ir.AbrirInventario(&inv); err != nil {
+		t.Fatalf("AbrirInventario: %v", err)
+	}
+	if len(inv.Itens) != 3 || inv.Itens[0].Esperada != 10 {
+		t.Fatalf("itens congelados = %+v", inv.Itens)
+	}
+	if err := ir.AbrirInventario(&domain.Inventario{}); !errors.Is(err, domain.ErrInventarioEmAndamento) {
+		t.Errorf("segunda sessão aberta: %v", err)
+	}
+
+	// venda durante a contagem não muda a quantidade esperada
+	venda := domain.Sale{ClientID: criarCliente(t, db, "Cliente").ID,
+		Items: []domain.SaleItem{{ProductID: a.ID, Quantity: 3}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "caixa"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ir.RegistrarContagens(inv.ID, []domain.Contagem{{CodigoBarras: "000", Quantidade: 1}}, "estoquista")
+	if !errors.Is(err, ErrCodigoBarrasInexistente) {
+		t.Errorf("código de barras desconhecido: %v", err)
+	}
+	contagens := []domain.Contagem{
+		{ProdutoID: a.ID, Quantidade: 9},
+		{CodigoBarras: "7891234567895", Quantidade: 1},
+	}
+	if _, err := ir.RegistrarContagens(inv.ID, contagens, "estoquista"); err != nil {
+		t.Fatalf("RegistrarContagens: %v", err)
+	}
+	// recontagem substitui a anterior
+	contado, err := ir.RegistrarContagens(inv.ID, []domain.Contagem{{ProdutoID: b.ID, Quantidade: 5}}, "estoquista")
+	if err != nil {
+		t.Fatalf("RegistrarContagens: %v", err)
+	}
+	if contado.Contados != 2 || contado.Divergentes != 2 {
+		t.Errorf("após contagens = %+v", contado)
+	}
+
+	aprovado, err := ir.AprovarInventario(inv.ID, "gerente")
+	if err != nil {
+		t.Fatalf("AprovarInventario: %v", err)
+	}
+	if aprovado.Status != domain.InventarioAprovado || aprovado.AprovadoPor != "gerente" {
+		t.Errorf("aprovado = %+v", aprovado)
+	}
+	if got := estoque(t, db, a.ID); got != 6 {
+		t.Errorf("estoque de A = %d, esperado 6 (10 - 3 vendidos - 1 faltante)", got)
+	}
+	if got := estoque(t, db, b.ID); got != 5 {
+		t.Errorf("estoque de B = %d, esperado 5", got)
+	}
+	if got := estoque(t, db, c.ID); got != 6 {
+		t.Errorf("produto não contado mudou: %d", got)
+	}
+
+	movs, err := NewMovimentoRepository(db).BuscarMovimentos(a.ID, map[string]any{"tipo": string(domain.MovimentoInventario)}, 10, 0)
+	if err != nil || len(movs) != 1 || movs[0].Quantidade != -1 || movs[0].Documento != documento("inventario", inv.ID) {
+		t.Errorf("movimentos de inventário = %+v, err %v", movs, err)
+	}
+
+	if _, err := ir.RegistrarContagens(inv.ID, contagens, "estoquista"); !errors.Is(err, domain.ErrSituacaoInventario) {
+		t.Errorf("contagem em sessão aprovada: %v", err)
+	}
+	if err := ir.CancelarInventario(inv.ID); !errors.Is(err, domain.ErrSituacaoInventario) {
+		t.Errorf("cancelar sessão aprovada: %v", err)
+	}
+}
@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrLoteInexistente indica lote que não existe ou não é do produto e depósito do movimento
+var ErrLoteInexistente = errors.New("lote não encontrado no depósito do produto")
+
+// LoteRepository consulta os lotes e as validades do estoque
+type LoteRepository struct {
+	db *sql.DB
+}
+
+// NewLoteRepository cria uma instância de LoteRepository
+func NewLoteRepository(db *sql.DB) *LoteRepository {
+	return &LoteRepository{db: db}
+}
+
+// BuscarLotes lista os lotes com saldo, do que vence primeiro ao último (sem
+// validade por último). Filtros: produto_id, deposito_id e todos (true inclui
+// os lotes já esgotados).
+func (lr *LoteRepository) BuscarLotes(filters map[string]any) ([]domain.Lote, error) {
+	query := "SELECT " + loteColunas + " FROM lotes l JOIN produtos p ON p.id = l.produto_id JOIN depositos d ON d.id = l.deposito_id"
+	var clauses []string
+	var args []any
+
+	if v, ok := filters["produto_id"]; ok {
+		clauses = append(clauses, "l.produto_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["deposito_id"]; ok {
+		clauses = append(clauses, "l.deposito_id = ?")
+		args = append(args, v)
+	}
+	if todos, _ := filters["todos"].(bool); !todos {
+		clauses = append(clauses, "l.quantidade > 0")
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY l.validade IS NULL, l.validade, l.id"
+
+	rows, err := lr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lotes := []domain.Lote{}
+	for rows.Next() {
+		l, err := scanLote(rows)
+		if err != nil {
+			return nil, err
+		}
+		lotes = append(lotes, l)
+	}
+	return lotes, rows.Err()
+}
+
+// RelatorioVencimentos lista os lotes com saldo que vencem nos próximos dias
+// dias a partir de hoje, incluindo os já vencidos, valorizados pelo custo
+// médio atual. Filtro: deposito_id.
+func (lr *LoteRepository) RelatorioVencimentos(dias int, hoje time.Time, filters map[string]any) (domain.RelatorioVencimentos, error) {
+	ate := hoje.AddDate(0, 0, dias).Format("2006-01-02")
+	query := "SELECT " + loteColunas + `, p.custo
+		FROM lotes l JOIN produtos p ON p.id = l.produto_id JOIN depositos d ON d.id = l.deposito_id
+		WHERE l.quantidade > 0 AND l.validade IS NOT NULL AND date(l.validade) <= date(?)`
+	args := []any{ate}
+	if v, ok := filters["deposito_id"]; ok {
+		query += " AND l.deposito_id = ?"
+		args = append(args, v)
+	}
+	query += " ORDER BY l.validade, l.id"
+
+	rows, err := lr.db.Query(query, args...)
+	if err != nil {
+		return domain.RelatorioVencimentos{}, err
+	}
+	defer rows.Close()
+
+	relatorio := domain.RelatorioVencimentos{
+		Dias:  dias,
+		Ate:   ate,
+		Lotes: []domain.LoteAVencer{},
+		Valor: domain.DecimalFromInt(0),
+	}
+	for rows.Next() {
+		var item domain.LoteAVencer
+		var custo domain.Decimal
+		l, err := scanLote(comExtras(rows, &custo))
+		if err != nil {
+			return domain.RelatorioVencimentos{}, err
+		}
+		item.Lote = l
+		item.DiasRestantes, _ = l.DiasParaVencer(hoje)
+		item.Vencido = item.DiasRestantes < 0
+		item.Custo = custo
+		item.Valor = custo.MulInt(l.Quantidade).RoundTo(2)
+		relatorio.Lotes = append(relatorio.Lotes, item)
+		relatorio.Quantidade += l.Quantidade
+		relatorio.Valor = relatorio.Valor.Add(item.Valor)
+	}
+	return relatorio, rows.Err()
+}
+
+// baixarLotes tira dos lotes as unidades da saída m, já lançada no razão: do
+// lote loteID quando informado, senão dos lotes com validade mais próxima
+// (FEFO). O que passar da soma dos lotes sai das unidades sem lote, que o
+// saldo do depósito garante existirem. Venda e alteração de venda não levam
+// lote vencido: se só eles cobrem a quantidade, devolve
+// *EstoqueInsuficienteError com os vencidos.
+func baixarLotes(tx *sql.Tx, m *domain.MovimentoEstoque, loteID int64) error {
+	restante := -m.Quantidade
+	origem := origemMovimento(m)
+	if loteID != 0 {
+		lote, err := buscarLoteMovimento(tx, loteID, m)
+		if err != nil {
+			return err
+		}
+		if lote.Quantidade < restante {
+			return &EstoqueInsuficienteError{Itens: []ItemSemEstoque{{
+				ProdutoID:  m.ProdutoID,
+				DepositoID: m.DepositoID,
+				LoteID:     loteID,
+				Solicitado: restante,
+				Disponivel: lote.Quantidade,
+			}}}
+		}
+		return moverLote(tx, loteID, m.ID, origem, -restante)
+	}
+
+	type saldoLote struct {
+		id, quantidade int64
+		vencido        bool
+	}
+	rows, err := tx.Query(
+		`SELECT id, quantidade, COALESCE(date(validade) < date(?), 0) FROM lotes
+		  WHERE produto_id = ? AND deposito_id = ? AND quantidade > 0
+		  ORDER BY validade IS NULL, validade, id`,
+		m.CriadoEm.Format("2006-01-02"), m.ProdutoID, m.DepositoID)
+	if err != nil {
+		return err
+	}
+	var lotes []saldoLote
+	var vencidos int64
+	for rows.Next() {
+		var l saldoLote
+		if err := rows.Scan(&l.id, &l.quantidade, &l.vencido); err != nil {
+			rows.Close()
+			return err
+		}
+		if l.vencido {
+			vencidos += l.quantidade
+		}
+		lotes = append(lotes, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	venda := m.Tipo == domain.MovimentoVenda || m.Tipo == domain.MovimentoAlteracaoVenda
+	if disponivel := m.SaldoDeposito + restante - vencidos; venda && disponivel < restante {
+		return &EstoqueInsuficienteError{Itens: []ItemSemEstoque{{
+			ProdutoID:  m.ProdutoID,
+			DepositoID: m.DepositoID,
+			Solicitado: restante,
+			Disponivel: disponivel,
+			Vencidos:   vencidos,
+		}}}
+	}
+	for _, l := range lotes {
+		if restante == 0 {
+			break
+		}
+		if venda && l.vencido {
+			continue
+		}
+		q := min(restante, l.quantidade)
+		if err := moverLote(tx, l.id, m.ID, origem, -q); err != nil {
+			return err
+		}
+		restante -= q
+	}
+	return nil
+}
+
+// entrarLote põe no lote as unidades da entrada m, já lançada no razão,
+// criando o lote no depósito na primeira entrada. Número já usado no produto
+// com outra validade devolve domain.ErrValidadeLoteDivergente.
+func entrarLote(tx *sql.Tx, m *domain.MovimentoEstoque, lote *domain.Lote) error {
+	if lote.Numero != "" {
+		var validade string
+		err := tx.QueryRow(
+			`SELECT COALESCE(date(validade), '') FROM lotes WHERE produto_id = ? AND numero = ? LIMIT 1`,
+			m.ProdutoID, lote.Numero).Scan(&validade)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && validade != lote.Validade {
+			return fmt.Errorf("lote %q do produto %d vence em %q: %w",
+				lote.Numero, m.ProdutoID, validade, domain.ErrValidadeLoteDivergente)
+		}
+	}
+
+	err := tx.QueryRow(
+		`SELECT id FROM lotes
+		  WHERE produto_id = ? AND deposito_id = ? AND numero = ? AND COALESCE(date(validade), '') = ?`,
+		m.ProdutoID, m.DepositoID, lote.Numero, lote.Validade).Scan(&lote.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		lote.CriadoEm = time.Now()
+		res, err := tx.Exec(
+			`INSERT INTO lotes (produto_id, deposito_id, numero, validade, criado_em) VALUES (?, ?, ?, ?, ?)`,
+			m.ProdutoID, m.DepositoID, lote.Numero, nullString(lote.Validade), lote.CriadoEm)
+		if err != nil {
+			return err
+		}
+		lote.ID, _ = res.LastInsertId()
+	} else if err != nil {
+		return err
+	}
+	lote.ProdutoID, lote.DepositoID = m.ProdutoID, m.DepositoID
+	return moverLote(tx, lote.ID, m.ID, origemMovimento(m), lote.Quantidade)
+}
+
+// ajustarLote aplica ao lote loteID o ajuste m, já lançado no razão
+func ajustarLote(tx *sql.Tx, m *domain.MovimentoEstoque, loteID int64) error {
+	if m.Quantidade < 0 {
+		return baixarLotes(tx, m, loteID)
+	}
+	if _, err := buscarLoteMovimento(tx, loteID, m); err != nil {
+		return err
+	}
+	return moverLote(tx, loteID, m.ID, origemMovimento(m), m.Quantidade)
+}
+
+// estornarLotes devolve aos lotes as unidades da entrada m que estorna saídas
+// do documento origem (venda cancelada, alterada ou devolvida), desfazendo as
+// baixas do último lote consumido para o primeiro. Unidades que origem não
+// tirou de lote algum voltam sem lote.
+func estornarLotes(tx *sql.Tx, m *domain.MovimentoEstoque, origem string) error {
+	rows, err := tx.Query(
+		`SELECT lm.lote_id, -SUM(lm.quantidade)
+		   FROM lotes_movimentos lm
+		   JOIN lotes l ON l.id = lm.lote_id
+		  WHERE lm.origem = ? AND l.produto_id = ? AND l.deposito_id = ?
+		  GROUP BY lm.lote_id
+		 HAVING SUM(lm.quantidade) < 0
+		  ORDER BY l.validade IS NULL DESC, l.validade DESC, l.id DESC`,
+		origem, m.ProdutoID, m.DepositoID)
+	if err != nil {
+		return err
+	}
+	type baixa struct{ loteID, quantidade int64 }
+	var baixas []baixa
+	for rows.Next() {
+		var b baixa
+		if err := rows.Scan(&b.loteID, &b.quantidade); err != nil {
+			rows.Close()
+			return err
+		}
+		baixas = append(baixas, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	restante := m.Quantidade
+	for _, b := range baixas {
+		if restante == 0 {
+			break
+		}
+		q := min(restante, b.quantidade)
+		if err := moverLote(tx, b.loteID, m.ID, origem, q); err != nil {
+			return err
+		}
+		restante -= q
+	}
+	return nil
+}
+
+// baixarLotesRecebidos tira a saída m, já lançada no razão, dos lotes em que
+// o documento origem deu entrada (mercadoria devolvida ao fornecedor da
+// compra), registrando as baixas em nome de origem para que a compra saiba
+// quanto ainda tem em cada lote. O que esses lotes não cobrirem, por já terem
+// sido vendidos, sai como em baixarLotes.
+func baixarLotesRecebidos(tx *sql.Tx, m *domain.MovimentoEstoque, origem string) error {
+	rows, err := tx.Query(
+		`SELECT lm.lote_id, MIN(SUM(lm.quantidade), l.quantidade)
+		   FROM lotes_movimentos lm
+		   JOIN lotes l ON l.id = lm.lote_id
+		  WHERE lm.origem = ? AND l.produto_id = ? AND l.deposito_id = ? AND l.quantidade > 0
+		  GROUP BY lm.lote_id
+		 HAVING SUM(lm.quantidade) > 0
+		  ORDER BY l.validade IS NULL, l.validade, l.id`,
+		origem, m.ProdutoID, m.DepositoID)
+	if err != nil {
+		return err
+	}
+	type saldoLote struct{ id, quantidade int64 }
+	var lotes []saldoLote
+	for rows.Next() {
+		var l saldoLote
+		if err := rows.Scan(&l.id, &l.quantidade); err != nil {
+			rows.Close()
+			return err
+		}
+		lotes = append(lotes, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	restante := -m.Quantidade
+	for _, l := range lotes {
+		if restante == 0 {
+			return nil
+		}
+		q := min(restante, l.quantidade)
+		if err := moverLote(tx, l.id, m.ID, origem, -q); err != nil {
+			return err
+		}
+		restante -= q
+	}
+	if restante == 0 {
+		return nil
+	}
+	resto := *m
+	resto.Quantidade = -restante
+	return baixarLotes(tx, &resto, 0)
+}
+
+// lotesDoMovimento devolve os lotes tocados pelo movimento, com a quantidade
+// movida em cada um (positiva, qualquer que seja o sentido)
+func lotesDoMovimento(q queryer, movimentoID int64) ([]domain.Lote, error) {
+	rows, err := q.Query(
+		`SELECT l.id, l.numero, COALESCE(date(l.validade), ''), ABS(lm.quantidade)
+		   FROM lotes_movimentos lm
+		   JOIN lotes l ON l.id = lm.lote_id
+		  WHERE lm.movimento_id = ?
+		  ORDER BY lm.id`,
+		movimentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lotes []domain.Lote
+	for rows.Next() {
+		var l domain.Lote
+		if err := rows.Scan(&l.ID, &l.Numero, &l.Validade, &l.Quantidade); err != nil {
+			return nil, err
+		}
+		lotes = append(lotes, l)
+	}
+	return lotes, rows.Err()
+}
+
+// lotesRecebidos agrupa por produto os lotes em que o documento deu entrada,
+// com a quantidade que ele pôs em cada um
+func lotesRecebidos(q queryer, doc string) (map[int64][]domain.Lote, error) {
+	rows, err := q.Query(
+		"SELECT "+loteColunas+`
+		   FROM lotes l JOIN produtos p ON p.id = l.produto_id JOIN depositos d ON d.id = l.deposito_id
+		  WHERE l.id IN (SELECT lote_id FROM lotes_movimentos WHERE origem = ? AND quantidade > 0)
+		  ORDER BY l.produto_id, l.id`,
+		doc)
+	if err != nil {
+		return nil, err
+	}
+	var lotes []domain.Lote
+	for rows.Next() {
+		l, err := scanLote(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lotes = append(lotes, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	porProduto := make(map[int64][]domain.Lote)
+	for _, l := range lotes {
+		if err := q.QueryRow(
+			`SELECT SUM(quantidade) FROM lotes_movimentos WHERE lote_id = ? AND origem = ? AND quantidade > 0`,
+			l.ID, doc).Scan(&l.Quantidade); err != nil {
+			return nil, err
+		}
+		porProduto[l.ProdutoID] = append(porProduto[l.ProdutoID], l)
+	}
+	return porProduto, nil
+}
+
+func buscarLoteMovimento(tx *sql.Tx, loteID int64, m *domain.MovimentoEstoque) (domain.Lote, error) {
+	var l domain.Lote
+	err := tx.QueryRow(
+		`SELECT id, quantidade FROM lotes WHERE id = ? AND produto_id = ? AND deposito_id = ?`,
+		loteID, m.ProdutoID, m.DepositoID).Scan(&l.ID, &l.Quantidade)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Lote{}, fmt.Errorf("lote %d: %w", loteID, ErrLoteInexistente)
+	}
+	return l, err
+}
+
+// moverLote soma quantidade ao lote e registra de qual movimento veio
+func moverLote(tx *sql.Tx, loteID, movimentoID int64, origem string, quantidade int64) error {
+	if _, err := tx.Exec("UPDATE lotes SET quantidade = quantidade + ? WHERE id = ?", quantidade, loteID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`INSERT INTO lotes_movimentos (lote_id, movimento_id, origem, quantidade) VALUES (?, ?, ?, ?)`,
+		loteID, movimentoID, origem, quantidade)
+	return err
+}
+
+// origemMovimento é o documento do movimento ou, em ajustes manuais sem
+// documento, o próprio movimento
+func origemMovimento(m *domain.MovimentoEstoque) string {
+	if m.Documento != "" {
+		return m.Documento
+	}
+	return documento("movimento", m.ID)
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// saldosLotes lê a quantidade de cada lote do produto no depósito, por número
+func saldosLotes(t *testing.T, lr *LoteRepository, produtoID, depositoID int64) map[string]int64 {
+	t.Helper()
+	lotes, err := lr.BuscarLotes(map[string]any{"produto_id": produtoID, "deposito_id": depositoID, "todos": true})
+	if err != nil {
+		t.Fatalf("BuscarLotes: %v", err)
+	}
+	saldos := make(map[string]int64, len(lotes))
+	for _, l := range lotes {
+		saldos[l.Numero] = l.Quantidade
+	}
+	return saldos
+}
+
+// conferirLotes exige que a soma dos lotes de cada depósito caiba no saldo dele
+func conferirLotes(t *testing.T, db *sql.DB) {
+	t.Helper()
+	var excedentes int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT l.produto_id, l.deposito_id, SUM(l.quantidade) AS em_lotes
+			  FROM lotes l GROUP BY l.produto_id, l.deposito_id) s
+		  LEFT JOIN estoque_depositos ed ON ed.produto_id = s.produto_id AND ed.deposito_id = s.deposito_id
+		 WHERE s.em_lotes > COALESCE(ed.quantidade, 0)`).Scan(&excedentes)
+	if err != nil || excedentes != 0 {
+		t.Errorf("lotes acima do saldo do depósito: %d, %v", excedentes, err)
+	}
+}
+
+func iguais(a, b map[string]int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLotesFEFO(t *testing.T) {
+	db := novoBanco(t)
+	lr := NewLoteRepository(db)
+	cr := NewCompraRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A", 2, "2.00")
+	loja := domain.DepositoPrincipal
+
+	compra := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: p.ID, Quantidade: 10, PrecoUnitario: decimal(t, "1.50")},
+	}}
+	if err := cr.SalvarCompra(&compra); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	excesso := map[int64][]domain.Lote{p.ID: {{Numero: "X", Quantidade: 11}}}
+	if _, err := cr.ReceberCompra(compra.ID, nil, excesso, "teste"); !errors.Is(err, ErrLotesExcedemItem) {
+		t.Fatalf("lotes acima do item: err = %v", err)
+	}
+	lotes := map[int64][]domain.Lote{p.ID: {
+		{Numero: "L2", Validade: "2030-03-01", Quantidade: 3},
+		{Numero: "VENC", Validade: "2029-12-20", Quantidade: 2},
+		{Numero: "L1", Validade: "2030-01-10", Quantidade: 4},
+	}}
+	recebida, err := cr.ReceberCompra(compra.ID, nil, lotes, "teste")
+	if err != nil {
+		t.Fatalf("ReceberCompra: %v", err)
+	}
+	if len(recebida.Items[0].Lotes) != 3 || recebida.Items[0].Lotes[0].ID == 0 {
+		t.Errorf("lotes do recebimento = %+v", recebida.Items[0].Lotes)
+	}
+	if got, _ := cr.BuscarCompraPorId(compra.ID); len(got.Items[0].Lotes) != 3 || got.Items[0].Lotes[2].Quantidade != 4 {
+		t.Errorf("lotes da compra = %+v", got.Items[0].Lotes)
+	}
+	if estoque(t, db, p.ID) != 12 {
+		t.Fatalf("estoque = %d, esperado 12 (10 da compra, 1 delas sem lote)", estoque(t, db, p.ID))
+	}
+
+	// vencidos e a vencer em 30 dias, do que vence primeiro
+	hoje := time.Date(2030, 1, 1, 10, 0, 0, 0, time.Local)
+	relatorio, err := lr.RelatorioVencimentos(30, hoje, nil)
+	if err != nil || len(relatorio.Lotes) != 2 || relatorio.Quantidade != 6 || relatorio.Valor.String() != "9.00" {
+		t.Fatalf("RelatorioVencimentos = %+v, %v", relatorio, err)
+	}
+	if venc := relatorio.Lotes[0]; venc.Numero != "VENC" || !venc.Vencido || venc.DiasRestantes != -12 {
+		t.Errorf("lote vencido = %+v", venc)
+	}
+	if l1 := relatorio.Lotes[1]; l1.Numero != "L1" || l1.Vencido || l1.DiasRestantes != 9 || l1.Produto != p.Nome {
+		t.Errorf("lote a vencer = %+v", l1)
+	}
+
+	// baixa do lote vencido por ajuste
+	mr := NewMovimentoRepository(db)
+	venc := relatorio.Lotes[0].ID
+	if _, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, LoteID: venc, Quantidade: -3,
+		Motivo: domain.AjusteVencimento}, "teste"); !faltaNoLote(err, venc) {
+		t.Errorf("ajuste acima do lote: err = %v", err)
+	}
+	if _, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, LoteID: 999, Quantidade: -1,
+		Motivo: domain.AjustePerda}, "teste"); !errors.Is(err, ErrLoteInexistente) {
+		t.Errorf("ajuste em lote inexistente: err = %v", err)
+	}
+	if _, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: p.ID, LoteID: venc, Quantidade: -2,
+		Motivo: domain.AjusteVencimento}, "teste"); err != nil {
+		t.Fatalf("AjustarEstoque do lote vencido: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, loja); !iguais(got, map[string]int64{"VENC": 0, "L1": 4, "L2": 3}) {
+		t.Errorf("lotes após baixa do vencido = %v", got)
+	}
+
+	// a venda consome primeiro o lote que vence antes
+	vr := NewVendasRepository(db)
+	c := criarCliente(t, db, "Cliente")
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 5}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, loja); !iguais(got, map[string]int64{"VENC": 0, "L1": 0, "L2": 2}) {
+		t.Errorf("lotes após a venda = %v", got)
+	}
+
+	// devolução e cancelamento voltam aos lotes de onde a venda tirou
+	dr := NewDevolucaoRepository(db)
+	if err := dr.RegistrarDevolucao(&domain.Devolucao{VendaID: venda.ID, Destino: domain.DevolucaoCredito,
+		Items: []domain.ItemDevolucao{{ProdutoID: p.ID, Quantidade: 2}}}); err != nil {
+		t.Fatalf("RegistrarDevolucao: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, loja); !iguais(got, map[string]int64{"VENC": 0, "L1": 1, "L2": 3}) {
+		t.Errorf("lotes após a devolução = %v", got)
+	}
+	if _, err := vr.CancelarVenda(venda.ID, "desistiu", "teste"); err != nil {
+		t.Fatalf("CancelarVenda: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, loja); !iguais(got, map[string]int64{"VENC": 0, "L1": 4, "L2": 3}) {
+		t.Errorf("lotes após o cancelamento = %v", got)
+	}
+
+	// a transferência leva os lotes, com número e validade, ao destino
+	fundos := criarDeposito(t, NewDepositoRepository(db), "Fundos")
+	tr := NewTransferenciaRepository(db)
+	if err := tr.Transferir(&domain.Transferencia{OrigemID: loja, DestinoID: fundos.ID,
+		Itens: []domain.ItemTransferencia{{ProdutoID: p.ID, Quantidade: 6}}}); err != nil {
+		t.Fatalf("Transferir: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, loja); !iguais(got, map[string]int64{"VENC": 0, "L1": 0, "L2": 1}) {
+		t.Errorf("lotes na origem = %v", got)
+	}
+	noDestino, err := lr.BuscarLotes(map[string]any{"deposito_id": fundos.ID})
+	if err != nil || len(noDestino) != 2 || noDestino[0].Numero != "L1" || noDestino[0].Validade != "2030-01-10" ||
+		noDestino[0].Quantidade != 4 || noDestino[1].Quantidade != 2 || noDestino[1].Deposito != "Fundos" {
+		t.Errorf("lotes no destino = %+v, %v", noDestino, err)
+	}
+
+	// mesmo número com outra validade é recusado
+	outra := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: p.ID, Quantidade: 1, PrecoUnitario: decimal(t, "1.50")},
+	}}
+	if err := cr.SalvarCompra(&outra); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	divergente := map[int64][]domain.Lote{p.ID: {{Numero: "L1", Validade: "2031-01-01", Quantidade: 1}}}
+	if _, err := cr.ReceberCompra(outra.ID, nil, divergente, "teste"); !errors.Is(err, domain.ErrValidadeLoteDivergente) {
+		t.Errorf("lote com outra validade: err = %v", err)
+	}
+
+	conferirLotes(t, db)
+	if divergencias, err := mr.VerificarConsistencia(); err != nil || len(divergencias) != 0 {
+		t.Errorf("VerificarConsistencia = %+v, %v", divergencias, err)
+	}
+}
+
+// faltaNoLote confere o erro de ajuste acima do saldo do lote
+func TestVendaNaoLevaLoteVencido(t *testing.T) {
+	db := novoBanco(t)
+	lr := NewLoteRepository(db)
+	cr := NewCompraRepository(db)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A", 0, "2.00")
+	c := criarCliente(t, db, "Cliente")
+	hoje := time.Now()
+
+	compra := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: p.ID, Quantidade: 10, PrecoUnitario: decimal(t, "1.50")},
+	}}
+	if err := cr.SalvarCompra(&compra); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	lotes := map[int64][]domain.Lote{p.ID: {
+		{Numero: "VENCIDO", Validade: hoje.AddDate(0, 0, -1).Format("2006-01-02"), Quantidade: 4},
+		{Numero: "BOM", Validade: hoje.AddDate(1, 0, 0).Format("2006-01-02"), Quantidade: 3},
+	}}
+	if _, err := cr.ReceberCompra(compra.ID, nil, lotes, "teste"); err != nil {
+		t.Fatalf("ReceberCompra: %v", err)
+	}
+
+	// 6 unidades: as 3 do lote bom e 3 das sem lote; o vencido fica
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 6}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	if got := saldosLotes(t, lr, p.ID, domain.DepositoPrincipal); !iguais(got, map[string]int64{"VENCIDO": 4, "BOM": 0}) {
+		t.Errorf("lotes após venda = %v", got)
+	}
+
+	// só sobraram unidades vencidas: a venda é recusada dizendo por quê
+	outra := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 1}}}
+	var semEstoque *EstoqueInsuficienteError
+	if err := vr.SalvarVenda(&outra, "teste"); !errors.As(err, &semEstoque) ||
+		semEstoque.Itens[0].Disponivel != 0 || semEstoque.Itens[0].Vencidos != 4 {
+		t.Errorf("venda de lote vencido: err = %v", err)
+	}
+	if got := estoque(t, db, p.ID); got != 4 {
+		t.Errorf("estoque = %d, esperado 4", got)
+	}
+	conferirLotes(t, db)
+}
+
+func faltaNoLote(err error, loteID int64) bool {
+	var semEstoque *EstoqueInsuficienteError
+	return errors.As(err, &semEstoque) && len(semEstoque.Itens) == 1 && semEstoque.Itens[0].LoteID == loteID &&
+		semEstoque.Itens[0].Disponivel == 2
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/database"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+	"github.com/julio-pupim/lojaestoque/migrations"
+)
+
+// novoBanco abre um SQLite vazio num diretório temporário e aplica todas as
+// migrações, para que as consultas sejam testadas contra o esquema real
+func novoBanco(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "teste.db"))
+	if err != nil {
+		t.Fatalf("abrindo banco: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migracoes, err := database.CarregarMigracoes(migrations.FS)
+	if err != nil {
+		t.Fatalf("carregando migrações: %v", err)
+	}
+	if _, err := database.AplicarMigracoes(db, migracoes); err != nil {
+		t.Fatalf("aplicando migrações: %v", err)
+	}
+	return db
+}
+
+func decimal(t *testing.T, s string) domain.Decimal {
+	t.Helper()
+	d, err := domain.ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("decimal %q: %v", s, err)
+	}
+	return d
+}
+
+func criarFornecedor(t *testing.T, db *sql.DB, nome string) domain.Fornecedor {
+	t.Helper()
+	f := domain.Fornecedor{Nome: nome}
+	if err := NewFornecedorRepository(db).Salvar(&f); err != nil {
+		t.Fatalf("salvando fornecedor: %v", err)
+	}
+	return f
+}
+
+func criarProduto(t *testing.T, db *sql.DB, f domain.Fornecedor, codigo string, estoque int64, preco string) domain.Produto {
+	t.Helper()
+	p := domain.Produto{
+		Nome:              "Produto " + codigo,
+		Fornecedor:        f,
+		CodigoFornecedor:  codigo,
+		QuantidadeEstoque: estoque,
+		Preco:             decimal(t, preco),
+	}
+	if err := NewProdutoRepository(db).Save(&p, "teste"); err != nil {
+		t.Fatalf("salvando produto: %v", err)
+	}
+	return p
+}
+
+func criarCliente(t *testing.T, db *sql.DB, nome string) domain.Cliente {
+	t.Helper()
+	c := domain.Cliente{Nome: nome, Telefone: "11999990000", DataCadastro: "2024-01-15"}
+	res, err := NewClienteRepository(db).SalvarCliente(&c)
+	if err != nil {
+		t.Fatalf("salvando cliente: %v", err)
+	}
+	c.ID, _ = res.LastInsertId()
+	return c
+}
+
+func estoque(t *testing.T, db *sql.DB, produtoID int64) int64 {
+	t.Helper()
+	var qtd int64
+	if err := db.QueryRow("SELECT qtd_estoque FROM produtos WHERE id = ?", produtoID).Scan(&qtd); err != nil {
+		t.Fatalf("lendo estoque: %v", err)
+	}
+	return qtd
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// MovimentoRepository consulta o razão de estoque (movimentacoes_estoque)
+type MovimentoRepository struct {
+	db *sql.DB
+}
+
+// NewMovimentoRepository cria uma instância de MovimentoRepository
+func NewMovimentoRepository(db *sql.DB) *MovimentoRepository {
+	return &MovimentoRepository{db: db}
+}
+
+// movimentarEstoque é o único caminho para alterar o estoque: aplica
+// m.Quantidade ao saldo do produto no depósito m.DepositoID (DepositoPrincipal
+// quando zero) e ao total em produtos.qtd_estoque, recusando saldo negativo no
+// depósito, e grava a linha no razão com os saldos resultantes. Saídas baixam
+// os lotes do depósito pela validade mais próxima; entradas não tocam nos
+// lotes, cabendo ao documento chamar entrarLote ou estornarLotes. Deve ser
+// chamada dentro da transação do documento.
+func movimentarEstoque(tx *sql.Tx, m *domain.MovimentoEstoque) error {
+	if err := lancarMovimento(tx, m); err != nil {
+		return err
+	}
+	if m.Quantidade < 0 {
+		return baixarLotes(tx, m, 0)
+	}
+	return nil
+}
+
+// lancarMovimento aplica o movimento aos saldos e ao razão sem mexer nos
+// lotes; fora de movimentarEstoque, só para quem escolhe o lote em seguida
+func lancarMovimento(tx *sql.Tx, m *domain.MovimentoEstoque) error {
+	if m.DepositoID == 0 {
+		m.DepositoID = domain.DepositoPrincipal
+	}
+	var existe int
+	err := tx.QueryRow("SELECT 1 FROM produtos WHERE id = ?", m.ProdutoID).Scan(&existe)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("produto %d: %w", m.ProdutoID, sql.ErrNoRows)
+	} else if err != nil {
+		return err
+	}
+
+	// o saldo do depósito só muda se continuar não negativo, para que dois
+	// documentos simultâneos não consumam a mesma última unidade
+	res, err := tx.Exec(
+		`UPDATE estoque_depositos SET quantidade = quantidade + ?
+		  WHERE produto_id = ? AND deposito_id = ? AND quantidade + ? >= 0`,
+		m.Quantidade, m.ProdutoID, m.DepositoID, m.Quantidade,
+	)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow("SELECT quantidade FROM estoque_depositos WHERE produto_id = ? AND deposito_id = ?",
+		m.ProdutoID, m.DepositoID).Scan(&m.SaldoDeposito)
+	semSaldo := errors.Is(err, sql.ErrNoRows)
+	if err != nil && !semSaldo {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if !semSaldo || m.Quantidade < 0 {
+			return &EstoqueInsuficienteError{Itens: []ItemSemEstoque{{
+				ProdutoID:  m.ProdutoID,
+				DepositoID: m.DepositoID,
+				Solicitado: -m.Quantidade,
+				Disponivel: m.SaldoDeposito,
+			}}}
+		}
+		// primeira entrada do produto no depósito
+		_, err := tx.Exec("INSERT INTO estoque_depositos (produto_id, deposito_id, quantidade) VALUES (?, ?, ?)",
+			m.ProdutoID, m.DepositoID, m.Quantidade)
+		if violaChaveEstrangeira(err) {
+			return fmt.Errorf("depósito %d: %w", m.DepositoID, ErrDepositoInexistente)
+		} else if err != nil {
+			return err
+		}
+		m.SaldoDeposito = m.Quantidade
+	}
+
+	if _, err := tx.Exec("UPDATE produtos SET qtd_estoque = qtd_estoque + ? WHERE id = ?",
+		m.Quantidade, m.ProdutoID); err != nil {
+		return err
+	}
+	if err := tx.QueryRow("SELECT qtd_estoque FROM produtos WHERE id = ?", m.ProdutoID).Scan(&m.Saldo); err != nil {
+		return err
+	}
+
+	if m.CriadoEm.IsZero() {
+		m.CriadoEm = time.Now()
+	}
+	res, err = tx.Exec(
+		`INSERT INTO movimentacoes_estoque
+		   (produto_id, deposito_id, tipo, quantidade, saldo, saldo_deposito, motivo, documento, usuario,
+		    criado_em, motivo_ajuste)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ProdutoID, m.DepositoID, m.Tipo, m.Quantidade, m.Saldo, m.SaldoDeposito,
+		nullString(m.Motivo), nullString(m.Documento), nullString(m.Usuario), m.CriadoEm,
+		nullString(string(m.MotivoAjuste)),
+	)
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+// documento monta a referência gravada no razão, ex.: "venda:12"
+func documento(tipo string, id int64) string {
+	return fmt.Sprintf("%s:%d", tipo, id)
+}
+
+// BuscarMovimentos lista o razão de um produto, do movimento mais recente ao mais antigo
+func (mr *MovimentoRepository) BuscarMovimentos(produtoID int64, filters map[string]any, limit, offset int) ([]domain.MovimentoEstoque, error) {
+	query := "SELECT " + movimentoColunas + " FROM movimentacoes_estoque"
+	clauses := []string{"produto_id = ?"}
+	args := []any{produtoID}
+
+	if v, ok := filters["tipo"]; ok {
+		clauses = append(clauses, "tipo = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["deposito_id"]; ok {
+		clauses = append(clauses, "deposito_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_inicio"]; ok {
+		clauses = append(clauses, "date(criado_em) >= date(?)")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_fim"]; ok {
+		clauses = append(clauses, "date(criado_em) <= date(?)")
+		args = append(args, v)
+	}
+	query += " WHERE " + strings.Join(clauses, " AND ")
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := mr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movimentos := []domain.MovimentoEstoque{}
+	for rows.Next() {
+		m, err := scanMovimento(rows)
+		if err != nil {
+			return nil, err
+		}
+		movimentos = append(movimentos, m)
+	}
+	return movimentos, rows.Err()
+}
+
+// AjustarEstoque lança o ajuste manual no razão como movimento AJUSTE com o
+// código do motivo, no depósito do ajuste. Ajuste que deixaria o estoque do
+// depósito ou o lote escolhido negativo devolve *EstoqueInsuficienteError;
+// produto inexistente, sql.ErrNoRows; depósito inexistente ou inativo,
+// ErrDepositoInexistente ou domain.ErrDepositoInativo; lote de outro produto
+// ou depósito, ErrLoteInexistente.
+func (mr *MovimentoRepository) AjustarEstoque(a domain.AjusteEstoque, usuario string) (domain.MovimentoEstoque, error) {
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return domain.MovimentoEstoque{}, err
+	}
+	defer tx.Rollback()
+
+	if a.DepositoID == 0 {
+		a.DepositoID = domain.DepositoPrincipal
+	}
+	if err := conferirDeposito(tx, a.DepositoID); err != nil {
+		return domain.MovimentoEstoque{}, err
+	}
+	m := domain.MovimentoEstoque{
+		ProdutoID:    a.ProdutoID,
+		DepositoID:   a.DepositoID,
+		Tipo:         domain.MovimentoAjuste,
+		Quantidade:   a.Quantidade,
+		Motivo:       a.Observacao,
+		MotivoAjuste: a.Motivo,
+		Usuario:      usuario,
+	}
+	if a.LoteID == 0 {
+		err = movimentarEstoque(tx, &m)
+	} else if err = lancarMovimento(tx, &m); err == nil {
+		err = ajustarLote(tx, &m, a.LoteID)
+	}
+	if err != nil {
+		return domain.MovimentoEstoque{}, err
+	}
+	return m, tx.Commit()
+}
+
+// RelatorioAjustes resume por motivo os ajustes manuais com código entre
+// data_inicio e data_fim ("YYYY-MM-DD", inclusive, ambos opcionais), com o
+// valor pelo custo médio atual dos produtos
+func (mr *MovimentoRepository) RelatorioAjustes(filters map[string]any) (domain.RelatorioAjustes, error) {
+	query := `
+		SELECT m.motivo_ajuste, COUNT(*), SUM(m.quantidade), p.custo
+		  FROM movimentacoes_estoque m
+		  JOIN produtos p ON p.id = m.produto_id`
+	clauses := []string{"m.tipo = ?", "m.motivo_ajuste IS NOT NULL"}
+	args := []any{domain.MovimentoAjuste}
+	relatorio := domain.RelatorioAjustes{Motivos: []domain.ResumoAjuste{}, ValorQuebra: domain.DecimalFromInt(0)}
+
+	if v, ok := filters["data_inicio"]; ok {
+		clauses = append(clauses, "date(m.criado_em) >= date(?)")
+		args = append(args, v)
+		relatorio.DataInicio, _ = v.(string)
+	}
+	if v, ok := filters["data_fim"]; ok {
+		clauses = append(clauses, "date(m.criado_em) <= date(?)")
+		args = append(args, v)
+		relatorio.DataFim, _ = v.(string)
+	}
+	if v, ok := filters["produto_id"]; ok {
+		clauses = append(clauses, "m.produto_id = ?")
+		args = append(args, v)
+	}
+	query += " WHERE " + strings.Join(clauses, " AND ")
+	query += " GROUP BY m.motivo_ajuste, m.produto_id ORDER BY m.motivo_ajuste"
+
+	rows, err := mr.db.Query(query, args...)
+	if err != nil {
+		return domain.RelatorioAjustes{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			motivo     domain.MotivoAjuste
+			ajustes    int
+			quantidade int64
+			custo      domain.Decimal
+		)
+		if err := rows.Scan(&motivo, &ajustes, &quantidade, &custo); err != nil {
+			return domain.RelatorioAjustes{}, err
+		}
+		n := len(relatorio.Motivos)
+		if n == 0 || relatorio.Motivos[n-1].Motivo != motivo {
+			relatorio.Motivos = append(relatorio.Motivos, domain.ResumoAjuste{Motivo: motivo, Valor: domain.DecimalFromInt(0)})
+			n++
+		}
+		resumo := &relatorio.Motivos[n-1]
+		valor := custo.MulInt(quantidade).RoundTo(2)
+		resumo.Ajustes += ajustes
+		resumo.Quantidade += quantidade
+		resumo.Valor = resumo.Valor.Add(valor)
+		if motivo.Quebra() {
+			relatorio.QuantidadeQuebra += quantidade
+			relatorio.ValorQuebra = relatorio.ValorQuebra.Add(valor)
+		}
+	}
+	return relatorio, rows.Err()
+}
+
+// VerificarConsistencia recalcula o estoque de cada produto a partir do razão e
+// devolve os produtos cujo qtd_estoque não confere e, com o depósito, os
+// saldos por depósito que não conferem com os movimentos daquele depósito
+func (mr *MovimentoRepository) VerificarConsistencia() ([]domain.DivergenciaEstoque, error) {
+	rows, err := mr.db.Query(`
+		SELECT p.id, 0, p.nome, p.qtd_estoque, COALESCE(SUM(m.quantidade), 0) AS saldo_razao
+		  FROM produtos p
+		  LEFT JOIN movimentacoes_estoque m ON m.produto_id = p.id
+		 GROUP BY p.id, p.nome, p.qtd_estoque
+		HAVING p.qtd_estoque <> saldo_razao
+		 UNION ALL
+		SELECT s.produto_id, s.deposito_id, p.nome, s.quantidade, s.saldo_razao
+		  FROM (SELECT COALESCE(ed.produto_id, m.produto_id) AS produto_id,
+		               COALESCE(ed.deposito_id, m.deposito_id) AS deposito_id,
+		               COALESCE(ed.quantidade, 0) AS quantidade, COALESCE(m.saldo, 0) AS saldo_razao
+		          FROM estoque_depositos ed
+		          FULL JOIN (SELECT produto_id, deposito_id, SUM(quantidade) AS saldo
+		                       FROM movimentacoes_estoque GROUP BY produto_id, deposito_id) m
+		            ON m.produto_id = ed.produto_id AND m.deposito_id = ed.deposito_id) s
+		  JOIN produtos p ON p.id = s.produto_id
+		 WHERE s.quantidade <> s.saldo_razao
+		 ORDER BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	divergencias := []domain.DivergenciaEstoque{}
+	for rows.Next() {
+		var d domain.DivergenciaEstoque
+		if err := rows.Scan(&d.ProdutoID, &d.DepositoID, &d.Nome, &d.Estoque, &d.SaldoRazao); err != nil {
+			return nil, err
+		}
+		d.Diferenca = d.Estoque - d.SaldoRazao
+		divergencias = append(divergencias, d)
+	}
+	return divergencias, rows.Err()
+}
+
+// BuscarBaseAvaliacao devolve os produtos e, em ordem cronológica, os
+// movimentos do razão até o dia data ("YYYY-MM-DD"), inclusive, com o custo
+// unitário da compra que originou cada entrada de compra
+func (mr *MovimentoRepository) BuscarBaseAvaliacao(data string) ([]domain.ProdutoAvaliacao, []domain.MovimentoCusteado, error) {
+	rows, err := mr.db.Query(`
+		SELECT p.id, p.nome, p.fornecedor_id, f.nome, p.custo
+		  FROM ` + produtoFrom + `
+		 ORDER BY p.nome`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	produtos := []domain.ProdutoAvaliacao{}
+	for rows.Next() {
+		var p domain.ProdutoAvaliacao
+		if err := rows.Scan(&p.ProdutoID, &p.Nome, &p.FornecedorID, &p.Fornecedor, &p.CustoPadrao); err != nil {
+			return nil, nil, err
+		}
+		produtos = append(produtos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// transferências não mudam o estoque da empresa; no PEPS virariam uma
+	// saída e uma entrada pelo custo padrão, trocando o custo das camadas
+	rows, err = mr.db.Query(`
+		SELECT m.produto_id, m.quantidade, cp.preco_unitario
+		  FROM movimentacoes_estoque m
+		  LEFT JOIN compras_produtos cp
+		    ON m.tipo = ? AND m.documento = 'compra:' || cp.compra_id AND cp.produto_id = m.produto_id
+		 WHERE date(m.criado_em) <= date(?) AND m.tipo <> ?
+		 ORDER BY m.id`, domain.MovimentoCompra, data, domain.MovimentoTransferencia)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var movimentos []domain.MovimentoCusteado
+	for rows.Next() {
+		var (
+			m     domain.MovimentoCusteado
+			custo domain.Decimal
+		)
+		if err := rows.Scan(&m.ProdutoID, &m.Quantidade, &custo); err != nil {
+			return nil, nil, err
+		}
+		if custo.Decimal != nil {
+			m.Custo = &custo
+		}
+		movimentos = append(movimentos, m)
+	}
+	return produtos, movimentos, rows.Err()
+}
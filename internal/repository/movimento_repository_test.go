@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestMovimentoRepository(t *testing.T) {
+	db := novoBanco(t)
+	mr := NewMovimentoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 5, "2.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 2}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "caixa1"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	movs, err := mr.BuscarMovimentos(p.ID, nil, 10, 0)
+	if err != nil {
+		t.Fatalf("BuscarMovimentos: %v", err)
+	}
+	if len(movs) != 2 {
+		t.Fatalf("BuscarMovimentos = %+v", movs)
+	}
+	saida, inicial := movs[0], movs[1]
+	if saida.Tipo != domain.MovimentoVenda || saida.Quantidade != -2 || saida.Saldo != 3 ||
+		saida.Documento != documento("venda", venda.ID) || saida.Usuario != "caixa1" {
+		t.Errorf("movimento de venda = %+v", saida)
+	}
+	if inicial.Tipo != domain.MovimentoSaldoInicial || inicial.Quantidade != 5 || inicial.Saldo != 5 {
+		t.Errorf("saldo inicial = %+v", inicial)
+	}
+
+	hoje := time.Now().Format("2006-01-02")
+	movs, err = mr.BuscarMovimentos(p.ID, map[string]any{
+		"tipo":        string(domain.MovimentoVenda),
+		"data_inicio": hoje,
+		"data_fim":    hoje,
+	}, 10, 0)
+	if err != nil || len(movs) != 1 || movs[0].ID != saida.ID {
+		t.Errorf("BuscarMovimentos(filtros) = %+v, err %v", movs, err)
+	}
+
+	divergencias, err := mr.VerificarConsistencia()
+	if err != nil {
+		t.Fatalf("VerificarConsistencia: %v", err)
+	}
+	if len(divergencias) != 0 {
+		t.Errorf("divergências inesperadas: %+v", divergencias)
+	}
+
+	// alteração direta, fora do razão, precisa aparecer como divergência
+	if _, err := db.Exec("UPDATE produtos SET qtd_estoque = 10 WHERE id = ?", p.ID); err != nil {
+		t.Fatal(err)
+	}
+	divergencias, err = mr.VerificarConsistencia()
+	if err != nil {
+		t.Fatalf("VerificarConsistencia: %v", err)
+	}
+	if len(divergencias) != 1 || divergencias[0].SaldoRazao != 3 || divergencias[0].Diferenca != 7 {
+		t.Errorf("VerificarConsistencia = %+v", divergencias)
+	}
+}
+
+func TestBuscarBaseAvaliacao(t *testing.T) {
+	db := novoBanco(t)
+	mr := NewMovimentoRepository(db)
+	cr := NewCompraRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 0, "10.00")
+
+	for _, custo := range []string{"4.00", "6.00"} {
+		c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+			{ProdutoID: p.ID, Quantidade: 5, PrecoUnitario: decimal(t, custo)},
+		}}
+		if err := cr.SalvarCompra(&c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cr.ReceberCompra(c.ID, nil, nil, "teste"); err != nil {
+			t.Fatalf("ReceberCompra: %v", err)
+		}
+	}
+	venda := domain.Sale{ClientID: criarCliente(t, db, "Cliente").ID,
+		Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 6}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatal(err)
+	}
+
+	hoje := time.Now().Format("2006-01-02")
+	produtos, movimentos, err := mr.BuscarBaseAvaliacao(hoje)
+	if err != nil {
+		t.Fatalf("BuscarBaseAvaliacao: %v", err)
+	}
+	if len(produtos) != 1 || produtos[0].Fornecedor != "Fornecedor" || !produtos[0].CustoPadrao.Equal(decimal(t, "5.00")) {
+		t.Errorf("produtos = %+v", produtos)
+	}
+
+	medio := domain.AvaliarEstoque(hoje, domain.AvaliacaoCustoMedio, produtos, movimentos)
+	peps := domain.AvaliarEstoque(hoje, domain.AvaliacaoPEPS, produtos, movimentos)
+	if len(medio.Produtos) != 1 || medio.Produtos[0].Quantidade != 4 || !medio.Total.Equal(decimal(t, "20.00")) {
+		t.Errorf("custo médio = %+v", medio)
+	}
+	if !peps.Total.Equal(decimal(t, "24.00")) {
+		t.Errorf("PEPS = %+v", peps)
+	}
+
+	ontem := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if _, movimentos, err = mr.BuscarBaseAvaliacao(ontem); err != nil || len(movimentos) != 0 {
+		t.Errorf("movimentos até ontem = %+v, err %v", movimentos, err)
+	}
+}
+
+func TestAjustesDeEstoque(t *testing.T) {
+	db := novoBanco(t)
+	mr := NewMovimentoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 10, "5.00")
+	b := criarProduto(t, db, f, "B1", 4, "8.00")
+	if _, err := db.Exec("UPDATE produtos SET custo = CASE id WHEN ? THEN 2.5 ELSE 4 END", a.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ajustes := []domain.AjusteEstoque{
+		{ProdutoID: a.ID, Quantidade: -2, Motivo: domain.AjusteQuebra, Observacao: "caiu da prateleira"},
+		{ProdutoID: b.ID, Quantidade: -1, Motivo: domain.AjusteQuebra},
+		{ProdutoID: a.ID, Quantidade: -1, Motivo: domain.AjusteFurto},
+		{ProdutoID: b.ID, Quantidade: 2, Motivo: domain.AjusteCorrecao},
+	}
+	for _, ajuste := range ajustes {
+		if _, err := mr.AjustarEstoque(ajuste, "gerente"); err != nil {
+			t.Fatalf("AjustarEstoque(%+v): %v", ajuste, err)
+		}
+	}
+	var semEstoque *EstoqueInsuficienteError
+	_, err := mr.AjustarEstoque(domain.AjusteEstoque{ProdutoID: b.ID, Quantidade: -9, Motivo: domain.AjustePerda}, "gerente")
+	if !errors.As(err, &semEstoque) {
+		t.Errorf("ajuste acima do estoque: %v", err)
+	}
+	if got := estoque(t, db, a.ID); got != 7 {
+		t.Errorf("estoque de A = %d, esperado 7", got)
+	}
+
+	movs, err := mr.BuscarMovimentos(a.ID, map[string]any{"tipo": string(domain.MovimentoAjuste)}, 10, 0)
+	if err != nil || len(movs) != 2 || movs[1].MotivoAjuste != domain.AjusteQuebra ||
+		movs[1].Motivo != "caiu da prateleira" || movs[1].Usuario != "gerente" {
+		t.Errorf("movimentos de ajuste = %+v, err %v", movs, err)
+	}
+
+	hoje := time.Now().Format("2006-01-02")
+	relatorio, err := mr.RelatorioAjustes(map[string]any{"data_inicio": hoje, "data_fim": hoje})
+	if err != nil {
+		t.Fatalf("RelatorioAjustes: %v", err)
+	}
+	if len(relatorio.Motivos) != 3 {
+		t.Fatalf("motivos = %+v", relatorio.Motivos)
+	}
+	quebra := relatorio.Motivos[2] // CORRECAO, FURTO, QUEBRA
+	if quebra.Motivo != domain.AjusteQuebra || quebra.Ajustes != 2 || quebra.Quantidade != -3 ||
+		!quebra.Valor.Equal(decimal(t, "-9.00")) {
+		t.Errorf("resumo de quebra = %+v", quebra)
+	}
+	if relatorio.QuantidadeQuebra != -4 || !relatorio.ValorQuebra.Equal(decimal(t, "-11.50")) {
+		t.Errorf("quebra total = %d / %s", relatorio.QuantidadeQuebra, relatorio.ValorQuebra)
+	}
+
+	ontem := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if relatorio, err = mr.RelatorioAjustes(map[string]any{"data_fim": ontem}); err != nil || len(relatorio.Motivos) != 0 {
+		t.Errorf("ajustes até ontem = %+v, err %v", relatorio, err)
+	}
+}
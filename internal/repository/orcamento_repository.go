@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// OrcamentoRepository grava orçamentos e os converte em vendas
+type OrcamentoRepository struct {
+	db *sql.DB
+}
+
+// NewOrcamentoRepository cria uma instância de OrcamentoRepository
+func NewOrcamentoRepository(db *sql.DB) *OrcamentoRepository {
+	return &OrcamentoRepository{db: db}
+}
+
+// SalvarOrcamento precifica o orçamento com os preços e promoções atuais e os
+// descontos, como uma venda, e o grava aberto. O estoque não é conferido nem baixado.
+func (orc *OrcamentoRepository) SalvarOrcamento(o *domain.Orcamento) error {
+	tx, err := orc.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := buscarClienteVenda(tx, o.ClientID); err != nil {
+		return err
+	}
+	if o.Data.IsZero() {
+		o.Data = time.Now()
+	}
+	precificador, err := novoPrecificador(tx, o.Items, o.Data)
+	if err != nil {
+		return err
+	}
+	proposta := domain.Sale{Items: o.Items, Desconto: o.Desconto, Total: o.Total, Perfil: o.Perfil}
+	if err := precificador.Precificar(&proposta); err != nil {
+		return err
+	}
+	if err := conferirDesconto(tx, &proposta); err != nil {
+		return err
+	}
+	o.Items, o.Bruto, o.ValorDesconto, o.Total = proposta.Items, proposta.Bruto, proposta.ValorDesconto, proposta.Total
+	o.Status = domain.OrcamentoAberto
+	o.AprovadoEm, o.VendaID = nil, nil
+
+	descontoTipo, descontoValor := colunasDesconto(o.Desconto)
+	res, err := tx.Exec(
+		`INSERT INTO orcamentos (cliente_id, data, valido_ate, status, total, usuario,
+		                         bruto, valor_desconto, desconto_tipo, desconto_valor)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		o.ClientID, o.Data, o.ValidoAte, o.Status, o.Total.String(), nullString(o.Usuario),
+		o.Bruto.String(), o.ValorDesconto.String(), descontoTipo, descontoValor)
+	if err != nil {
+		return err
+	}
+	o.ID, _ = res.LastInsertId()
+
+	for _, item := range o.Items {
+		descontoTipo, descontoValor := colunasDesconto(item.Desconto)
+		promocaoID, valorPromocao := colunasPromocao(item.Promocao)
+		if _, err := tx.Exec(
+			`INSERT INTO orcamentos_produtos
+			   (orcamento_id, produto_id, quantidade, preco_tabela, preco_unitario, total, autorizado_por,
+			    bruto, valor_desconto, desconto_tipo, desconto_valor, promocao_id, valor_promocao)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			o.ID, item.ProductID, item.Quantity, item.ListPrice.String(), item.UnitPrice.String(),
+			item.Total.String(), nullString(item.AuthorizedBy),
+			item.Bruto.String(), item.ValorDesconto.String(), descontoTipo, descontoValor,
+			promocaoID, valorPromocao); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// BuscarOrcamentos lista orçamentos (com itens) filtrando por cliente_id e
+// status. Os abertos ou aprovados com validade vencida saem como expirados,
+// sem alterar o banco.
+func (orc *OrcamentoRepository) BuscarOrcamentos(filters map[string]any, limit, offset int) ([]domain.Orcamento, error) {
+	hoje := time.Now()
+	query := "SELECT " + orcamentoColunas + " FROM orcamentos"
+	var clauses []string
+	var args []any
+	if v, ok := filters["cliente_id"]; ok {
+		clauses = append(clauses, "cliente_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["status"]; ok {
+		clauses = append(clauses, "CASE WHEN status IN (?, ?) AND valido_ate < date(?) THEN ? ELSE status END = ?")
+		args = append(args, domain.OrcamentoAberto, domain.OrcamentoAprovado, hoje.Format("2006-01-02"),
+			domain.OrcamentoExpirado, v)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY data DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := orc.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orcamentos := []domain.Orcamento{}
+	for rows.Next() {
+		o, err := scanOrcamento(rows)
+		if err != nil {
+			return nil, err
+		}
+		o.Expirar(hoje)
+		orcamentos = append(orcamentos, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range orcamentos {
+		if orcamentos[i].Items, err = buscarItensOrcamento(orc.db, orcamentos[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return orcamentos, nil
+}
+
+// BuscarOrcamentoPorId retorna o orçamento com cliente e itens ou sql.ErrNoRows;
+// vencido, sai como expirado
+func (orc *OrcamentoRepository) BuscarOrcamentoPorId(id int64) (domain.Orcamento, error) {
+	o, err := buscarOrcamento(orc.db, id)
+	if err != nil {
+		return domain.Orcamento{}, err
+	}
+	o.Expirar(time.Now())
+	cliente, err := scanCliente(orc.db.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", o.ClientID))
+	if err != nil {
+		return domain.Orcamento{}, err
+	}
+	o.Cliente = &cliente
+	return o, nil
+}
+
+// AprovarOrcamento registra que o cliente aceitou o orçamento
+func (orc *OrcamentoRepository) AprovarOrcamento(id int64) (domain.Orcamento, error) {
+	o, err := buscarOrcamento(orc.db, id)
+	if err != nil {
+		return domain.Orcamento{}, err
+	}
+	if err := o.Aprovar(time.Now()); err != nil {
+		return domain.Orcamento{}, err
+	}
+	res, err := orc.db.Exec("UPDATE orcamentos SET status = ?, aprovado_em = ? WHERE id = ? AND status = ?",
+		o.Status, o.AprovadoEm, id, domain.OrcamentoAberto)
+	if err != nil {
+		return domain.Orcamento{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Orcamento{}, fmt.Errorf("aprovar orçamento %d: %w", id, domain.ErrSituacaoOrcamento)
+	}
+	return o, nil
+}
+
+// ConverterOrcamento grava, numa única transação, a venda do orçamento
+// aprovado pelo mesmo caminho de SalvarVenda (estoque e preços conferidos de
+// novo) e marca o orçamento como convertido. complemento traz os pagamentos
+// no ato, quem liberou o crédito e o depósito de onde sai a mercadoria; com
+// precosAtuais a venda aceita preços que mudaram desde o orçamento. Preços
+// autorizados e descontos seguem a autorização gravada no orçamento, e não o
+// perfil de quem o converte.
+func (orc *OrcamentoRepository) ConverterOrcamento(id int64, precosAtuais bool, complemento domain.Sale, usuario string) (domain.Sale, error) {
+	tx, err := orc.db.Begin()
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	defer tx.Rollback()
+
+	o, err := buscarOrcamento(tx, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	venda, err := o.Venda(time.Now(), precosAtuais)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	venda.Pagamentos = complemento.Pagamentos
+	venda.CreditoLiberadoPor = complemento.CreditoLiberadoPor
+	venda.DepositoID = complemento.DepositoID
+	venda.Perfil = complemento.Perfil
+	if err := salvarVenda(tx, &venda, usuario); err != nil {
+		return domain.Sale{}, err
+	}
+
+	res, err := tx.Exec("UPDATE orcamentos SET status = ?, venda_id = ? WHERE id = ? AND status = ?",
+		domain.OrcamentoConvertido, venda.ID, id, domain.OrcamentoAprovado)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Sale{}, fmt.Errorf("converter orçamento %d: %w", id, domain.ErrSituacaoOrcamento)
+	}
+	return venda, tx.Commit()
+}
+
+// ExpirarOrcamentos grava como expirados os orçamentos abertos ou aprovados
+// cuja validade terminou antes de hoje e devolve quantos foram alterados. As
+// consultas já os mostram expirados; o servidor chama esta ao iniciar para
+// que o banco acompanhe.
+func (orc *OrcamentoRepository) ExpirarOrcamentos(hoje time.Time) (int64, error) {
+	res, err := orc.db.Exec(
+		"UPDATE orcamentos SET status = ? WHERE status IN (?, ?) AND valido_ate < date(?)",
+		domain.OrcamentoExpirado, domain.OrcamentoAberto, domain.OrcamentoAprovado, hoje.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func buscarOrcamento(q queryer, id int64) (domain.Orcamento, error) {
+	o, err := scanOrcamento(q.QueryRow("SELECT "+orcamentoColunas+" FROM orcamentos WHERE id = ?", id))
+	if err != nil {
+		return domain.Orcamento{}, err
+	}
+	o.Items, err = buscarItensOrcamento(q, id)
+	return o, err
+}
+
+func buscarItensOrcamento(q queryer, orcamentoID int64) ([]domain.SaleItem, error) {
+	rows, err := q.Query("SELECT "+orcamentoItemColunas+" FROM orcamentos_produtos WHERE orcamento_id = ? ORDER BY produto_id", orcamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.SaleItem{}
+	for rows.Next() {
+		item, err := scanVendaItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		// a primeira coluna é o orçamento, não uma venda
+		item.SaleID = 0
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
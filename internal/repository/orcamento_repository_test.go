@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestOrcamentoRepository(t *testing.T) {
+	db := novoBanco(t)
+	orc := NewOrcamentoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 5, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	o := domain.Orcamento{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	if err := o.Validate(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := orc.SalvarOrcamento(&o); err != nil {
+		t.Fatalf("SalvarOrcamento: %v", err)
+	}
+	if o.Status != domain.OrcamentoAberto || !o.Total.Equal(decimal(t, "30")) {
+		t.Errorf("orçamento salvo = %+v", o)
+	}
+	if got := estoque(t, db, p.ID); got != 5 {
+		t.Errorf("orçamento não deveria mexer no estoque: %d", got)
+	}
+
+	if _, err := orc.ConverterOrcamento(o.ID, false, domain.Sale{}, "teste"); !errors.Is(err, domain.ErrSituacaoOrcamento) {
+		t.Errorf("converter orçamento aberto: err = %v", err)
+	}
+	if _, err := orc.AprovarOrcamento(o.ID); err != nil {
+		t.Fatalf("AprovarOrcamento: %v", err)
+	}
+
+	// o preço mudou depois do orçamento
+	if _, err := db.Exec("UPDATE produtos SET preco_unitario = '11.00' WHERE id = ?", p.ID); err != nil {
+		t.Fatal(err)
+	}
+	var divergente *domain.TotalDivergenteError
+	if _, err := orc.ConverterOrcamento(o.ID, false, domain.Sale{}, "teste"); !errors.As(err, &divergente) {
+		t.Errorf("conversão com preço alterado: err = %v", err)
+	}
+	venda, err := orc.ConverterOrcamento(o.ID, true, domain.Sale{
+		Pagamentos: []domain.Pagamento{{Valor: decimal(t, "33.00"), Forma: domain.FormaPix}},
+	}, "teste")
+	if err != nil {
+		t.Fatalf("ConverterOrcamento: %v", err)
+	}
+	if !venda.Total.Equal(decimal(t, "33")) || venda.PaymentStatus != domain.PaymentStatusPaid {
+		t.Errorf("venda convertida = %+v", venda)
+	}
+	if got := estoque(t, db, p.ID); got != 2 {
+		t.Errorf("estoque após conversão = %d, esperado 2", got)
+	}
+	got, err := orc.BuscarOrcamentoPorId(o.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.OrcamentoConvertido || got.VendaID == nil || *got.VendaID != venda.ID || got.Cliente == nil {
+		t.Errorf("orçamento convertido = %+v", got)
+	}
+	if _, err := orc.ConverterOrcamento(o.ID, true, domain.Sale{}, "teste"); !errors.Is(err, domain.ErrSituacaoOrcamento) {
+		t.Errorf("converter duas vezes: err = %v", err)
+	}
+
+	// conversão sem estoque suficiente não grava nada
+	semEstoque := domain.Orcamento{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	if err := semEstoque.Validate(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := orc.SalvarOrcamento(&semEstoque); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orc.AprovarOrcamento(semEstoque.ID); err != nil {
+		t.Fatal(err)
+	}
+	var falta *EstoqueInsuficienteError
+	if _, err := orc.ConverterOrcamento(semEstoque.ID, false, domain.Sale{}, "teste"); !errors.As(err, &falta) {
+		t.Errorf("conversão sem estoque: err = %v", err)
+	}
+
+	// validade vencida expira o orçamento nas consultas, sem gravar no banco
+	if _, err := db.Exec("UPDATE orcamentos SET valido_ate = date('now', '-1 day') WHERE id = ?", semEstoque.ID); err != nil {
+		t.Fatal(err)
+	}
+	lista, err := orc.BuscarOrcamentos(map[string]any{"status": string(domain.OrcamentoExpirado)}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lista) != 1 || lista[0].ID != semEstoque.ID || lista[0].Status != domain.OrcamentoExpirado || len(lista[0].Items) != 1 {
+		t.Errorf("orçamentos expirados = %+v", lista)
+	}
+	if lista, err = orc.BuscarOrcamentos(map[string]any{"status": string(domain.OrcamentoAprovado)}, 10, 0); err != nil || len(lista) != 0 {
+		t.Errorf("orçamentos aprovados = %+v, err %v", lista, err)
+	}
+	if o, err := orc.BuscarOrcamentoPorId(semEstoque.ID); err != nil || o.Status != domain.OrcamentoExpirado {
+		t.Errorf("BuscarOrcamentoPorId = %s, err %v", o.Status, err)
+	}
+	var gravado domain.StatusOrcamento
+	if err := db.QueryRow("SELECT status FROM orcamentos WHERE id = ?", semEstoque.ID).Scan(&gravado); err != nil || gravado != domain.OrcamentoAprovado {
+		t.Errorf("status gravado = %s, err %v; consultas não devem alterar o banco", gravado, err)
+	}
+	if n, err := orc.ExpirarOrcamentos(time.Now()); err != nil || n != 1 {
+		t.Errorf("ExpirarOrcamentos = %d, err %v", n, err)
+	}
+	if _, err := orc.ConverterOrcamento(semEstoque.ID, true, domain.Sale{}, "teste"); !errors.Is(err, domain.ErrOrcamentoExpirado) {
+		t.Errorf("converter expirado: err = %v", err)
+	}
+}
+
+// Preço e desconto autorizados pelo gerente no orçamento valem quando um
+// vendedor, que não poderia concedê-los, converte o orçamento em venda
+func TestConverterOrcamentoAutorizadoPeloGerente(t *testing.T) {
+	db := novoBanco(t)
+	orc := NewOrcamentoRepository(db)
+	dr := NewDescontoRepository(db)
+	for perfil, maximo := range map[string]string{domain.PerfilGerente: "20", "vendedor": "5"} {
+		if err := dr.DefinirLimite(domain.LimiteDesconto{Perfil: perfil, PercentualMaximo: decimal(t, maximo)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 5, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	preco := decimal(t, "8.00")
+	itens := func() []domain.SaleItem {
+		return []domain.SaleItem{{ProductID: p.ID, Quantity: 2, PriceOverride: &preco, AuthorizedBy: "Ana"}}
+	}
+	desconto := &domain.Desconto{Tipo: domain.DescontoPercentual, Valor: decimal(t, "15")}
+	o := domain.Orcamento{ClientID: c.ID, Items: itens(), Desconto: desconto, Usuario: "Ana", Perfil: domain.PerfilGerente}
+	if err := o.Validate(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := orc.SalvarOrcamento(&o); err != nil {
+		t.Fatalf("SalvarOrcamento: %v", err)
+	}
+	if _, err := orc.AprovarOrcamento(o.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// a mesma venda registrada direto pelo vendedor é recusada
+	direta := domain.Sale{ClientID: c.ID, Items: itens(), Desconto: desconto, Perfil: "vendedor"}
+	if err := NewVendasRepository(db).SalvarVenda(&direta, "Bia"); !errors.Is(err, domain.ErrPrecoNaoAutorizado) {
+		t.Fatalf("venda direta do vendedor: err = %v, esperado ErrPrecoNaoAutorizado", err)
+	}
+
+	venda, err := orc.ConverterOrcamento(o.ID, false, domain.Sale{Perfil: "vendedor"}, "Bia")
+	if err != nil {
+		t.Fatalf("ConverterOrcamento pelo vendedor: %v", err)
+	}
+	if !venda.Total.Equal(decimal(t, "13.60")) || !venda.Items[0].UnitPrice.Equal(preco) || venda.Items[0].AuthorizedBy != "Ana" {
+		t.Errorf("venda convertida: total %s item %+v", venda.Total, venda.Items[0])
+	}
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// PagamentoRepository registra os recebimentos e o plano de parcelas das vendas
+type PagamentoRepository struct {
+	db *sql.DB
+}
+
+// NewPagamentoRepository cria uma instância de PagamentoRepository
+func NewPagamentoRepository(db *sql.DB) *PagamentoRepository {
+	return &PagamentoRepository{db: db}
+}
+
+// RegistrarPagamento grava o pagamento e recalcula status_pagamento e
+// data_pagamento da venda. Pagamentos acima do saldo em aberto são recusados.
+func (pr *PagamentoRepository) RegistrarPagamento(p *domain.Pagamento) error {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	venda, err := scanVenda(tx.QueryRow("SELECT "+vendaColunas+" FROM vendas v WHERE v.id = ?", p.VendaID))
+	if err != nil {
+		return err
+	}
+	if venda.Status == domain.StatusVendaCancelada {
+		return ErrVendaCancelada
+	}
+	if err := registrarPagamento(tx, &venda, p); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DefinirParcelas substitui o plano de parcelas da venda; a soma das parcelas
+// precisa fechar com o total
+func (pr *PagamentoRepository) DefinirParcelas(vendaID int64, parcelas []domain.Parcela) error {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	venda, err := scanVenda(tx.QueryRow("SELECT "+vendaColunas+" FROM vendas v WHERE v.id = ?", vendaID))
+	if err != nil {
+		return err
+	}
+	if venda.Status == domain.StatusVendaCancelada {
+		return ErrVendaCancelada
+	}
+	if err := domain.ValidarParcelas(parcelas, venda.Total); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM parcelas WHERE venda_id = ?", vendaID); err != nil {
+		return err
+	}
+	for i := range parcelas {
+		p := &parcelas[i]
+		p.VendaID = vendaID
+		p.Numero = i + 1
+		res, err := tx.Exec(
+			`INSERT INTO parcelas (venda_id, numero, valor, vencimento) VALUES (?, ?, ?, ?)`,
+			vendaID, p.Numero, p.Valor.String(), p.Vencimento)
+		if err != nil {
+			return err
+		}
+		p.ID, _ = res.LastInsertId()
+	}
+	return tx.Commit()
+}
+
+// BuscarResumo devolve total, valor pago, saldo, parcelas e histórico de
+// pagamentos da venda ou sql.ErrNoRows
+func (pr *PagamentoRepository) BuscarResumo(vendaID int64) (domain.ResumoPagamentos, error) {
+	venda, err := scanVenda(pr.db.QueryRow("SELECT "+vendaColunas+" FROM vendas v WHERE v.id = ?", vendaID))
+	if err != nil {
+		return domain.ResumoPagamentos{}, err
+	}
+	resumo := domain.ResumoPagamentos{
+		VendaID:       venda.ID,
+		Total:         venda.Total,
+		Status:        venda.PaymentStatus,
+		DataPagamento: venda.PaymentDate,
+	}
+	if resumo.Pagamentos, err = buscarPagamentos(pr.db, vendaID); err != nil {
+		return domain.ResumoPagamentos{}, err
+	}
+	if resumo.Parcelas, err = buscarParcelas(pr.db, vendaID); err != nil {
+		return domain.ResumoPagamentos{}, err
+	}
+
+	resumo.Pago = domain.DecimalFromInt(0)
+	for _, p := range resumo.Pagamentos {
+		resumo.Pago = resumo.Pago.Add(p.Valor)
+	}
+	resumo.Saldo = resumo.Total.Sub(resumo.Pago)
+	domain.AlocarPagamentos(resumo.Parcelas, resumo.Pago, time.Now())
+	return resumo, nil
+}
+
+// registrarPagamento confere o saldo, grava o pagamento e atualiza a situação
+// de pagamento da venda dentro da transação recebida. Pagamento com crédito na
+// loja consome o crédito do cliente.
+func registrarPagamento(tx *sql.Tx, venda *domain.Sale, p *domain.Pagamento) error {
+	pago, err := somarPagamentos(tx, venda.ID)
+	if err != nil {
+		return err
+	}
+	if pago.Add(p.Valor).Compare(venda.Total) > 0 {
+		return domain.ErrPagamentoExcedeSaldo
+	}
+
+	if p.Forma == domain.FormaCreditoLoja {
+		if err := lancarCredito(tx, venda.ClientID, domain.DecimalFromInt(0).Sub(p.Valor),
+			documento("venda", venda.ID), p.Usuario); err != nil {
+			return err
+		}
+	}
+
+	p.VendaID = venda.ID
+	if p.Data.IsZero() {
+		p.Data = time.Now()
+	}
+	p.CriadoEm = time.Now()
+	res, err := tx.Exec(
+		`INSERT INTO pagamentos (venda_id, valor, data_pagamento, forma, usuario, criado_em)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		p.VendaID, p.Valor.String(), p.Data, p.Forma, nullString(p.Usuario), p.CriadoEm)
+	if err != nil {
+		return err
+	}
+	p.ID, _ = res.LastInsertId()
+	return atualizarSituacaoPagamento(tx, venda)
+}
+
+// atualizarSituacaoPagamento deriva status_pagamento da soma dos pagamentos;
+// data_pagamento é a data do último pagamento quando a venda fica quitada
+func atualizarSituacaoPagamento(tx *sql.Tx, venda *domain.Sale) error {
+	pago, err := somarPagamentos(tx, venda.ID)
+	if err != nil {
+		return err
+	}
+	venda.PaymentStatus = domain.SituacaoPagamento(venda.Total, pago)
+	venda.PaymentDate = nil
+	if venda.PaymentStatus == domain.PaymentStatusPaid {
+		var ultimo time.Time
+		if err := tx.QueryRow(
+			"SELECT data_pagamento FROM pagamentos WHERE venda_id = ? ORDER BY data_pagamento DESC, id DESC LIMIT 1",
+			venda.ID).Scan(&ultimo); err != nil {
+			return err
+		}
+		venda.PaymentDate = &ultimo
+	}
+	_, err = tx.Exec(`UPDATE vendas SET status_pagamento = ?, data_pagamento = ? WHERE id = ?`,
+		venda.PaymentStatus, venda.PaymentDate, venda.ID)
+	return err
+}
+
+// somarPagamentos soma o que já foi recebido da venda, arredondado em centavos
+func somarPagamentos(q queryer, vendaID int64) (domain.Decimal, error) {
+	var pago domain.Decimal
+	err := q.QueryRow("SELECT ROUND(COALESCE(SUM(valor), 0), 2) FROM pagamentos WHERE venda_id = ?", vendaID).Scan(&pago)
+	return pago, err
+}
+
+func buscarPagamentos(q queryer, vendaID int64) ([]domain.Pagamento, error) {
+	rows, err := q.Query("SELECT "+pagamentoColunas+" FROM pagamentos WHERE venda_id = ? ORDER BY data_pagamento, id", vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pagamentos := []domain.Pagamento{}
+	for rows.Next() {
+		p, err := scanPagamento(rows)
+		if err != nil {
+			return nil, err
+		}
+		pagamentos = append(pagamentos, p)
+	}
+	return pagamentos, rows.Err()
+}
+
+func buscarParcelas(q queryer, vendaID int64) ([]domain.Parcela, error) {
+	rows, err := q.Query("SELECT "+parcelaColunas+" FROM parcelas WHERE venda_id = ? ORDER BY vencimento, numero", vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parcelas := []domain.Parcela{}
+	for rows.Next() {
+		p, err := scanParcela(rows)
+		if err != nil {
+			return nil, err
+		}
+		parcelas = append(parcelas, p)
+	}
+	return parcelas, rows.Err()
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestPagamentoRepository(t *testing.T) {
+	db := novoBanco(t)
+	pgr := NewPagamentoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "33.40")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	hoje := time.Now()
+	parcelas, err := domain.DividirParcelas(venda.Total, 3, hoje.AddDate(0, 0, -10), 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pgr.DefinirParcelas(venda.ID, parcelas); err != nil {
+		t.Fatalf("DefinirParcelas: %v", err)
+	}
+	if err := pgr.DefinirParcelas(venda.ID, parcelas[:2]); !errors.Is(err, domain.ErrParcelasInvalidas) {
+		t.Errorf("parcelas que não fecham o total: err = %v", err)
+	}
+
+	pagamento := domain.Pagamento{VendaID: venda.ID, Valor: decimal(t, "50.00"), Forma: domain.FormaPix, Usuario: "caixa",
+		Data: hoje.Add(-time.Hour)}
+	if err := pgr.RegistrarPagamento(&pagamento); err != nil {
+		t.Fatalf("RegistrarPagamento: %v", err)
+	}
+
+	resumo, err := pgr.BuscarResumo(venda.ID)
+	if err != nil {
+		t.Fatalf("BuscarResumo: %v", err)
+	}
+	if resumo.Status != domain.PaymentStatusPartial || resumo.DataPagamento != nil ||
+		!resumo.Pago.Equal(decimal(t, "50")) || !resumo.Saldo.Equal(decimal(t, "50.20")) {
+		t.Errorf("resumo após pagamento parcial = %+v", resumo)
+	}
+	if len(resumo.Pagamentos) != 1 || resumo.Pagamentos[0].Forma != domain.FormaPix || resumo.Pagamentos[0].Usuario != "caixa" {
+		t.Errorf("pagamentos = %+v", resumo.Pagamentos)
+	}
+	if len(resumo.Parcelas) != 3 || resumo.Parcelas[0].Situacao != domain.ParcelaPaga ||
+		resumo.Parcelas[1].Situacao != domain.ParcelaParcial || resumo.Parcelas[2].Situacao != domain.ParcelaAberta {
+		t.Errorf("parcelas = %+v", resumo.Parcelas)
+	}
+
+	excesso := domain.Pagamento{VendaID: venda.ID, Valor: decimal(t, "50.21"), Forma: domain.FormaDinheiro}
+	if err := pgr.RegistrarPagamento(&excesso); !errors.Is(err, domain.ErrPagamentoExcedeSaldo) {
+		t.Errorf("pagamento acima do saldo: err = %v", err)
+	}
+
+	quitacao := domain.Pagamento{VendaID: venda.ID, Valor: decimal(t, "50.20"), Forma: domain.FormaDinheiro, Data: hoje}
+	if err := pgr.RegistrarPagamento(&quitacao); err != nil {
+		t.Fatalf("RegistrarPagamento: %v", err)
+	}
+	got, err := NewVendasRepository(db).BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PaymentStatus != domain.PaymentStatusPaid || got.PaymentDate == nil || !got.PaymentDate.Equal(hoje) ||
+		len(got.Pagamentos) != 2 || len(got.Parcelas) != 3 {
+		t.Errorf("venda quitada = %+v", got)
+	}
+}
+
+func TestSalvarVendaComPagamento(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "5.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{
+		ClientID:      c.ID,
+		PaymentStatus: domain.PaymentStatusPaid,
+		Items:         []domain.SaleItem{{ProductID: p.ID, Quantity: 2}},
+		Pagamentos:    []domain.Pagamento{{Valor: decimal(t, "4"), Forma: domain.FormaDinheiro}},
+	}
+	if err := NewVendasRepository(db).SalvarVenda(&venda, "caixa"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	if venda.PaymentStatus != domain.PaymentStatusPartial {
+		t.Errorf("status = %s, esperado PARCIAL derivado dos pagamentos", venda.PaymentStatus)
+	}
+
+	acima := domain.Sale{
+		ClientID:   c.ID,
+		Items:      []domain.SaleItem{{ProductID: p.ID, Quantity: 1}},
+		Pagamentos: []domain.Pagamento{{Valor: decimal(t, "6"), Forma: domain.FormaDinheiro}},
+	}
+	if err := NewVendasRepository(db).SalvarVenda(&acima, "caixa"); !errors.Is(err, domain.ErrPagamentoExcedeSaldo) {
+		t.Errorf("pagamento acima do total: err = %v", err)
+	}
+	if est := estoque(t, db, p.ID); est != 8 {
+		t.Errorf("estoque = %d, venda recusada não deveria baixar estoque", est)
+	}
+}
+
+func TestRegistrarPagamentoVendaCancelada(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "5.00")
+	c := criarCliente(t, db, "Cliente")
+	vr := NewVendasRepository(db)
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 1}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vr.CancelarVenda(venda.ID, "", "teste"); err != nil {
+		t.Fatal(err)
+	}
+	pagamento := domain.Pagamento{VendaID: venda.ID, Valor: decimal(t, "5"), Forma: domain.FormaPix}
+	if err := NewPagamentoRepository(db).RegistrarPagamento(&pagamento); !errors.Is(err, ErrVendaCancelada) {
+		t.Errorf("err = %v, esperado ErrVendaCancelada", err)
+	}
+}
@@ -5,11 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/apd/v3"
 	models "github.com/julio-pupim/lojaestoque/internal/domain"
 )
 
+// ErrProdutoEmUso indica produto que já tem vendas, compras ou movimentos de estoque
+var ErrProdutoEmUso = errors.New("produto possui histórico e não pode ser removido")
+
+// ErrCodigoBarrasEmUso indica código de barras já cadastrado em outro produto
+var ErrCodigoBarrasEmUso = errors.New("código de barras já cadastrado em outro produto")
+
+// ErrProdutoComCodigoBarras indica geração de código interno para produto que já tem código
+var ErrProdutoComCodigoBarras = errors.New("produto já tem código de barras")
+
+// ErrEstoqueSomenteAjuste indica tentativa de mudar a quantidade em estoque
+// editando o produto, sem um ajuste com motivo
+var ErrEstoqueSomenteAjuste = errors.New("quantidade em estoque só muda por ajuste com motivo (POST /produtos/{id}/ajustes)")
+
+// ErrCustoPelasCompras indica custo informado à mão em produto que já tem
+// estoque ou compras recebidas, cujo custo médio vem das compras
+var ErrCustoPelasCompras = errors.New("custo só pode ser informado em produto sem estoque e sem compras recebidas; depois segue o custo médio das compras")
+
 // ProdutoRepository encapsula acessos ao banco para produtos
 type ProdutoRepository struct {
 	db *sql.DB
@@ -20,84 +38,152 @@ func NewProdutoRepository(db *sql.DB) *ProdutoRepository {
 	return &ProdutoRepository{db: db}
 }
 
-// Save insere ou atualiza (soma estoque) de um produto
-func (r *ProdutoRepository) Save(p *models.Produto) error {
+// Save insere um produto ou atualiza o já cadastrado com o mesmo par
+// fornecedor/código. Na inclusão, a quantidade informada entra no razão do
+// depósito principal como saldo inicial; no produto existente, a quantidade
+// precisa ser zero, senão devolve ErrEstoqueSomenteAjuste, e a entrada deve ir
+// por AjustarEstoque ou pelo recebimento de uma compra. O custo do produto
+// existente também não muda por aqui: um custo diferente do atual devolve
+// ErrCustoPelasCompras.
+func (r *ProdutoRepository) Save(p *models.Produto, usuario string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// 1) Busca pelo par (fornecedor_id, codigo_fornecedor)
-	var id, qtdAtual int64
-	row := r.db.QueryRow(
-		`SELECT id, quantidade_estoque
+	var (
+		id         int64
+		qtdAtual   int64
+		custoAtual models.Decimal
+	)
+	row := tx.QueryRow(
+		`SELECT id, qtd_estoque, custo
            FROM produtos
           WHERE fornecedor_id = ? AND codigo_fornecedor = ?`,
 		p.Fornecedor.Id, p.CodigoFornecedor,
 	)
-	err := row.Scan(&id, &qtdAtual)
+	err = row.Scan(&id, &qtdAtual, &custoAtual)
 
+	mov := &models.MovimentoEstoque{Quantidade: p.QuantidadeEstoque, Usuario: usuario}
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
-		// 2a) Não existe → inserção
-		_, err = r.db.Exec(
+		// 2a) Não existe → inserção com estoque zerado; o saldo vem do razão
+		if p.Custo.Decimal == nil {
+			p.Custo = models.DecimalFromInt(0)
+		}
+		var res sql.Result
+		res, err = tx.Exec(
 			`INSERT INTO produtos
-               (nome, fornecedor_id, codigo_fornecedor,
-                quantidade_estoque, preco)
-             VALUES (?, ?, ?, ?, ?)`,
+               (nome, fornecedor_id, codigo_fornecedor, codigo_barras,
+                qtd_estoque, preco_unitario, qtd_minima, qtd_maxima, custo)
+             VALUES (?, ?, ?, ?, 0, ?, ?, ?, ?)`,
 			p.Nome,
 			p.Fornecedor.Id,
 			p.CodigoFornecedor,
-			p.QuantidadeEstoque,
+			nullString(p.CodigoBarras),
 			p.Preco.String(),
+			nullInt64(p.QuantidadeMinima),
+			nullInt64(p.QuantidadeMaxima),
+			p.Custo.String(),
 		)
+		if err != nil {
+			return traduzErroProduto(err)
+		}
+		id, _ = res.LastInsertId()
+		mov.Tipo = models.MovimentoSaldoInicial
+		mov.Motivo = "cadastro do produto"
 
 	case err != nil:
 		// 2b) Erro inesperado no SELECT
 		return fmt.Errorf("erro ao buscar produto existente: %w", err)
 
 	default:
-		// 2c) Já existe → soma estoque e atualiza preço
-		novaQtde := qtdAtual + p.QuantidadeEstoque
-		_, err = r.db.Exec(
+		// 2c) Já existe → atualiza o preço; código de barras informado
+		// substitui o atual. Estoque e custo não mudam por aqui.
+		if p.QuantidadeEstoque != 0 {
+			return ErrEstoqueSomenteAjuste
+		}
+		if p.Custo.Decimal != nil && !p.Custo.Equal(custoAtual) {
+			return ErrCustoPelasCompras
+		}
+		p.Custo = custoAtual
+		_, err = tx.Exec(
 			`UPDATE produtos
-                SET quantidade_estoque = ?, preco = ?
+                SET preco_unitario = ?, codigo_barras = COALESCE(?, codigo_barras)
               WHERE id = ?`,
-			novaQtde,
 			p.Preco.String(),
+			nullString(p.CodigoBarras),
 			id,
 		)
+		if err != nil {
+			return traduzErroProduto(err)
+		}
+		if err := tx.QueryRow("SELECT COALESCE(codigo_barras, '') FROM produtos WHERE id = ?", id).
+			Scan(&p.CodigoBarras); err != nil {
+			return err
+		}
+		p.QuantidadeEstoque = qtdAtual
 	}
 
-	return err
+	p.ID = id
+	p.CalcularMargens()
+	mov.ProdutoID = id
+	if mov.Quantidade != 0 {
+		if err := movimentarEstoque(tx, mov); err != nil {
+			return err
+		}
+		p.QuantidadeEstoque = mov.Saldo
+	}
+	return tx.Commit()
 }
 
 // Find consulta produtos com filtros opcionais
 func (r *ProdutoRepository) Find(filters map[string]any, limit, offset int) ([]models.Produto, error) {
-	base := `SELECT id, nome, fornecedor_id, codigo_fornecedor, quantidade_estoque, preco FROM produtos`
+	base := "SELECT " + produtoColunas + " FROM " + produtoFrom
 	var clauses []string
 	var args []any
 
+	if v, ok := filters["id"]; ok {
+		clauses = append(clauses, "p.id = ?")
+		args = append(args, v)
+	}
 	if v, ok := filters["nome"]; ok {
-		clauses = append(clauses, "nome LIKE ?")
+		clauses = append(clauses, "p.nome LIKE ?")
 		args = append(args, "%"+v.(string)+"%")
 	}
 	if v, ok := filters["fornecedor_id"]; ok {
-		clauses = append(clauses, "fornecedor_id = ?")
+		clauses = append(clauses, "p.fornecedor_id = ?")
 		args = append(args, v)
 	}
 	if v, ok := filters["codigo_fornecedor"]; ok {
-		clauses = append(clauses, "codigo_fornecedor = ?")
+		clauses = append(clauses, "p.codigo_fornecedor = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["codigo_barras"]; ok {
+		clauses = append(clauses, "p.codigo_barras = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["deposito_id"]; ok {
+		// só produtos com saldo no depósito
+		clauses = append(clauses, `EXISTS (SELECT 1 FROM estoque_depositos ed
+		                                    WHERE ed.produto_id = p.id AND ed.deposito_id = ? AND ed.quantidade > 0)`)
 		args = append(args, v)
 	}
 	if v, ok := filters["preco_min"]; ok {
-		clauses = append(clauses, "preco >= ?")
+		clauses = append(clauses, "p.preco_unitario >= ?")
 		args = append(args, v.(*apd.Decimal).String())
 	}
 	if v, ok := filters["preco_max"]; ok {
-		clauses = append(clauses, "preco <= ?")
+		clauses = append(clauses, "p.preco_unitario <= ?")
 		args = append(args, v.(*apd.Decimal).String())
 	}
 
 	if len(clauses) > 0 {
 		base += " WHERE " + strings.Join(clauses, " AND ")
 	}
-	base += " LIMIT ? OFFSET ?"
+	base += " ORDER BY p.nome LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(base, args...)
@@ -106,38 +192,24 @@ func (r *ProdutoRepository) Find(filters map[string]any, limit, offset int) ([]m
 	}
 	defer rows.Close()
 
-	var produtos []models.Produto
+	produtos := []models.Produto{}
 	for rows.Next() {
-		var (
-			id, fornecedorID, qtdEstoque, qtdMinima int64
-			nome, codForn, codBarras, precoStr      string
-		)
-		if err := rows.Scan(
-			&id, &nome, &fornecedorID, &codForn,
-			&codBarras, &qtdEstoque, &precoStr, &qtdMinima,
-		); err != nil {
-			return nil, err
-		}
-		forn := models.Fornecedor{Id: fornecedorID, Nome: nome}
-		p, err := models.NewProduto(
-			id,
-			nome,
-			forn,
-			codForn,
-			qtdEstoque,
-			precoStr,
-		)
+		p, err := scanProduto(rows)
 		if err != nil {
 			return nil, err
 		}
-		produtos = append(produtos, *p)
+		produtos = append(produtos, p)
 	}
 	return produtos, rows.Err()
 }
 
-// Delete remove um produto pelo ID
+// Delete remove um produto pelo ID; produtos com histórico (vendas, compras,
+// movimentos de estoque) não podem ser removidos
 func (r *ProdutoRepository) Delete(id int64) error {
 	res, err := r.db.Exec("DELETE FROM produtos WHERE id = ?", id)
+	if violaChaveEstrangeira(err) {
+		return ErrProdutoEmUso
+	}
 	if err != nil {
 		return err
 	}
@@ -148,17 +220,228 @@ func (r *ProdutoRepository) Delete(id int64) error {
 	return nil
 }
 
-// Update edita campos (exceto ID, histórico de estoque)
+// Update edita campos (exceto ID, histórico de estoque). A quantidade em
+// estoque não muda por aqui: uma quantidade diferente da atual devolve
+// ErrEstoqueSomenteAjuste, e a alteração deve ir por AjustarEstoque. Um custo
+// diferente do atual só é aceito em produto sem estoque e sem compras
+// recebidas; nos demais devolve ErrCustoPelasCompras.
 func (r *ProdutoRepository) Update(p *models.Produto) error {
 	// assume que Validate foi chamada antes
-	_, err := r.db.Exec(
-		`UPDATE produtos SET nome = ?, codigo_fornecedor = ?, quantidade_estoque = ?, preco = ?
-		 WHERE fornecedor_id = ? AND codigo_fornecedor = ?`,
-		p.Nome, p.CodigoFornecedor, p.QuantidadeEstoque, p.Preco, p.Fornecedor.Id, p.CodigoFornecedor,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		qtdAtual   int64
+		custoAtual models.Decimal
+	)
+	err = tx.QueryRow("SELECT qtd_estoque, custo FROM produtos WHERE id = ?", p.ID).Scan(&qtdAtual, &custoAtual)
+	if err != nil {
+		return err
+	}
+	if p.QuantidadeEstoque != qtdAtual {
+		return ErrEstoqueSomenteAjuste
+	}
+	if !p.Custo.Equal(custoAtual) {
+		var comprado bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM movimentacoes_estoque WHERE produto_id = ? AND tipo = ?)",
+			p.ID, models.MovimentoCompra).Scan(&comprado)
+		if err != nil {
+			return err
+		}
+		if qtdAtual != 0 || comprado {
+			return ErrCustoPelasCompras
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE produtos SET nome = ?, codigo_fornecedor = ?, codigo_barras = ?, preco_unitario = ?,
+		        qtd_minima = ?, qtd_maxima = ?, custo = ?
+		 WHERE id = ?`,
+		p.Nome, p.CodigoFornecedor, nullString(p.CodigoBarras), p.Preco.String(),
+		nullInt64(p.QuantidadeMinima), nullInt64(p.QuantidadeMaxima), p.Custo.String(), p.ID,
 	)
+	if err != nil {
+		return traduzErroProduto(err)
+	}
+
+	p.CalcularMargens()
+	return tx.Commit()
+}
+
+// BuscarPorCodigoBarras devolve o produto com o código de barras ou sql.ErrNoRows
+func (r *ProdutoRepository) BuscarPorCodigoBarras(codigo string) (models.Produto, error) {
+	return scanProduto(r.db.QueryRow(
+		"SELECT "+produtoColunas+" FROM "+produtoFrom+" WHERE p.codigo_barras = ?", codigo))
+}
+
+// BuscarPorIds devolve os produtos encontrados indexados pelo id; ids
+// inexistentes simplesmente não aparecem no mapa
+func (r *ProdutoRepository) BuscarPorIds(ids []int64) (map[int64]models.Produto, error) {
+	produtos := make(map[int64]models.Produto, len(ids))
+	if len(ids) == 0 {
+		return produtos, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := r.db.Query("SELECT "+produtoColunas+" FROM "+produtoFrom+
+		" WHERE p.id IN (?"+strings.Repeat(", ?", len(ids)-1)+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduto(rows)
+		if err != nil {
+			return nil, err
+		}
+		produtos[p.ID] = p
+	}
+	return produtos, rows.Err()
+}
+
+// GerarCodigoBarras atribui ao produto sem código o EAN-13 interno derivado
+// do seu id
+func (r *ProdutoRepository) GerarCodigoBarras(id int64) (models.Produto, error) {
+	var atual string
+	err := r.db.QueryRow("SELECT COALESCE(codigo_barras, '') FROM produtos WHERE id = ?", id).Scan(&atual)
+	if err != nil {
+		return models.Produto{}, err
+	}
+	if atual != "" {
+		return models.Produto{}, ErrProdutoComCodigoBarras
+	}
+	if err := atribuirCodigoInterno(r.db, id); err != nil {
+		return models.Produto{}, err
+	}
+	return scanProduto(r.db.QueryRow("SELECT "+produtoColunas+" FROM "+produtoFrom+" WHERE p.id = ?", id))
+}
+
+// GerarCodigosBarrasFaltantes atribui códigos internos, numa única transação,
+// a todos os produtos sem código de barras e devolve quantos foram gerados
+func (r *ProdutoRepository) GerarCodigosBarrasFaltantes() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM produtos WHERE codigo_barras IS NULL ORDER BY id")
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := atribuirCodigoInterno(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), tx.Commit()
+}
+
+func atribuirCodigoInterno(q queryer, id int64) error {
+	codigo, err := models.CodigoBarrasInterno(id)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("UPDATE produtos SET codigo_barras = ? WHERE id = ?", codigo, id)
+	if err != nil {
+		return fmt.Errorf("produto %d: %w", id, traduzErroProduto(err))
+	}
+	return nil
+}
+
+func traduzErroProduto(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: produtos.codigo_barras") {
+		return ErrCodigoBarrasEmUso
+	}
 	return err
 }
 
+// BuscarAbaixoDoMinimo lista produtos com estoque menor que qtd_minima
+func (r *ProdutoRepository) BuscarAbaixoDoMinimo(limit, offset int) ([]models.Produto, error) {
+	rows, err := r.db.Query(
+		"SELECT "+produtoColunas+" FROM "+produtoFrom+`
+		  WHERE p.qtd_minima IS NOT NULL AND p.qtd_estoque < p.qtd_minima
+		  ORDER BY f.nome, p.nome
+		  LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	produtos := []models.Produto{}
+	for rows.Next() {
+		p, err := scanProduto(rows)
+		if err != nil {
+			return nil, err
+		}
+		produtos = append(produtos, p)
+	}
+	return produtos, rows.Err()
+}
+
+// BuscarCandidatosReposicao traz os produtos abaixo do mínimo com o total
+// vendido desde `desde`, o que já está em compras pendentes e o último custo
+// pago, para o cálculo da sugestão de compra
+func (r *ProdutoRepository) BuscarCandidatosReposicao(desde time.Time) ([]models.ItemReposicao, error) {
+	rows, err := r.db.Query(
+		`SELECT p.id, p.nome, p.fornecedor_id, f.nome, p.qtd_estoque,
+		        p.qtd_minima, COALESCE(p.qtd_maxima, 0),
+		        COALESCE((SELECT SUM(vp.quantidade)
+		                    FROM vendas_produtos vp
+		                    JOIN vendas v ON v.id = vp.venda_id
+		                   WHERE vp.produto_id = p.id AND v.data_venda >= ? AND v.status = ?), 0),
+		        COALESCE((SELECT SUM(cp.quantidade)
+		                    FROM compras_produtos cp
+		                    JOIN compras c ON c.id = cp.compra_id
+		                   WHERE cp.produto_id = p.id AND c.status = ?), 0),
+		        (SELECT cp.preco_unitario
+		           FROM compras_produtos cp
+		           JOIN compras c ON c.id = cp.compra_id
+		          WHERE cp.produto_id = p.id AND c.status = ?
+		          ORDER BY c.data_recebimento DESC
+		          LIMIT 1)
+		   FROM produtos p
+		   JOIN fornecedores f ON f.id = p.fornecedor_id
+		  WHERE p.qtd_minima IS NOT NULL AND p.qtd_estoque < p.qtd_minima
+		  ORDER BY f.nome, p.nome`,
+		desde, models.StatusVendaAtiva, models.StatusCompraPendente, models.StatusCompraRecebida)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []models.ItemReposicao{}
+	for rows.Next() {
+		var i models.ItemReposicao
+		if err := rows.Scan(&i.ProdutoID, &i.Nome, &i.FornecedorID, &i.FornecedorNome, &i.Estoque,
+			&i.QtdMinima, &i.QtdMaxima, &i.VendidoPeriodo, &i.EmPedido, &i.PrecoUnitario); err != nil {
+			return nil, err
+		}
+		itens = append(itens, i)
+	}
+	return itens, rows.Err()
+}
+
 // --- Handler de produtos (pseudocódigo, importar chi, handler, etc.) ---
 // r.Route("/produtos", func(r chi.Router) {
 //   pr := repository.NewProdutoRepository(db)
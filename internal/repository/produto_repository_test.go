@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestProdutoRepository(t *testing.T) {
+	db := novoBanco(t)
+	pr := NewProdutoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+
+	p := criarProduto(t, db, f, "A1", 10, "12.50")
+	if p.ID == 0 || p.QuantidadeEstoque != 10 {
+		t.Fatalf("Save: %+v", p)
+	}
+
+	// mesmo par fornecedor/código atualiza o preço; o estoque só muda por ajuste
+	mais := domain.Produto{Nome: p.Nome, Fornecedor: f, CodigoFornecedor: "A1",
+		QuantidadeEstoque: 5, Preco: decimal(t, "13.00")}
+	if err := pr.Save(&mais, "teste"); !errors.Is(err, ErrEstoqueSomenteAjuste) {
+		t.Fatalf("Save existente com quantidade: err = %v, esperado ErrEstoqueSomenteAjuste", err)
+	}
+	mais.QuantidadeEstoque = 0
+	if err := pr.Save(&mais, "teste"); err != nil {
+		t.Fatalf("Save existente: %v", err)
+	}
+	if mais.ID != p.ID || mais.QuantidadeEstoque != 10 {
+		t.Errorf("Save existente: id %d estoque %d", mais.ID, mais.QuantidadeEstoque)
+	}
+
+	lista, err := pr.Find(map[string]any{"id": p.ID}, 10, 0)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(lista) != 1 {
+		t.Fatalf("Find(id) = %d produtos", len(lista))
+	}
+	got := lista[0]
+	if got.Nome != p.Nome || got.Fornecedor.Nome != f.Nome || got.CodigoFornecedor != "A1" ||
+		got.QuantidadeEstoque != 10 || !got.Preco.Equal(decimal(t, "13")) {
+		t.Errorf("Find(id) = %+v", got)
+	}
+
+	criarProduto(t, db, f, "B2", 0, "99.90")
+	filtros := map[string]any{
+		"nome":              "Produto",
+		"fornecedor_id":     f.Id,
+		"codigo_fornecedor": "B2",
+		"preco_min":         apd.New(50, 0),
+		"preco_max":         apd.New(100, 0),
+	}
+	if lista, err = pr.Find(filtros, 10, 0); err != nil || len(lista) != 1 || lista[0].CodigoFornecedor != "B2" {
+		t.Errorf("Find(filtros) = %+v, err %v", lista, err)
+	}
+
+	got.QuantidadeEstoque = 3
+	if err := pr.Update(&got); !errors.Is(err, ErrEstoqueSomenteAjuste) {
+		t.Errorf("Update com nova quantidade: err = %v, esperado ErrEstoqueSomenteAjuste", err)
+	}
+	ajuste := domain.AjusteEstoque{ProdutoID: p.ID, Quantidade: -7, Motivo: domain.AjusteCorrecao}
+	if _, err := NewMovimentoRepository(db).AjustarEstoque(ajuste, "teste"); err != nil {
+		t.Fatalf("AjustarEstoque: %v", err)
+	}
+	if err := got.SetNiveisEstoque(5, 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := pr.Update(&got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	abaixo, err := pr.BuscarAbaixoDoMinimo(10, 0)
+	if err != nil {
+		t.Fatalf("BuscarAbaixoDoMinimo: %v", err)
+	}
+	if len(abaixo) != 1 || abaixo[0].ID != p.ID || abaixo[0].QuantidadeEstoque != 3 ||
+		abaixo[0].QuantidadeMinima != 5 || abaixo[0].QuantidadeMaxima != 20 {
+		t.Errorf("BuscarAbaixoDoMinimo = %+v", abaixo)
+	}
+
+	candidatos, err := pr.BuscarCandidatosReposicao(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("BuscarCandidatosReposicao: %v", err)
+	}
+	if len(candidatos) != 1 || candidatos[0].ProdutoID != p.ID || candidatos[0].Estoque != 3 {
+		t.Errorf("BuscarCandidatosReposicao = %+v", candidatos)
+	}
+
+	if err := pr.Delete(p.ID); !errors.Is(err, ErrProdutoEmUso) {
+		t.Errorf("Delete com movimentos: err = %v, esperado ErrProdutoEmUso", err)
+	}
+	if err := pr.Delete(999); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Delete inexistente: err = %v", err)
+	}
+}
+
+// O custo informado à mão vale só até o produto ter estoque ou compras
+// recebidas; dali em diante ele é o custo médio das compras
+func TestCustoInformadoSoSemEstoqueNemCompras(t *testing.T) {
+	db := novoBanco(t)
+	pr := NewProdutoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+
+	novo := criarProduto(t, db, f, "A1", 0, "8.00")
+	novo.Custo = decimal(t, "3.00")
+	if err := pr.Update(&novo); err != nil {
+		t.Fatalf("Update do custo sem estoque nem compras: %v", err)
+	}
+	repostado := domain.Produto{Nome: novo.Nome, Fornecedor: f, CodigoFornecedor: "A1",
+		Preco: decimal(t, "9.00"), Custo: decimal(t, "3.50")}
+	if err := pr.Save(&repostado, "teste"); !errors.Is(err, ErrCustoPelasCompras) {
+		t.Errorf("Save existente com custo: err = %v, esperado ErrCustoPelasCompras", err)
+	}
+	repostado.Custo = domain.Decimal{}
+	if err := pr.Save(&repostado, "teste"); err != nil || !repostado.Custo.Equal(decimal(t, "3")) {
+		t.Errorf("Save existente sem custo: custo %s, err %v", repostado.Custo, err)
+	}
+
+	comEstoque := criarProduto(t, db, f, "B2", 2, "8.00")
+	comEstoque.Custo = decimal(t, "1.00")
+	if err := pr.Update(&comEstoque); !errors.Is(err, ErrCustoPelasCompras) {
+		t.Errorf("Update do custo com estoque: err = %v, esperado ErrCustoPelasCompras", err)
+	}
+
+	// recebida uma compra, nem zerar o estoque libera o custo
+	comprado := criarProduto(t, db, f, "C3", 0, "8.00")
+	cr := NewCompraRepository(db)
+	c := domain.Compra{FornecedorID: f.Id, Items: []domain.CompraItem{
+		{ProdutoID: comprado.ID, Quantidade: 2, PrecoUnitario: decimal(t, "5.00")},
+	}}
+	if err := cr.SalvarCompra(&c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cr.ReceberCompra(c.ID, nil, nil, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	ajuste := domain.AjusteEstoque{ProdutoID: comprado.ID, Quantidade: -2, Motivo: domain.AjusteCorrecao}
+	if _, err := NewMovimentoRepository(db).AjustarEstoque(ajuste, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	lista, err := pr.Find(map[string]any{"id": comprado.ID}, 1, 0)
+	if err != nil || len(lista) != 1 || lista[0].QuantidadeEstoque != 0 {
+		t.Fatalf("Find = %+v, err %v", lista, err)
+	}
+	lista[0].Custo = decimal(t, "1.00")
+	if err := pr.Update(&lista[0]); !errors.Is(err, ErrCustoPelasCompras) {
+		t.Errorf("Update do custo após compra: err = %v, esperado ErrCustoPelasCompras", err)
+	}
+}
+
+func TestDeletarProdutoSemHistorico(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 0, "1.00")
+
+	if err := NewProdutoRepository(db).Delete(p.ID); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+}
+
+func TestCodigoBarras(t *testing.T) {
+	db := novoBanco(t)
+	pr := NewProdutoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+
+	p := domain.Produto{Nome: "Caderno", Fornecedor: f, CodigoFornecedor: "CAD", CodigoBarras: "4006381333931",
+		Preco: decimal(t, "12.00")}
+	if err := pr.Save(&p, "teste"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	achado, err := pr.BuscarPorCodigoBarras("4006381333931")
+	if err != nil || achado.ID != p.ID || achado.CodigoBarras != "4006381333931" {
+		t.Errorf("BuscarPorCodigoBarras = %+v, err %v", achado, err)
+	}
+	if _, err := pr.BuscarPorCodigoBarras("96385074"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("código desconhecido: err = %v", err)
+	}
+
+	outro := criarProduto(t, db, f, "B1", 0, "1.00")
+	outro.CodigoBarras = p.CodigoBarras
+	if err := pr.Update(&outro); !errors.Is(err, ErrCodigoBarrasEmUso) {
+		t.Errorf("código repetido: err = %v, esperado ErrCodigoBarrasEmUso", err)
+	}
+
+	gerado, err := pr.GerarCodigoBarras(outro.ID)
+	if err != nil {
+		t.Fatalf("GerarCodigoBarras: %v", err)
+	}
+	if esperado, _ := domain.CodigoBarrasInterno(outro.ID); gerado.CodigoBarras != esperado {
+		t.Errorf("código gerado = %q, esperado %q", gerado.CodigoBarras, esperado)
+	}
+	if _, err := pr.GerarCodigoBarras(p.ID); !errors.Is(err, ErrProdutoComCodigoBarras) {
+		t.Errorf("gerar para produto com código: err = %v", err)
+	}
+
+	criarProduto(t, db, f, "C1", 0, "1.00")
+	criarProduto(t, db, f, "D1", 0, "1.00")
+	if n, err := pr.GerarCodigosBarrasFaltantes(); err != nil || n != 2 {
+		t.Errorf("GerarCodigosBarrasFaltantes = %d, %v", n, err)
+	}
+	if n, err := pr.GerarCodigosBarrasFaltantes(); err != nil || n != 0 {
+		t.Errorf("segunda geração = %d, %v", n, err)
+	}
+}
+
+func TestBuscarPorIds(t *testing.T) {
+	db := novoBanco(t)
+	pr := NewProdutoRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A", 1, "1.00")
+	b := criarProduto(t, db, f, "B", 2, "2.00")
+	criarProduto(t, db, f, "C", 3, "3.00")
+
+	produtos, err := pr.BuscarPorIds([]int64{b.ID, a.ID, 999})
+	if err != nil {
+		t.Fatalf("BuscarPorIds: %v", err)
+	}
+	if len(produtos) != 2 || produtos[a.ID].CodigoFornecedor != "A" || produtos[b.ID].CodigoFornecedor != "B" {
+		t.Errorf("BuscarPorIds = %+v", produtos)
+	}
+	if produtos, err := pr.BuscarPorIds(nil); err != nil || len(produtos) != 0 {
+		t.Errorf("BuscarPorIds(nil) = %+v, %v", produtos, err)
+	}
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrPromocaoEmUso indica promoção já aplicada em vendas ou orçamentos
+var ErrPromocaoEmUso = errors.New("promoção já aplicada em vendas ou orçamentos; encerre-a em vez de remover")
+
+// PromocaoRepository encapsula acessos ao banco para as regras de preço
+type PromocaoRepository struct {
+	db *sql.DB
+}
+
+// NewPromocaoRepository cria uma instância de PromocaoRepository
+func NewPromocaoRepository(db *sql.DB) *PromocaoRepository {
+	return &PromocaoRepository{db: db}
+}
+
+// SalvarPromocao grava a promoção com suas faixas. Fornecedor ou produto
+// inexistente devolve sql.ErrNoRows.
+func (pr *PromocaoRepository) SalvarPromocao(p *domain.Promocao) error {
+	tx, err := pr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if p.FornecedorID != 0 {
+		if err := tx.QueryRow("SELECT id FROM fornecedores WHERE id = ?", p.FornecedorID).Scan(new(int64)); err != nil {
+			return fmt.Errorf("fornecedor %d: %w", p.FornecedorID, err)
+		}
+	}
+	if p.ProdutoID != 0 {
+		if err := tx.QueryRow("SELECT id FROM produtos WHERE id = ?", p.ProdutoID).Scan(new(int64)); err != nil {
+			return fmt.Errorf("produto %d: %w", p.ProdutoID, err)
+		}
+	}
+
+	p.CriadaEm = time.Now()
+	res, err := tx.Exec(
+		`INSERT INTO promocoes (nome, tipo, fornecedor_id, produto_id, percentual, leve, pague, inicio, fim, criada_em)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Nome, p.Tipo, nullInt64(p.FornecedorID), nullInt64(p.ProdutoID), nullDecimal(p.Percentual),
+		nullInt64(int64(p.Leve)), nullInt64(int64(p.Pague)), p.Inicio, nullString(p.Fim), p.CriadaEm)
+	if err != nil {
+		return err
+	}
+	p.ID, _ = res.LastInsertId()
+
+	for _, f := range p.Faixas {
+		if _, err := tx.Exec(
+			`INSERT INTO promocoes_faixas (promocao_id, quantidade_minima, preco) VALUES (?, ?, ?)`,
+			p.ID, f.QuantidadeMinima, f.Preco.String()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// BuscarPromocoes consulta promoções por tipo, produto, fornecedor ou
+// vigência num dia ("vigente_em", "YYYY-MM-DD")
+func (pr *PromocaoRepository) BuscarPromocoes(filters map[string]any, limit, offset int) ([]domain.Promocao, error) {
+	query := "SELECT " + promocaoColunas + " FROM promocoes"
+	var clauses []string
+	var args []any
+
+	if v, ok := filters["tipo"]; ok {
+		clauses = append(clauses, "tipo = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["produto_id"]; ok {
+		clauses = append(clauses, "produto_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["fornecedor_id"]; ok {
+		clauses = append(clauses, "fornecedor_id = ?")
+		args = append(args, v)
+	}
+	if v, ok := filters["vigente_em"]; ok {
+		clauses = append(clauses, "date(inicio) <= date(?) AND (fim IS NULL OR date(fim) >= date(?))")
+		args = append(args, v, v)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	return buscarPromocoes(pr.db, query, args...)
+}
+
+// BuscarPromocaoPorId devolve a promoção com as faixas ou sql.ErrNoRows
+func (pr *PromocaoRepository) BuscarPromocaoPorId(id int64) (domain.Promocao, error) {
+	p, err := scanPromocao(pr.db.QueryRow("SELECT "+promocaoColunas+" FROM promocoes WHERE id = ?", id))
+	if err != nil {
+		return domain.Promocao{}, err
+	}
+	p.Faixas, err = buscarFaixas(pr.db, p.ID)
+	return p, err
+}
+
+// EncerrarPromocao termina a vigência da promoção no dia anterior a hoje
+func (pr *PromocaoRepository) EncerrarPromocao(id int64, hoje time.Time) (domain.Promocao, error) {
+	p, err := pr.BuscarPromocaoPorId(id)
+	if err != nil {
+		return domain.Promocao{}, err
+	}
+	if err := p.Encerrar(hoje); err != nil {
+		return domain.Promocao{}, err
+	}
+	if _, err := pr.db.Exec("UPDATE promocoes SET fim = ? WHERE id = ?", p.Fim, id); err != nil {
+		return domain.Promocao{}, err
+	}
+	return p, nil
+}
+
+// DeletarPromocao remove promoção que nunca foi aplicada
+func (pr *PromocaoRepository) DeletarPromocao(id int64) error {
+	res, err := pr.db.Exec("DELETE FROM promocoes WHERE id = ?", id)
+	if violaChaveEstrangeira(err) {
+		return ErrPromocaoEmUso
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// buscarPromocoes executa query sobre promocaoColunas e carrega as faixas
+func buscarPromocoes(q queryer, query string, args ...any) ([]domain.Promocao, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	promocoes := []domain.Promocao{}
+	for rows.Next() {
+		p, err := scanPromocao(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		promocoes = append(promocoes, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range promocoes {
+		if promocoes[i].Tipo != domain.PromocaoFaixaQuantidade {
+			continue
+		}
+		if promocoes[i].Faixas, err = buscarFaixas(q, promocoes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return promocoes, nil
+}
+
+func buscarFaixas(q queryer, promocaoID int64) ([]domain.FaixaPreco, error) {
+	rows, err := q.Query(
+		`SELECT quantidade_minima, preco FROM promocoes_faixas WHERE promocao_id = ? ORDER BY quantidade_minima`,
+		promocaoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var faixas []domain.FaixaPreco
+	for rows.Next() {
+		var f domain.FaixaPreco
+		if err := rows.Scan(&f.QuantidadeMinima, &f.Preco); err != nil {
+			return nil, err
+		}
+		faixas = append(faixas, f)
+	}
+	return faixas, rows.Err()
+}
+
+// novoPrecificador lê preço de tabela e fornecedor de cada produto dos itens
+// e as promoções vigentes em dia, em ordem de criação
+func novoPrecificador(q queryer, items []domain.SaleItem, dia time.Time) (domain.Precificador, error) {
+	p := domain.Precificador{
+		Precos:       make(map[int64]domain.Decimal, len(items)),
+		Fornecedores: make(map[int64]int64, len(items)),
+	}
+	for _, item := range items {
+		var (
+			preco        domain.Decimal
+			fornecedorID int64
+		)
+		err := q.QueryRow("SELECT preco_unitario, fornecedor_id FROM produtos WHERE id = ?", item.ProductID).
+			Scan(&preco, &fornecedorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, fmt.Errorf("produto %d: %w", item.ProductID, sql.ErrNoRows)
+		} else if err != nil {
+			return p, err
+		}
+		p.Precos[item.ProductID] = preco
+		p.Fornecedores[item.ProductID] = fornecedorID
+	}
+
+	d := dia.Format("2006-01-02")
+	promocoes, err := buscarPromocoes(q,
+		"SELECT "+promocaoColunas+" FROM promocoes"+
+			" WHERE date(inicio) <= date(?) AND (fim IS NULL OR date(fim) >= date(?)) ORDER BY id", d, d)
+	if err != nil {
+		return p, err
+	}
+	p.Promocoes = promocoes
+	return p, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestPromocaoAplicadaNaVenda(t *testing.T) {
+	db := novoBanco(t)
+	pr := NewPromocaoRepository(db)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 20, "4.00")
+	c := criarCliente(t, db, "Cliente")
+
+	hoje := time.Now()
+	leve := domain.Promocao{Nome: "Leve 3 pague 2", Tipo: domain.PromocaoLevePague, ProdutoID: p.ID, Leve: 3, Pague: 2}
+	faixa := domain.Promocao{Nome: "Atacado", Tipo: domain.PromocaoFaixaQuantidade, ProdutoID: p.ID,
+		Faixas: []domain.FaixaPreco{{QuantidadeMinima: 10, Preco: decimal(t, "3.00")}}}
+	futura := domain.Promocao{Nome: "Futura", Tipo: domain.PromocaoLevePague, ProdutoID: p.ID, Leve: 2, Pague: 1,
+		Inicio: hoje.AddDate(0, 0, 5).Format("2006-01-02")}
+	for _, promo := range []*domain.Promocao{&leve, &faixa, &futura} {
+		if err := promo.Validate(hoje); err != nil {
+			t.Fatal(err)
+		}
+		if err := pr.SalvarPromocao(promo); err != nil {
+			t.Fatalf("SalvarPromocao(%q): %v", promo.Nome, err)
+		}
+	}
+
+	// 12 unidades: leve/pague dá 4 grátis (16.00), mais que os 12.00 da faixa
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 12}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	gravada, err := vr.BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	promo := gravada.Items[0].Promocao
+	if promo == nil || promo.ID != leve.ID || promo.Nome != leve.Nome || !promo.Valor.Equal(decimal(t, "16.00")) ||
+		!gravada.Total.Equal(decimal(t, "32.00")) {
+		t.Errorf("venda gravada: promoção %+v, total %s", promo, gravada.Total)
+	}
+
+	if err := pr.DeletarPromocao(leve.ID); !errors.Is(err, ErrPromocaoEmUso) {
+		t.Errorf("DeletarPromocao de promoção usada: err = %v", err)
+	}
+	if _, err := pr.EncerrarPromocao(leve.ID, hoje); err != nil {
+		t.Fatalf("EncerrarPromocao: %v", err)
+	}
+	vigentes, err := pr.BuscarPromocoes(map[string]any{"vigente_em": hoje.Format("2006-01-02")}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vigentes) != 1 || vigentes[0].ID != faixa.ID || len(vigentes[0].Faixas) != 1 {
+		t.Errorf("promoções vigentes = %+v", vigentes)
+	}
+
+	venda = domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	if venda.Items[0].Promocao != nil || !venda.Total.Equal(decimal(t, "12.00")) {
+		t.Errorf("venda após encerrar: promoção %+v, total %s", venda.Items[0].Promocao, venda.Total)
+	}
+	if err := pr.DeletarPromocao(futura.ID); err != nil {
+		t.Errorf("DeletarPromocao: %v", err)
+	}
+}
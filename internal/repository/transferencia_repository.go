@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// TransferenciaRepository grava as transferências de mercadoria entre depósitos
+type TransferenciaRepository struct {
+	db *sql.DB
+}
+
+// NewTransferenciaRepository cria uma instância de TransferenciaRepository
+func NewTransferenciaRepository(db *sql.DB) *TransferenciaRepository {
+	return &TransferenciaRepository{db: db}
+}
+
+// Transferir grava a transferência e, na mesma transação, a saída de cada
+// item na origem e a entrada no destino. Se algum item não tiver saldo na
+// origem nada é gravado e um *EstoqueInsuficienteError lista todos os itens
+// com problema; depósito inexistente ou inativo devolve ErrDepositoInexistente
+// ou domain.ErrDepositoInativo.
+func (tr *TransferenciaRepository) Transferir(t *domain.Transferencia) error {
+	tx, err := tr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range []int64{t.OrigemID, t.DestinoID} {
+		if err := conferirDeposito(tx, id); err != nil {
+			return err
+		}
+	}
+
+	if t.Data.IsZero() {
+		t.Data = time.Now()
+	}
+	res, err := tx.Exec(
+		`INSERT INTO transferencias (origem_id, destino_id, data, observacao, usuario) VALUES (?, ?, ?, ?, ?)`,
+		t.OrigemID, t.DestinoID, t.Data, nullString(t.Observacao), nullString(t.Usuario))
+	if err != nil {
+		return err
+	}
+	t.ID, _ = res.LastInsertId()
+	doc := documento("transferencia", t.ID)
+
+	var faltando []ItemSemEstoque
+	for _, item := range t.Itens {
+		if _, err := tx.Exec(
+			`INSERT INTO transferencias_produtos (transferencia_id, produto_id, quantidade) VALUES (?, ?, ?)`,
+			t.ID, item.ProdutoID, item.Quantidade); err != nil {
+			if violaChaveEstrangeira(err) {
+				return fmt.Errorf("produto %d: %w", item.ProdutoID, sql.ErrNoRows)
+			}
+			return err
+		}
+		saida := domain.MovimentoEstoque{
+			ProdutoID:  item.ProdutoID,
+			DepositoID: t.OrigemID,
+			Tipo:       domain.MovimentoTransferencia,
+			Quantidade: -item.Quantidade,
+			Motivo:     t.Observacao,
+			Documento:  doc,
+			Usuario:    t.Usuario,
+			CriadoEm:   t.Data,
+		}
+		err := movimentarEstoque(tx, &saida)
+		var semEstoque *EstoqueInsuficienteError
+		if errors.As(err, &semEstoque) {
+			faltando = append(faltando, semEstoque.Itens...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entrada := saida
+		entrada.DepositoID, entrada.Quantidade = t.DestinoID, item.Quantidade
+		if err := movimentarEstoque(tx, &entrada); err != nil {
+			return err
+		}
+		// os lotes baixados na origem chegam ao destino com o mesmo número e validade
+		lotes, err := lotesDoMovimento(tx, saida.ID)
+		if err != nil {
+			return err
+		}
+		for i := range lotes {
+			if err := entrarLote(tx, &entrada, &lotes[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if len(faltando) > 0 {
+		return &EstoqueInsuficienteError{Itens: faltando}
+	}
+
+	if t.Itens, err = buscarItensTransferencia(tx, t.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BuscarTransferencias lista as transferências, sem os itens, da mais recente
+// para a mais antiga. Filtros: deposito_id (origem ou destino), produto_id,
+// data_inicio e data_fim ("YYYY-MM-DD", inclusive).
+func (tr *TransferenciaRepository) BuscarTransferencias(filters map[string]any, limit, offset int) ([]domain.Transferencia, error) {
+	query := "SELECT " + transferenciaColunas + " FROM transferencias t"
+	var clauses []string
+	var args []any
+
+	if v, ok := filters["deposito_id"]; ok {
+		clauses = append(clauses, "(t.origem_id = ? OR t.destino_id = ?)")
+		args = append(args, v, v)
+	}
+	if v, ok := filters["produto_id"]; ok {
+		clauses = append(clauses,
+			"EXISTS (SELECT 1 FROM transferencias_produtos tp WHERE tp.transferencia_id = t.id AND tp.produto_id = ?)")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_inicio"]; ok {
+		clauses = append(clauses, "date(t.data) >= date(?)")
+		args = append(args, v)
+	}
+	if v, ok := filters["data_fim"]; ok {
+		clauses = append(clauses, "date(t.data) <= date(?)")
+		args = append(args, v)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY t.data DESC, t.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := tr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transferencias := []domain.Transferencia{}
+	for rows.Next() {
+		t, err := scanTransferencia(rows)
+		if err != nil {
+			return nil, err
+		}
+		transferencias = append(transferencias, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range transferencias {
+		if transferencias[i].Itens, err = buscarItensTransferencia(tr.db, transferencias[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return transferencias, nil
+}
+
+// BuscarTransferenciaPorId retorna a transferência com os itens ou sql.ErrNoRows
+func (tr *TransferenciaRepository) BuscarTransferenciaPorId(id int64) (domain.Transferencia, error) {
+	t, err := scanTransferencia(tr.db.QueryRow("SELECT "+transferenciaColunas+" FROM transferencias t WHERE t.id = ?", id))
+	if err != nil {
+		return domain.Transferencia{}, err
+	}
+	if t.Itens, err = buscarItensTransferencia(tr.db, id); err != nil {
+		return domain.Transferencia{}, err
+	}
+	return t, nil
+}
+
+func buscarItensTransferencia(q queryer, transferenciaID int64) ([]domain.ItemTransferencia, error) {
+	rows, err := q.Query(`
+		SELECT tp.produto_id, p.nome, tp.quantidade
+		  FROM transferencias_produtos tp
+		  JOIN produtos p ON p.id = tp.produto_id
+		 WHERE tp.transferencia_id = ?
+		 ORDER BY p.nome, tp.produto_id`, transferenciaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.ItemTransferencia{}
+	for rows.Next() {
+		var item domain.ItemTransferencia
+		if err := rows.Scan(&item.ProdutoID, &item.Nome, &item.Quantidade); err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
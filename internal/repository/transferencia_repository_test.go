@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// saldoDeposito lê o estoque do produto no depósito; sem linha, zero
+func saldoDeposito(t *testing.T, db *sql.DB, produtoID, depositoID int64) int64 {
+	t.Helper()
+	var qtd int64
+	err := db.QueryRow("SELECT quantidade FROM estoque_depositos WHERE produto_id = ? AND deposito_id = ?",
+		produtoID, depositoID).Scan(&qtd)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("lendo estoque do depósito: %v", err)
+	}
+	return qtd
+}
+
+func TestTransferencias(t *testing.T) {
+	db := novoBanco(t)
+	tr := NewTransferenciaRepository(db)
+	fundos := criarDeposito(t, NewDepositoRepository(db), "Fundos")
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A", 10, "2.00")
+	b := criarProduto(t, db, f, "B", 1, "3.00")
+	loja := domain.DepositoPrincipal
+
+	// compra recebida no depósito dos fundos
+	cr := NewCompraRepository(db)
+	compra := domain.Compra{FornecedorID: f.Id, DepositoID: fundos.ID, Items: []domain.CompraItem{
+		{ProdutoID: a.ID, Quantidade: 6, PrecoUnitario: decimal(t, "1.00")},
+	}}
+	if err := cr.SalvarCompra(&compra); err != nil {
+		t.Fatalf("SalvarCompra: %v", err)
+	}
+	if _, err := cr.ReceberCompra(compra.ID, nil, nil, "teste"); err != nil {
+		t.Fatalf("ReceberCompra: %v", err)
+	}
+	if saldoDeposito(t, db, a.ID, fundos.ID) != 6 || saldoDeposito(t, db, a.ID, loja) != 10 || estoque(t, db, a.ID) != 16 {
+		t.Fatalf("depois da compra: fundos %d, loja %d, total %d",
+			saldoDeposito(t, db, a.ID, fundos.ID), saldoDeposito(t, db, a.ID, loja), estoque(t, db, a.ID))
+	}
+
+	transf := domain.Transferencia{OrigemID: fundos.ID, DestinoID: loja, Usuario: "estoquista",
+		Itens: []domain.ItemTransferencia{{ProdutoID: a.ID, Quantidade: 4}}}
+	if err := tr.Transferir(&transf); err != nil {
+		t.Fatalf("Transferir: %v", err)
+	}
+	if saldoDeposito(t, db, a.ID, fundos.ID) != 2 || saldoDeposito(t, db, a.ID, loja) != 14 || estoque(t, db, a.ID) != 16 {
+		t.Errorf("depois da transferência: fundos %d, loja %d, total %d",
+			saldoDeposito(t, db, a.ID, fundos.ID), saldoDeposito(t, db, a.ID, loja), estoque(t, db, a.ID))
+	}
+
+	// sem saldo de B nos fundos nada é transferido, nem o item A
+	falha := domain.Transferencia{OrigemID: fundos.ID, DestinoID: loja, Itens: []domain.ItemTransferencia{
+		{ProdutoID: a.ID, Quantidade: 1}, {ProdutoID: b.ID, Quantidade: 1},
+	}}
+	var semEstoque *EstoqueInsuficienteError
+	if err := tr.Transferir(&falha); !errors.As(err, &semEstoque) || len(semEstoque.Itens) != 1 ||
+		semEstoque.Itens[0].ProdutoID != b.ID || semEstoque.Itens[0].DepositoID != fundos.ID {
+		t.Fatalf("transferência sem saldo: err = %v", err)
+	}
+	if saldoDeposito(t, db, a.ID, fundos.ID) != 2 {
+		t.Errorf("transferência recusada mexeu no estoque: fundos %d", saldoDeposito(t, db, a.ID, fundos.ID))
+	}
+
+	got, err := tr.BuscarTransferenciaPorId(transf.ID)
+	if err != nil || got.OrigemID != fundos.ID || got.Usuario != "estoquista" || len(got.Itens) != 1 ||
+		got.Itens[0].Quantidade != 4 || got.Itens[0].Nome != a.Nome {
+		t.Errorf("BuscarTransferenciaPorId = %+v, %v", got, err)
+	}
+	if lista, err := tr.BuscarTransferencias(map[string]any{"deposito_id": fundos.ID, "produto_id": a.ID}, 10, 0); err != nil || len(lista) != 1 || len(lista[0].Itens) != 1 {
+		t.Errorf("BuscarTransferencias = %+v, %v", lista, err)
+	}
+	if lista, _ := tr.BuscarTransferencias(map[string]any{"produto_id": b.ID}, 10, 0); len(lista) != 0 {
+		t.Errorf("BuscarTransferencias por B = %+v", lista)
+	}
+
+	// venda nos fundos só enxerga o saldo dos fundos
+	vr := NewVendasRepository(db)
+	c := criarCliente(t, db, "Cliente")
+	venda := domain.Sale{ClientID: c.ID, DepositoID: fundos.ID, Items: []domain.SaleItem{{ProductID: a.ID, Quantity: 3}}}
+	if err := vr.SalvarVenda(&venda, "teste"); !errors.As(err, &semEstoque) || semEstoque.Itens[0].Disponivel != 2 {
+		t.Fatalf("venda acima do saldo do depósito: err = %v", err)
+	}
+	venda = domain.Sale{ClientID: c.ID, DepositoID: fundos.ID, Items: []domain.SaleItem{{ProductID: a.ID, Quantity: 2}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda nos fundos: %v", err)
+	}
+	if got, _ := vr.BuscarVendaPorId(venda.ID); got.DepositoID != fundos.ID {
+		t.Errorf("depósito da venda = %d", got.DepositoID)
+	}
+
+	pr := NewProdutoRepository(db)
+	if nos, _ := pr.Find(map[string]any{"deposito_id": fundos.ID}, 10, 0); len(nos) != 0 {
+		t.Errorf("produtos com saldo nos fundos = %+v", nos)
+	}
+	if _, err := vr.CancelarVenda(venda.ID, "desistiu", "teste"); err != nil {
+		t.Fatalf("CancelarVenda: %v", err)
+	}
+	if nos, _ := pr.Find(map[string]any{"deposito_id": fundos.ID}, 10, 0); len(nos) != 1 || nos[0].ID != a.ID {
+		t.Errorf("cancelamento devolve ao depósito da venda: %+v", nos)
+	}
+	if nos, _ := pr.Find(map[string]any{"deposito_id": loja}, 10, 0); len(nos) != 2 {
+		t.Errorf("produtos com saldo na loja = %+v", nos)
+	}
+
+	mr := NewMovimentoRepository(db)
+	movs, err := mr.BuscarMovimentos(a.ID, map[string]any{"deposito_id": fundos.ID}, 20, 0)
+	if err != nil || len(movs) != 4 {
+		t.Fatalf("movimentos dos fundos = %+v, %v", movs, err)
+	}
+	if ultimo := movs[0]; ultimo.Tipo != domain.MovimentoCancelamento || ultimo.SaldoDeposito != 2 || ultimo.Saldo != 16 {
+		t.Errorf("último movimento dos fundos = %+v", ultimo)
+	}
+	if divergencias, err := mr.VerificarConsistencia(); err != nil || len(divergencias) != 0 {
+		t.Errorf("VerificarConsistencia = %+v, %v", divergencias, err)
+	}
+	if _, err := db.Exec("UPDATE estoque_depositos SET quantidade = 5 WHERE produto_id = ? AND deposito_id = ?",
+		a.ID, fundos.ID); err != nil {
+		t.Fatal(err)
+	}
+	divergencias, err := mr.VerificarConsistencia()
+	if err != nil || len(divergencias) != 1 || divergencias[0].DepositoID != fundos.ID || divergencias[0].Diferenca != 3 {
+		t.Errorf("VerificarConsistencia com depósito divergente = %+v, %v", divergencias, err)
+	}
+}
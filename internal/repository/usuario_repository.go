@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+// ErrNomeUsuarioEmUso indica outro usuário já cadastrado com o mesmo nome
+var ErrNomeUsuarioEmUso = errors.New("já existe usuário com este nome")
+
+// ErrTokenInvalido indica token que não pertence a nenhum usuário ativo
+var ErrTokenInvalido = errors.New("token inválido")
+
+// UsuarioRepository cadastra os usuários e identifica o dono de cada token
+type UsuarioRepository struct {
+	db *sql.DB
+}
+
+// NewUsuarioRepository cria uma instância de UsuarioRepository
+func NewUsuarioRepository(db *sql.DB) *UsuarioRepository {
+	return &UsuarioRepository{db: db}
+}
+
+// CriarUsuario cadastra o usuário e devolve o token de acesso, que só é
+// conhecido neste momento: o banco guarda apenas o hash
+func (ur *UsuarioRepository) CriarUsuario(u *domain.Usuario) (string, error) {
+	segredo := make([]byte, 32)
+	if _, err := rand.Read(segredo); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(segredo)
+
+	res, err := ur.db.Exec(
+		`INSERT INTO usuarios (nome, perfil, token_hash, criado_em) VALUES (?, ?, ?, ?)`,
+		u.Nome, u.Perfil, hashToken(token), time.Now())
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: usuarios.nome") {
+			return "", ErrNomeUsuarioEmUso
+		}
+		return "", err
+	}
+	u.ID, _ = res.LastInsertId()
+	u.Ativo = true
+	return token, nil
+}
+
+// Autenticar devolve o usuário ativo dono do token ou ErrTokenInvalido
+func (ur *UsuarioRepository) Autenticar(token string) (domain.Usuario, error) {
+	var u domain.Usuario
+	err := ur.db.QueryRow(
+		`SELECT id, nome, perfil, ativo FROM usuarios WHERE token_hash = ? AND ativo = 1`,
+		hashToken(token)).Scan(&u.ID, &u.Nome, &u.Perfil, &u.Ativo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Usuario{}, ErrTokenInvalido
+	}
+	return u, err
+}
+
+func hashToken(token string) string {
+	soma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(soma[:])
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestUsuarios(t *testing.T) {
+	db := novoBanco(t)
+	ur := NewUsuarioRepository(db)
+
+	u := domain.Usuario{Nome: "Ana", Perfil: domain.PerfilGerente}
+	token, err := ur.CriarUsuario(&u)
+	if err != nil || u.ID == 0 || len(token) != 64 {
+		t.Fatalf("CriarUsuario = %q, %v", token, err)
+	}
+	if _, err := ur.CriarUsuario(&domain.Usuario{Nome: "Ana", Perfil: "vendedor"}); !errors.Is(err, ErrNomeUsuarioEmUso) {
+		t.Errorf("nome repetido: err = %v", err)
+	}
+
+	got, err := ur.Autenticar(token)
+	if err != nil || got.Nome != "Ana" || got.Perfil != domain.PerfilGerente || !got.Ativo {
+		t.Errorf("Autenticar = %+v, %v", got, err)
+	}
+	if _, err := ur.Autenticar("gerente"); !errors.Is(err, ErrTokenInvalido) {
+		t.Errorf("token inventado: err = %v", err)
+	}
+	var hash string
+	if err := db.QueryRow("SELECT token_hash FROM usuarios WHERE id = ?", u.ID).Scan(&hash); err != nil || hash == token {
+		t.Errorf("token guardado sem hash: %q, %v", hash, err)
+	}
+	if _, err := db.Exec("UPDATE usuarios SET ativo = 0 WHERE id = ?", u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ur.Autenticar(token); !errors.Is(err, ErrTokenInvalido) {
+		t.Errorf("usuário inativo: err = %v", err)
+	}
+}
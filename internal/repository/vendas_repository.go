@@ -2,13 +2,55 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/julio-pupim/lojaestoque/internal/domain"
 )
 
+// ItemSemEstoque descreve um item da venda cuja quantidade excede o estoque
+// do depósito
+type ItemSemEstoque struct {
+	ProdutoID  int64 `json:"produto_id"`
+	DepositoID int64 `json:"deposito_id,omitempty"`
+	LoteID     int64 `json:"lote_id,omitempty"`
+	Solicitado int64 `json:"solicitado"`
+	Disponivel int64 `json:"disponivel"`
+	Vencidos   int64 `json:"vencidos,omitempty"` // em lotes vencidos, que não podem ser vendidos
+}
+
+// EstoqueInsuficienteError é retornado quando algum item da venda não tem estoque suficiente
+type EstoqueInsuficienteError struct {
+	Itens []ItemSemEstoque
+}
+
+func (e *EstoqueInsuficienteError) Error() string {
+	partes := make([]string, 0, len(e.Itens))
+	for _, item := range e.Itens {
+		parte := fmt.Sprintf("produto %d (solicitado %d, disponível %d", item.ProdutoID, item.Solicitado, item.Disponivel)
+		if item.Vencidos > 0 {
+			parte += fmt.Sprintf(", %d em lotes vencidos", item.Vencidos)
+		}
+		partes = append(partes, parte+")")
+	}
+	return "estoque insuficiente: " + strings.Join(partes, "; ")
+}
+
+// ErrVendaCancelada indica operação sobre venda que já foi cancelada
+var ErrVendaCancelada = errors.New("venda cancelada")
+
+// ErrVendaNaoPendente indica tentativa de alterar venda com pagamento registrado
+var ErrVendaNaoPendente = errors.New("somente vendas com pagamento pendente podem ser alteradas")
+
+// ErrVendaComDevolucao indica tentativa de alterar venda que já teve itens devolvidos
+var ErrVendaComDevolucao = errors.New("venda com devolução não pode ser alterada")
+
+// ErrClienteInexistente indica venda para um cliente não cadastrado
+var ErrClienteInexistente = errors.New("cliente não encontrado")
+
 type VendasRepository struct {
 	db *sql.DB
 }
@@ -16,57 +58,466 @@ type VendasRepository struct {
 func NewVendasRepository(db *sql.DB) *VendasRepository {
 	return &VendasRepository{db: db}
 }
-func (vr *VendasRepository) SalvarVenda(sale *domain.Sale) error {
+
+// SalvarVenda precifica a venda com os preços atuais dos produtos, grava a venda
+// com seus itens e baixa o estoque de cada produto na mesma transação. Se algum
+// item não tiver estoque suficiente nada é gravado e um
+// *EstoqueInsuficienteError lista todos os itens com problema. Pagamentos
+// recebidos no ato entram na mesma transação e definem o status de pagamento.
+func (vr *VendasRepository) SalvarVenda(sale *domain.Sale, usuario string) error {
 	tx, err := vr.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if err := salvarVenda(tx, sale, usuario); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// salvarVenda faz o trabalho de SalvarVenda dentro da transação recebida
+func salvarVenda(tx *sql.Tx, sale *domain.Sale, usuario string) error {
+	if sale.DataVenda.IsZero() {
+		sale.DataVenda = time.Now()
+	}
+	sale.PaymentStatus = domain.PaymentStatusPending
+	sale.PaymentDate = nil
+	sale.Status = domain.StatusVendaAtiva
+	if sale.DepositoID == 0 {
+		sale.DepositoID = domain.DepositoPrincipal
+	}
+	if err := conferirDeposito(tx, sale.DepositoID); err != nil {
+		return err
+	}
+	cliente, err := buscarClienteVenda(tx, sale.ClientID)
+	if err != nil {
+		return err
+	}
+
+	precificador, err := novoPrecificador(tx, sale.Items, sale.DataVenda)
+	if err != nil {
+		return err
+	}
+	if err := precificador.Precificar(sale); err != nil {
+		return err
+	}
+	if err := conferirDesconto(tx, sale); err != nil {
+		return err
+	}
+	aPrazo := sale.Total
+	for _, p := range sale.Pagamentos {
+		aPrazo = aPrazo.Sub(p.Valor)
+	}
+	if err := conferirCredito(tx, cliente, sale, aPrazo); err != nil {
+		return err
+	}
+
+	descontoTipo, descontoValor := colunasDesconto(sale.Desconto)
+	res, err := tx.Exec(
+		`INSERT INTO vendas (cliente_id, data_venda, total, status_pagamento, status, credito_liberado_por,
+		                     bruto, valor_desconto, desconto_tipo, desconto_valor, deposito_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sale.ClientID, sale.DataVenda, sale.Total.String(), sale.PaymentStatus, sale.Status,
+		nullString(sale.CreditoLiberadoPor), sale.Bruto.String(), sale.ValorDesconto.String(),
+		descontoTipo, descontoValor, sale.DepositoID,
+	)
+	if err != nil {
+		return err
+	}
+	sale.ID, _ = res.LastInsertId()
+	if err := inserirItensVenda(tx, sale); err != nil {
+		return err
+	}
+
+	if err := baixarEstoque(tx, sale, usuario); err != nil {
+		return err
+	}
+
+	for i := range sale.Pagamentos {
+		p := &sale.Pagamentos[i]
+		if p.Data.IsZero() {
+			p.Data = sale.DataVenda
+		}
+		p.Usuario = usuario
+		if err := registrarPagamento(tx, sale, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuscarVendaPorId retorna a venda com cliente e itens ou sql.ErrNoRows
+func (vr *VendasRepository) BuscarVendaPorId(id int64) (domain.Sale, error) {
+	venda, err := buscarVenda(vr.db, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	cliente, err := scanCliente(vr.db.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", venda.ClientID))
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	venda.Cliente = &cliente
+
+	if venda.Pagamentos, err = buscarPagamentos(vr.db, id); err != nil {
+		return domain.Sale{}, err
+	}
+	if venda.Parcelas, err = buscarParcelas(vr.db, id); err != nil {
+		return domain.Sale{}, err
+	}
+	pago := domain.DecimalFromInt(0)
+	for _, p := range venda.Pagamentos {
+		pago = pago.Add(p.Valor)
+	}
+	domain.AlocarPagamentos(venda.Parcelas, pago, time.Now())
+	return venda, nil
+}
+
+// AtualizarVenda altera o cliente e/ou os itens de uma venda ainda pendente.
+// Campos zerados em alteracao são mantidos; quando Items é informado a venda é
+// precificada de novo com o desconto de alteracao (ausente remove o desconto),
+// apenas a diferença de quantidade de cada produto é lançada no razão de
+// estoque e o plano de parcelas, que não fecha mais com o novo total, é
+// descartado. A liberação de crédito não é mantida: a venda alterada passa
+// pelo limite do cliente, a menos que alteracao traga nova liberação.
+func (vr *VendasRepository) AtualizarVenda(id int64, alteracao domain.Sale, usuario string) (domain.Sale, error) {
+	tx, err := vr.db.Begin()
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	defer tx.Rollback()
+
+	venda, err := buscarVenda(tx, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	if venda.Status == domain.StatusVendaCancelada {
+		return domain.Sale{}, ErrVendaCancelada
+	}
+	if venda.PaymentStatus != domain.PaymentStatusPending {
+		return domain.Sale{}, ErrVendaNaoPendente
+	}
+	if devolvidos, err := quantidadesDevolvidas(tx, id); err != nil {
+		return domain.Sale{}, err
+	} else if len(devolvidos) > 0 {
+		return domain.Sale{}, ErrVendaComDevolucao
+	}
+
+	if alteracao.ClientID != 0 {
+		venda.ClientID = alteracao.ClientID
+	}
+	// a liberação vale para a venda como foi liberada: alterada, é conferida de novo
+	venda.CreditoLiberadoPor = alteracao.CreditoLiberadoPor
+	venda.Perfil = alteracao.Perfil
+	cliente, err := buscarClienteVenda(tx, venda.ClientID)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+
+	if alteracao.Items != nil {
+		anteriores := venda.Items
+		venda.Items = alteracao.Items
+		venda.Desconto = alteracao.Desconto
+		venda.Total = alteracao.Total
+		precificador, err := novoPrecificador(tx, venda.Items, venda.DataVenda)
+		if err != nil {
+			return domain.Sale{}, err
+		}
+		if err := precificador.Precificar(&venda); err != nil {
+			return domain.Sale{}, err
+		}
+		if err := conferirDesconto(tx, &venda); err != nil {
+			return domain.Sale{}, err
+		}
+
+		if _, err := tx.Exec("DELETE FROM vendas_produtos WHERE venda_id = ?", id); err != nil {
+			return domain.Sale{}, err
+		}
+		if err := inserirItensVenda(tx, &venda); err != nil {
+			return domain.Sale{}, err
+		}
+		if err := lancarSaidas(tx, id, venda.DepositoID, diferencaItens(anteriores, venda.Items),
+			domain.MovimentoAlteracaoVenda, usuario); err != nil {
+			return domain.Sale{}, err
+		}
+		if _, err := tx.Exec("DELETE FROM parcelas WHERE venda_id = ?", id); err != nil {
+			return domain.Sale{}, err
+		}
+	}
+
+	// venda pendente não tem pagamentos: o total inteiro é a prazo
+	if err := conferirCredito(tx, cliente, &venda, venda.Total); err != nil {
+		return domain.Sale{}, err
+	}
+	descontoTipo, descontoValor := colunasDesconto(venda.Desconto)
+	if _, err := tx.Exec(
+		`UPDATE vendas SET cliente_id = ?, total = ?, credito_liberado_por = ?,
+		                   bruto = ?, valor_desconto = ?, desconto_tipo = ?, desconto_valor = ?
+		  WHERE id = ?`,
+		venda.ClientID, venda.Total.String(), nullString(venda.CreditoLiberadoPor),
+		venda.Bruto.String(), venda.ValorDesconto.String(), descontoTipo, descontoValor, id); err != nil {
+		return domain.Sale{}, err
+	}
+	return venda, tx.Commit()
+}
+
+// CancelarVenda marca a venda como cancelada e devolve ao estoque, com
+// movimentos CANCELAMENTO, os itens que ainda não foram devolvidos, de volta
+// aos lotes de onde saíram. O que o cliente pagou e ainda não recebeu de volta
+// em devoluções vira crédito na loja. A venda e seus itens continuam gravados.
+func (vr *VendasRepository) CancelarVenda(id int64, motivo, usuario string) (domain.Sale, error) {
+	tx, err := vr.db.Begin()
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	defer tx.Rollback()
+
+	venda, err := buscarVenda(tx, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	if venda.Status == domain.StatusVendaCancelada {
+		return domain.Sale{}, ErrVendaCancelada
+	}
+
+	devolvidos, err := quantidadesDevolvidas(tx, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	for _, item := range venda.Items {
+		restante := int64(item.Quantity) - devolvidos[item.ProductID]
+		if restante <= 0 {
+			continue
+		}
+		m := domain.MovimentoEstoque{
+			ProdutoID:  item.ProductID,
+			DepositoID: venda.DepositoID,
+			Tipo:       domain.MovimentoCancelamento,
+			Quantidade: restante,
+			Motivo:     motivo,
+			Documento:  documento("venda", id),
+			Usuario:    usuario,
+		}
+		if err := movimentarEstoque(tx, &m); err != nil {
+			return domain.Sale{}, err
+		}
+		if err := estornarLotes(tx, &m, m.Documento); err != nil {
+			return domain.Sale{}, err
+		}
+	}
+
+	pago, err := pagoNaoDevolvido(tx, id)
+	if err != nil {
+		return domain.Sale{}, err
+	}
+	if pago.Sign() > 0 {
+		if err := lancarCredito(tx, venda.ClientID, pago, documento("venda", id), usuario); err != nil {
+			return domain.Sale{}, err
+		}
+	}
+
+	agora := time.Now()
 	res, err := tx.Exec(
-		`INSERT INTO vendas (cliente_id, data_venda, total, status_pagamento) VALUES (?, ?, ?, ?)`,
-		sale.ClientID, sale.DataVenda, sale.Total, sale.PaymentStatus,
+		`UPDATE vendas SET status = ?, cancelada_em = ?, motivo_cancelamento = ? WHERE id = ? AND status = ?`,
+		domain.StatusVendaCancelada, agora, nullString(motivo), id, domain.StatusVendaAtiva,
 	)
 	if err != nil {
-		tx.Rollback()
+		return domain.Sale{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.Sale{}, ErrVendaCancelada
+	}
+	venda.Status = domain.StatusVendaCancelada
+	venda.CanceladaEm = &agora
+	venda.MotivoCancelamento = motivo
+
+	return venda, tx.Commit()
+}
+
+// pagoNaoDevolvido soma o que foi pago pela venda, sem os abatimentos de
+// devolução, menos o que as devoluções já reembolsaram ou deram de crédito
+func pagoNaoDevolvido(q queryer, vendaID int64) (domain.Decimal, error) {
+	var valor domain.Decimal
+	err := q.QueryRow(`
+		SELECT ROUND(
+		    COALESCE((SELECT SUM(valor) FROM pagamentos WHERE venda_id = ? AND forma <> ?), 0)
+		  - COALESCE((SELECT SUM(reembolso + credito) FROM devolucoes WHERE venda_id = ?), 0), 2)`,
+		vendaID, domain.FormaDevolucao, vendaID).Scan(&valor)
+	return valor, err
+}
+
+// buscarClienteVenda lê o cliente da venda, que precisa estar cadastrado
+func buscarClienteVenda(q queryer, clienteID int64) (domain.Cliente, error) {
+	cliente, err := scanCliente(q.QueryRow("SELECT "+clienteColunas+" FROM clientes WHERE id = ?", clienteID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Cliente{}, fmt.Errorf("cliente %d: %w", clienteID, ErrClienteInexistente)
+	}
+	return cliente, err
+}
+
+// conferirCredito recusa a parte a prazo da venda quando o cliente está
+// bloqueado ou passaria do limite, a menos que a venda traga quem liberou.
+// Só perfil que pode autorizar libera crédito; outro devolve
+// domain.ErrLiberacaoNaoAutorizada.
+func conferirCredito(q queryer, cliente domain.Cliente, venda *domain.Sale, aPrazo domain.Decimal) error {
+	if venda.CreditoLiberadoPor != "" {
+		if !domain.PodeAutorizar(venda.Perfil) {
+			return domain.ErrLiberacaoNaoAutorizada
+		}
+		return nil
+	}
+	saldo, err := saldoEmAberto(q, cliente.ID, venda.ID)
+	if err != nil {
 		return err
 	}
-	saleID, _ := res.LastInsertId()
-	sale.ID = saleID
+	return cliente.AvaliarCredito(saldo, aPrazo)
+}
+
+// saldoEmAberto soma o que falta receber das vendas ativas do cliente,
+// desconsiderando a venda excetoVendaID
+func saldoEmAberto(q queryer, clienteID, excetoVendaID int64) (domain.Decimal, error) {
+	var saldo domain.Decimal
+	err := q.QueryRow(`
+		SELECT ROUND(COALESCE(SUM(v.total - COALESCE(
+		           (SELECT SUM(pg.valor) FROM pagamentos pg WHERE pg.venda_id = v.id), 0)), 0), 2)
+		  FROM vendas v
+		 WHERE v.cliente_id = ? AND v.status = ? AND v.id <> ?`,
+		clienteID, domain.StatusVendaAtiva, excetoVendaID).Scan(&saldo)
+	return saldo, err
+}
+
+// inserirItensVenda grava os itens já precificados, com o custo atual de cada
+// produto e o lucro bruto do item
+func inserirItensVenda(tx *sql.Tx, sale *domain.Sale) error {
 	valueStrings := make([]string, 0, len(sale.Items))
-	valueArgs := make([]any, 0, len(sale.Items)*5)
+	valueArgs := make([]any, 0, len(sale.Items)*15)
 
-	for _, item := range sale.Items {
-		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?)")
+	for i := range sale.Items {
+		var custo domain.Decimal
+		if err := tx.QueryRow("SELECT custo FROM produtos WHERE id = ?", sale.Items[i].ProductID).Scan(&custo); err != nil {
+			return err
+		}
+		sale.Items[i].SaleID = sale.ID
+		sale.Items[i].ApurarLucro(custo)
+		item := sale.Items[i]
+		descontoTipo, descontoValor := colunasDesconto(item.Desconto)
+		promocaoID, valorPromocao := colunasPromocao(item.Promocao)
+		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 		valueArgs = append(valueArgs,
-			saleID,
+			sale.ID,
 			item.ProductID,
 			item.Quantity,
-			item.UnitPrice,
-			item.Total)
+			item.UnitPrice.String(),
+			item.Total.String(),
+			item.ListPrice.String(),
+			nullString(item.AuthorizedBy),
+			item.Bruto.String(),
+			item.ValorDesconto.String(),
+			descontoTipo,
+			descontoValor,
+			promocaoID,
+			valorPromocao,
+			item.CustoUnitario.String(),
+			item.LucroBruto.String())
 	}
 	query := fmt.Sprintf(`
   INSERT INTO vendas_produtos
-    (venda_id, produto_id, quantidade, preco_unitario, total)
+    (venda_id, produto_id, quantidade, preco_unitario, total, preco_tabela, autorizado_por,
+     bruto, valor_desconto, desconto_tipo, desconto_valor, promocao_id, valor_promocao,
+     custo_unitario, lucro_bruto)
   VALUES %s`, strings.Join(valueStrings, ","))
-	if _, err := tx.Exec(query, valueArgs...); err != nil {
-		tx.Rollback()
-		return err
+	_, err := tx.Exec(query, valueArgs...)
+	return err
+}
+
+// saidaEstoque é a quantidade de um produto que a venda retira do estoque;
+// negativa quando devolve
+type saidaEstoque struct {
+	produtoID  int64
+	quantidade int64
+}
+
+// baixarEstoque lança a saída de cada item da venda no razão, no depósito da venda
+func baixarEstoque(tx *sql.Tx, sale *domain.Sale, usuario string) error {
+	saidas := make([]saidaEstoque, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		saidas = append(saidas, saidaEstoque{item.ProductID, int64(item.Quantity)})
 	}
-	return tx.Commit()
+	return lancarSaidas(tx, sale.ID, sale.DepositoID, saidas, domain.MovimentoVenda, usuario)
+}
+
+// diferencaItens calcula o que muda no estoque quando os itens de uma venda
+// passam de antes para depois, na ordem dos produtos
+func diferencaItens(antes, depois []domain.SaleItem) []saidaEstoque {
+	delta := make(map[int64]int64)
+	for _, item := range antes {
+		delta[item.ProductID] -= int64(item.Quantity)
+	}
+	for _, item := range depois {
+		delta[item.ProductID] += int64(item.Quantity)
+	}
+
+	saidas := make([]saidaEstoque, 0, len(delta))
+	for produtoID, qtd := range delta {
+		if qtd != 0 {
+			saidas = append(saidas, saidaEstoque{produtoID, qtd})
+		}
+	}
+	sort.Slice(saidas, func(i, j int) bool { return saidas[i].produtoID < saidas[j].produtoID })
+	return saidas
+}
+
+// lancarSaidas grava as saídas da venda no razão do depósito, como VENDA no
+// registro e ALTERACAO_VENDA na edição dos itens. movimentarEstoque só
+// decrementa se houver quantidade suficiente, o que impede que duas vendas
+// simultâneas consumam a mesma última unidade; as faltas de todos os itens são
+// reunidas num único *EstoqueInsuficienteError.
+func lancarSaidas(tx *sql.Tx, vendaID, depositoID int64, saidas []saidaEstoque, tipo domain.TipoMovimento, usuario string) error {
+	var faltando []ItemSemEstoque
+	for _, saida := range saidas {
+		m := domain.MovimentoEstoque{
+			ProdutoID:  saida.produtoID,
+			DepositoID: depositoID,
+			Tipo:       tipo,
+			Quantidade: -saida.quantidade,
+			Documento:  documento("venda", vendaID),
+			Usuario:    usuario,
+		}
+		err := movimentarEstoque(tx, &m)
+		if err == nil && m.Quantidade > 0 {
+			// item reduzido na alteração volta aos lotes de onde saiu
+			err = estornarLotes(tx, &m, m.Documento)
+		}
+		var semEstoque *EstoqueInsuficienteError
+		if errors.As(err, &semEstoque) {
+			faltando = append(faltando, semEstoque.Itens...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(faltando) > 0 {
+		return &EstoqueInsuficienteError{Itens: faltando}
+	}
+	return nil
 }
+
 func (vr *VendasRepository) BuscarVendas(filters map[string]any, limit, offset int) ([]domain.Sale, error) {
-	sql := `SELECT v.* FROM vendas v 
-	join clientes c on c.id = v.cliente_id 
-	join vendas_produtos vp on vp.venda_id = v.id
-	join produto p on vp.produto_id = p.id 
+	sql := "SELECT " + vendaColunas + ` FROM vendas v
+	join clientes c on c.id = v.cliente_id
 	`
 	var clauses []string
 	var args []any
 	if v, ok := filters["nome_cliente"]; ok {
-		clauses = append(clauses, "c.nome ILIKE ?")
+		clauses = append(clauses, "c.nome LIKE ?")
 		args = append(args, "%"+v.(string)+"%")
 	}
 	if v, ok := filters["nome_produto"]; ok {
-		clauses = append(clauses, "p.nome ILIKE ?")
+		clauses = append(clauses, `EXISTS (SELECT 1 FROM vendas_produtos vp
+		                                    JOIN produtos p ON p.id = vp.produto_id
+		                                   WHERE vp.venda_id = v.id AND p.nome LIKE ?)`)
 		args = append(args, "%"+v.(string)+"%")
 	}
 	if v, ok := filters["total"]; ok {
@@ -74,71 +525,77 @@ func (vr *VendasRepository) BuscarVendas(filters map[string]any, limit, offset i
 		args = append(args, v.(string))
 	}
 	if v, ok := filters["data_pagamento"]; ok {
-		clauses = append(clauses, "v.data_pagamento = ?")
+		clauses = append(clauses, "date(v.data_pagamento) = date(?)")
 		args = append(args, v.(string))
 	}
 
 	if v, ok := filters["data_venda"]; ok {
-		clauses = append(clauses, "v.data_venda = ?")
+		clauses = append(clauses, "date(v.data_venda) = date(?)")
 		args = append(args, v.(string))
 	}
 	if v, ok := filters["status_pagamento"]; ok {
-		clauses = append(clauses, "v.status_pagamento ILIKE ?")
-		args = append(args, "%"+v.(string)+"%")
+		clauses = append(clauses, "v.status_pagamento = ?")
+		args = append(args, strings.ToUpper(v.(string)))
+	}
+	if v, ok := filters["status"]; ok {
+		clauses = append(clauses, "v.status = ?")
+		args = append(args, strings.ToUpper(v.(string)))
 	}
 	if len(clauses) > 0 {
 		sql += " WHERE " + strings.Join(clauses, " AND ")
 	}
-	sql += " LIMIT ? OFFSET ?"
+	sql += " ORDER BY v.data_venda DESC, v.id DESC LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 	rows, err := vr.db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var vendas []domain.Sale
+	vendas := []domain.Sale{}
 	for rows.Next() {
-		var (
-			id, clienteId            int64
-			dataVenda, dataPagamento time.Time
-			total                    domain.Decimal
-			statusPagamento          domain.PaymentStatus
-		)
-		if err := rows.Scan(id, clienteId, dataVenda, total, dataPagamento, statusPagamento); err != nil {
+		venda, err := scanVenda(rows)
+		if err != nil {
+			return nil, err
+		}
+		vendas = append(vendas, venda)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range vendas {
+		if vendas[i].Items, err = buscarItensVenda(vr.db, vendas[i].ID); err != nil {
 			return nil, err
 		}
-		vendas = append(vendas, domain.Sale{})
 	}
 	return vendas, nil
 }
-func (vr *VendasRepository) buscarVendaPorId(id int64) (domain.Sale, error) {
-	row := vr.db.QueryRow("SELECT * FROM vendas WHERE id = ?", id)
-	if row.Err() != nil {
-		return domain.Sale{}, row.Err()
-	}
-	var (
-		idResponse, clienteId int64
-		dataVenda             time.Time
-		dataPagamento         *time.Time
-		total                 domain.Decimal
-		status_pagamento      domain.PaymentStatus
-	)
-	err := row.Scan(idResponse, clienteId, dataVenda, dataPagamento, total, status_pagamento)
+func buscarVenda(q queryer, id int64) (domain.Sale, error) {
+	venda, err := scanVenda(q.QueryRow("SELECT "+vendaColunas+" FROM vendas v WHERE v.id = ?", id))
 	if err != nil {
 		return domain.Sale{}, err
 	}
-
-	return domain.Sale{ID: idResponse, ClientID: clienteId, DataVenda: dataVenda, Total: total,
-		PaymentDate: dataPagamento, PaymentStatus: status_pagamento}, nil
+	venda.Items, err = buscarItensVenda(q, id)
+	return venda, err
 }
 
-// func (vr *VendasRepository) updateVenda() (domain.Sale, error) {
-// }
-
-func (vr *VendasRepository) DeletarVenda(id int64) error {
-	_, err := vr.db.Exec("DELETE FROM vendas WHERE id = ?", id)
+func buscarItensVenda(q queryer, vendaID int64) ([]domain.SaleItem, error) {
+	rows, err := q.Query(
+		"SELECT "+vendaItemColunas+" FROM vendas_produtos WHERE venda_id = ? ORDER BY produto_id",
+		vendaID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	defer rows.Close()
+
+	itens := []domain.SaleItem{}
+	for rows.Next() {
+		item, err := scanVendaItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
 }
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/julio-pupim/lojaestoque/internal/domain"
+)
+
+func TestVendasRepository(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	cafe := criarProduto(t, db, f, "CAFE", 10, "18.90")
+	acucar := criarProduto(t, db, f, "ACUCAR", 10, "4.50")
+	c := criarCliente(t, db, "Maria Souza")
+
+	desconto := decimal(t, "4.00")
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{
+		{ProductID: cafe.ID, Quantity: 2},
+		{ProductID: acucar.ID, Quantity: 3, PriceOverride: &desconto, AuthorizedBy: "gerente"},
+	}, Perfil: domain.PerfilGerente}
+	if err := vr.SalvarVenda(&venda, "caixa1"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+	if !venda.Total.Equal(decimal(t, "49.80")) {
+		t.Errorf("total = %s, esperado 49.80", venda.Total)
+	}
+
+	got, err := vr.BuscarVendaPorId(venda.ID)
+	if err != nil {
+		t.Fatalf("BuscarVendaPorId: %v", err)
+	}
+	if got.ClientID != c.ID || got.Cliente == nil || got.Cliente.Nome != c.Nome ||
+		!got.Total.Equal(venda.Total) || len(got.Items) != 2 ||
+		got.PaymentStatus != domain.PaymentStatusPending || got.Status != domain.StatusVendaAtiva {
+		t.Fatalf("BuscarVendaPorId = %+v", got)
+	}
+	if _, err := vr.BuscarVendaPorId(999); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("venda inexistente: err = %v", err)
+	}
+	item := got.Items[1]
+	if item.ProductID != acucar.ID || !item.ListPrice.Equal(decimal(t, "4.5")) ||
+		!item.UnitPrice.Equal(desconto) || item.AuthorizedBy != "gerente" || !item.Total.Equal(decimal(t, "12")) {
+		t.Errorf("item com preço autorizado = %+v", item)
+	}
+
+	filtros := []map[string]any{
+		{"nome_cliente": "maria"},
+		{"nome_produto": "CAFE"},
+		{"total": "49.8"},
+		{"data_venda": venda.DataVenda.Format("2006-01-02")},
+		{"status_pagamento": "pendente"},
+		{"status": "ativa"},
+	}
+	for _, f := range filtros {
+		vendas, err := vr.BuscarVendas(f, 10, 0)
+		if err != nil {
+			t.Fatalf("BuscarVendas(%v): %v", f, err)
+		}
+		if len(vendas) != 1 || vendas[0].ID != venda.ID || len(vendas[0].Items) != 2 {
+			t.Errorf("BuscarVendas(%v) = %+v", f, vendas)
+		}
+	}
+	if vendas, err := vr.BuscarVendas(map[string]any{"nome_produto": "feijão"}, 10, 0); err != nil || len(vendas) != 0 {
+		t.Errorf("BuscarVendas sem resultado = %+v, err %v", vendas, err)
+	}
+}
+
+func TestSalvarVendaSemEstoque(t *testing.T) {
+	db := novoBanco(t)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 1, "3.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 2}}}
+	err := NewVendasRepository(db).SalvarVenda(&venda, "teste")
+	var semEstoque *EstoqueInsuficienteError
+	if !errors.As(err, &semEstoque) {
+		t.Fatalf("err = %v, esperado *EstoqueInsuficienteError", err)
+	}
+	if len(semEstoque.Itens) != 1 || semEstoque.Itens[0].Disponivel != 1 {
+		t.Errorf("itens sem estoque = %+v", semEstoque.Itens)
+	}
+}
+
+// Vendas simultâneas disputando as mesmas unidades: cada uma só baixa o que
+// ainda há, as demais recebem *EstoqueInsuficienteError e o estoque não fica
+// negativo
+func TestVendasSimultaneasNaoNegativamEstoque(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 5, "3.00")
+	c := criarCliente(t, db, "Cliente")
+
+	const vendas = 12
+	erros := make(chan error, vendas)
+	var wg sync.WaitGroup
+	for range vendas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 2}}}
+			erros <- vr.SalvarVenda(&venda, "teste")
+		}()
+	}
+	wg.Wait()
+	close(erros)
+
+	vendidas := 0
+	for err := range erros {
+		var semEstoque *EstoqueInsuficienteError
+		switch {
+		case err == nil:
+			vendidas++
+		case !errors.As(err, &semEstoque):
+			t.Errorf("venda simultânea: %v", err)
+		}
+	}
+	if vendidas != 2 {
+		t.Errorf("vendas registradas = %d, esperado 2", vendidas)
+	}
+	if est := estoque(t, db, p.ID); est != 1 {
+		t.Errorf("estoque = %d, esperado 1", est)
+	}
+	divergencias, err := NewMovimentoRepository(db).VerificarConsistencia()
+	if err != nil || len(divergencias) != 0 {
+		t.Errorf("VerificarConsistencia = %+v, err %v", divergencias, err)
+	}
+}
+
+func TestAtualizarVenda(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	a := criarProduto(t, db, f, "A1", 10, "2.00")
+	b := criarProduto(t, db, f, "B2", 10, "3.00")
+	maria := criarCliente(t, db, "Maria")
+	joao := criarCliente(t, db, "João")
+
+	venda := domain.Sale{ClientID: maria.ID, Items: []domain.SaleItem{
+		{ProductID: a.ID, Quantity: 4},
+		{ProductID: b.ID, Quantity: 1},
+	}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	alterada, err := vr.AtualizarVenda(venda.ID, domain.Sale{ClientID: joao.ID, Items: []domain.SaleItem{
+		{ProductID: a.ID, Quantity: 1},
+		{ProductID: b.ID, Quantity: 5},
+	}}, "teste")
+	if err != nil {
+		t.Fatalf("AtualizarVenda: %v", err)
+	}
+	if alterada.ClientID != joao.ID || !alterada.Total.Equal(decimal(t, "17")) {
+		t.Errorf("AtualizarVenda = cliente %d total %s", alterada.ClientID, alterada.Total)
+	}
+	if est := estoque(t, db, a.ID); est != 9 {
+		t.Errorf("estoque de A = %d, esperado 9", est)
+	}
+	if est := estoque(t, db, b.ID); est != 5 {
+		t.Errorf("estoque de B = %d, esperado 5", est)
+	}
+	got, _ := vr.BuscarVendaPorId(venda.ID)
+	if got.Cliente.ID != joao.ID || !got.Total.Equal(decimal(t, "17")) || len(got.Items) != 2 || got.Items[1].Quantity != 5 {
+		t.Errorf("venda gravada = %+v", got)
+	}
+	// a diferença da edição fica no razão separada da venda original
+	movs, err := NewMovimentoRepository(db).BuscarMovimentos(b.ID,
+		map[string]any{"tipo": string(domain.MovimentoAlteracaoVenda)}, 10, 0)
+	if err != nil || len(movs) != 1 || movs[0].Quantidade != -4 || movs[0].Documento != documento("venda", venda.ID) {
+		t.Errorf("movimentos da alteração = %+v, err %v", movs, err)
+	}
+
+	_, err = vr.AtualizarVenda(venda.ID, domain.Sale{Items: []domain.SaleItem{{ProductID: b.ID, Quantity: 11}}}, "teste")
+	var semEstoque *EstoqueInsuficienteError
+	if !errors.As(err, &semEstoque) {
+		t.Errorf("alteração sem estoque: err = %v", err)
+	}
+	if _, err := vr.AtualizarVenda(venda.ID, domain.Sale{ClientID: 999}, "teste"); !errors.Is(err, ErrClienteInexistente) {
+		t.Errorf("cliente inexistente: err = %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE vendas SET status_pagamento = ? WHERE id = ?", domain.PaymentStatusPaid, venda.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vr.AtualizarVenda(venda.ID, domain.Sale{ClientID: maria.ID}, "teste"); !errors.Is(err, ErrVendaNaoPendente) {
+		t.Errorf("venda paga: err = %v, esperado ErrVendaNaoPendente", err)
+	}
+}
+
+func TestCancelarVenda(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "2.00")
+	c := criarCliente(t, db, "Cliente")
+
+	venda := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 4}}}
+	if err := vr.SalvarVenda(&venda, "teste"); err != nil {
+		t.Fatalf("SalvarVenda: %v", err)
+	}
+
+	cancelada, err := vr.CancelarVenda(venda.ID, "desistência", "gerente")
+	if err != nil {
+		t.Fatalf("CancelarVenda: %v", err)
+	}
+	if cancelada.Status != domain.StatusVendaCancelada || cancelada.CanceladaEm == nil {
+		t.Errorf("CancelarVenda = %+v", cancelada)
+	}
+	if est := estoque(t, db, p.ID); est != 10 {
+		t.Errorf("estoque após cancelamento = %d, esperado 10", est)
+	}
+	got, _ := vr.BuscarVendaPorId(venda.ID)
+	if got.Status != domain.StatusVendaCancelada || got.MotivoCancelamento != "desistência" || len(got.Items) != 1 {
+		t.Errorf("venda cancelada gravada = %+v", got)
+	}
+
+	if _, err := vr.CancelarVenda(venda.ID, "", "gerente"); !errors.Is(err, ErrVendaCancelada) {
+		t.Errorf("cancelar de novo: err = %v, esperado ErrVendaCancelada", err)
+	}
+	if _, err := vr.AtualizarVenda(venda.ID, domain.Sale{ClientID: c.ID}, "teste"); !errors.Is(err, ErrVendaCancelada) {
+		t.Errorf("alterar cancelada: err = %v, esperado ErrVendaCancelada", err)
+	}
+	dr := NewDevolucaoRepository(db)
+	if conta, _ := dr.BuscarCreditoLoja(c.ID); conta.Saldo.Sign() != 0 {
+		t.Errorf("venda sem pagamento cancelada gerou crédito %s", conta.Saldo)
+	}
+
+	// o que foi pago volta como crédito na loja
+	paga := domain.Sale{ClientID: c.ID, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 3}},
+		Pagamentos: []domain.Pagamento{{Valor: decimal(t, "5.00"), Forma: domain.FormaDinheiro}}}
+	if err := vr.SalvarVenda(&paga, "teste"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vr.CancelarVenda(paga.ID, "desistência", "gerente"); err != nil {
+		t.Fatalf("cancelar venda paga: %v", err)
+	}
+	conta, err := dr.BuscarCreditoLoja(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !conta.Saldo.Equal(decimal(t, "5")) || len(conta.Movimentos) != 1 ||
+		conta.Movimentos[0].Documento != documento("venda", paga.ID) {
+		t.Errorf("crédito do cancelamento = %+v", conta)
+	}
+}
+
+func TestPrecoAutorizadoExigePerfil(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 10, "10.00")
+	c := criarCliente(t, db, "Cliente")
+
+	// qualquer nome em autorizado_por não basta: o perfil de quem vende decide
+	barato := decimal(t, "0.01")
+	for _, perfil := range []string{"", "vendedor"} {
+		v := domain.Sale{ClientID: c.ID, Perfil: perfil, Items: []domain.SaleItem{
+			{ProductID: p.ID, Quantity: 5, PriceOverride: &barato, AuthorizedBy: "gerente"}}}
+		if err := vr.SalvarVenda(&v, "teste"); !errors.Is(err, domain.ErrPrecoNaoAutorizado) {
+			t.Errorf("preço autorizado com perfil %q: err = %v", perfil, err)
+		}
+	}
+	if got := estoque(t, db, p.ID); got != 10 {
+		t.Errorf("estoque após vendas recusadas = %d", got)
+	}
+
+	v := domain.Sale{ClientID: c.ID, Perfil: domain.PerfilGerente, Items: []domain.SaleItem{
+		{ProductID: p.ID, Quantity: 5, PriceOverride: &barato, AuthorizedBy: "maria"}}}
+	if err := vr.SalvarVenda(&v, "maria"); err != nil {
+		t.Fatalf("preço autorizado pelo gerente: %v", err)
+	}
+	if !v.Total.Equal(decimal(t, "0.05")) {
+		t.Errorf("total = %s", v.Total)
+	}
+}
+
+func TestSalvarVendaLimiteCredito(t *testing.T) {
+	db := novoBanco(t)
+	vr := NewVendasRepository(db)
+	f := criarFornecedor(t, db, "Fornecedor")
+	p := criarProduto(t, db, f, "A1", 100, "10.00")
+	c := criarCliente(t, db, "Cliente")
+	limite := decimal(t, "50")
+	if err := NewClienteRepository(db).DefinirCredito(c.ID, &limite, false); err != nil {
+		t.Fatal(err)
+	}
+
+	vender := func(qtd int, liberadoPor string, pagamentos ...domain.Pagamento) (domain.Sale, error) {
+		v := domain.Sale{ClientID: c.ID, CreditoLiberadoPor: liberadoPor, Pagamentos: pagamentos,
+			Perfil: domain.PerfilGerente, Items: []domain.SaleItem{{ProductID: p.ID, Quantity: qtd}}}
+		return v, vr.SalvarVenda(&v, "teste")
+	}
+
+	if _, err := vender(3, ""); err != nil {
+		t.Fatalf("venda dentro do limite: %v", err)
+	}
+	_, err := vender(3, "")
+	var semCredito *domain.CreditoInsuficienteError
+	if !errors.As(err, &semCredito) {
+		t.Fatalf("venda acima do limite: err = %v", err)
+	}
+	if !semCredito.Situacao.SaldoAberto.Equal(decimal(t, "30")) || !semCredito.Situacao.Disponivel.Equal(decimal(t, "20")) {
+		t.Errorf("situação no erro = %+v", semCredito.Situacao)
+	}
+
+	// pago no ato não consome crédito
+	if _, err := vender(3, "", domain.Pagamento{Valor: decimal(t, "10"), Forma: domain.FormaPix}); err != nil {
+		t.Errorf("venda com entrada: %v", err)
+	}
+	// liberação escrita por quem não tem perfil para ela não passa
+	for _, perfil := range []string{"", "vendedor"} {
+		v := domain.Sale{ClientID: c.ID, CreditoLiberadoPor: "qualquer um", Perfil: perfil,
+			Items: []domain.SaleItem{{ProductID: p.ID, Quantity: 5}}}
+		if err := vr.SalvarVenda(&v, "teste"); !errors.Is(err, domain.ErrLiberacaoNaoAutorizada) {
+			t.Errorf("liberação com perfil %q: err = %v", perfil, err)
+		}
+	}
+	liberada, err := vender(5, "maria")
+	if err != nil {
+		t.Fatalf("venda liberada: %v", err)
+	}
+	got, _ := vr.BuscarVendaPorId(liberada.ID)
+	if got.CreditoLiberadoPor != "maria" {
+		t.Errorf("credito_liberado_por = %q", got.CreditoLiberadoPor)
+	}
+
+	if err := NewClienteRepository(db).DefinirCredito(c.ID, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vender(1, ""); !errors.As(err, &semCredito) || !semCredito.Situacao.Bloqueado {
+		t.Errorf("cliente bloqueado: err = %v", err)
+	}
+	if _, err := vender(1, "", domain.Pagamento{Valor: decimal(t, "10"), Forma: domain.FormaDinheiro}); err != nil {
+		t.Errorf("cliente bloqueado pagando à vista: %v", err)
+	}
+}
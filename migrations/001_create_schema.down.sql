@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS vendas_produtos;
+DROP TABLE IF EXISTS vendas;
+DROP TABLE IF EXISTS compras_produtos;
+DROP TABLE IF EXISTS compras;
+DROP TABLE IF EXISTS produtos;
+DROP TABLE IF EXISTS clientes;
+DROP TABLE IF EXISTS fornecedores;
@@ -1,5 +1,4 @@
--- Enable foreign key support
-PRAGMA foreign_keys = ON;
+-- Foreign keys are enabled per connection (see database.Open)
 
 -- 1. Table: fornecedores (suppliers)
 CREATE TABLE IF NOT EXISTS fornecedores (
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_fornecedores_cnpj;
+
+ALTER TABLE fornecedores DROP COLUMN condicoes_pagamento;
+ALTER TABLE fornecedores DROP COLUMN email;
+ALTER TABLE fornecedores DROP COLUMN telefone;
+ALTER TABLE fornecedores DROP COLUMN contato;
+ALTER TABLE fornecedores DROP COLUMN cnpj;
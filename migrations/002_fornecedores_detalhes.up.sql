@@ -0,0 +1,8 @@
+-- Dados cadastrais completos dos fornecedores
+ALTER TABLE fornecedores ADD COLUMN cnpj TEXT;
+ALTER TABLE fornecedores ADD COLUMN contato TEXT;
+ALTER TABLE fornecedores ADD COLUMN telefone TEXT;
+ALTER TABLE fornecedores ADD COLUMN email TEXT;
+ALTER TABLE fornecedores ADD COLUMN condicoes_pagamento TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fornecedores_cnpj ON fornecedores(cnpj) WHERE cnpj IS NOT NULL;
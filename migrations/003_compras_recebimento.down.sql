@@ -0,0 +1,2 @@
+ALTER TABLE compras DROP COLUMN data_recebimento;
+ALTER TABLE compras DROP COLUMN status;
@@ -0,0 +1,3 @@
+-- Situação do pedido de compra e data em que foi recebido
+ALTER TABLE compras ADD COLUMN status TEXT NOT NULL DEFAULT 'PENDENTE';
+ALTER TABLE compras ADD COLUMN data_recebimento DATETIME;
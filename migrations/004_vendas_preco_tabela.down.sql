@@ -0,0 +1,2 @@
+ALTER TABLE vendas_produtos DROP COLUMN autorizado_por;
+ALTER TABLE vendas_produtos DROP COLUMN preco_tabela;
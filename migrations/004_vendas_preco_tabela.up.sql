@@ -0,0 +1,3 @@
+-- Preço de tabela vigente na venda e responsável por preço diferenciado
+ALTER TABLE vendas_produtos ADD COLUMN preco_tabela REAL;
+ALTER TABLE vendas_produtos ADD COLUMN autorizado_por TEXT;
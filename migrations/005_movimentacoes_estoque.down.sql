@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS movimentacoes_estoque_sem_delete;
+DROP TRIGGER IF EXISTS movimentacoes_estoque_sem_update;
+DROP INDEX IF EXISTS idx_movimentacoes_produto;
+DROP TABLE IF EXISTS movimentacoes_estoque;
@@ -0,0 +1,34 @@
+-- Razão (kardex) de todas as entradas e saídas de estoque
+CREATE TABLE IF NOT EXISTS movimentacoes_estoque (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    produto_id INTEGER NOT NULL,
+    tipo TEXT NOT NULL,
+    quantidade INTEGER NOT NULL,
+    saldo INTEGER NOT NULL,
+    motivo TEXT,
+    documento TEXT,
+    usuario TEXT,
+    criado_em DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movimentacoes_produto ON movimentacoes_estoque(produto_id, id);
+
+-- O razão é somente de inclusão
+CREATE TRIGGER IF NOT EXISTS movimentacoes_estoque_sem_update
+BEFORE UPDATE ON movimentacoes_estoque
+BEGIN
+    SELECT RAISE(ABORT, 'movimentacoes_estoque não pode ser alterada');
+END;
+
+CREATE TRIGGER IF NOT EXISTS movimentacoes_estoque_sem_delete
+BEFORE DELETE ON movimentacoes_estoque
+BEGIN
+    SELECT RAISE(ABORT, 'movimentacoes_estoque não pode ser apagada');
+END;
+
+-- Abre o razão com o saldo atual de cada produto
+INSERT INTO movimentacoes_estoque (produto_id, tipo, quantidade, saldo, motivo)
+SELECT id, 'SALDO_INICIAL', qtd_estoque, qtd_estoque, 'saldo existente na criação do razão'
+  FROM produtos
+ WHERE qtd_estoque <> 0;
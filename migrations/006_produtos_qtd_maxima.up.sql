@@ -0,0 +1,2 @@
+-- Nível alvo (máximo) de estoque usado na sugestão de reposição
+ALTER TABLE produtos ADD COLUMN qtd_maxima INTEGER;
@@ -0,0 +1,3 @@
+ALTER TABLE vendas DROP COLUMN motivo_cancelamento;
+ALTER TABLE vendas DROP COLUMN cancelada_em;
+ALTER TABLE vendas DROP COLUMN status;
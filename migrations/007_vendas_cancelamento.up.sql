@@ -0,0 +1,5 @@
+-- Vendas não são mais apagadas: o cancelamento devolve os itens ao estoque e
+-- mantém a venda no histórico
+ALTER TABLE vendas ADD COLUMN status TEXT NOT NULL DEFAULT 'ATIVA';
+ALTER TABLE vendas ADD COLUMN cancelada_em DATETIME;
+ALTER TABLE vendas ADD COLUMN motivo_cancelamento TEXT;
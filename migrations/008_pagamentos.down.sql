@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS parcelas;
+DROP TABLE IF EXISTS pagamentos;
@@ -0,0 +1,33 @@
+-- Valores recebidos de cada venda; status_pagamento e data_pagamento de
+-- vendas passam a ser derivados da soma destes pagamentos
+CREATE TABLE IF NOT EXISTS pagamentos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    venda_id INTEGER NOT NULL,
+    valor REAL NOT NULL,
+    data_pagamento DATETIME NOT NULL,
+    forma TEXT NOT NULL,
+    usuario TEXT,
+    criado_em DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(venda_id) REFERENCES vendas(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pagamentos_venda ON pagamentos(venda_id);
+
+-- Vencimentos combinados com o cliente; o valor pago de cada parcela é
+-- calculado distribuindo os pagamentos pela ordem de vencimento
+CREATE TABLE IF NOT EXISTS parcelas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    venda_id INTEGER NOT NULL,
+    numero INTEGER NOT NULL,
+    valor REAL NOT NULL,
+    vencimento DATE NOT NULL,
+    UNIQUE(venda_id, numero),
+    FOREIGN KEY(venda_id) REFERENCES vendas(id)
+);
+
+-- Vendas já marcadas como pagas recebem um pagamento equivalente, para que o
+-- status derivado continue o mesmo
+INSERT INTO pagamentos (venda_id, valor, data_pagamento, forma, usuario)
+SELECT id, total, COALESCE(data_pagamento, data_venda), 'OUTRO', 'migração'
+  FROM vendas
+ WHERE status_pagamento = 'PAGO';
@@ -0,0 +1,3 @@
+ALTER TABLE vendas DROP COLUMN credito_liberado_por;
+ALTER TABLE clientes DROP COLUMN bloqueado;
+ALTER TABLE clientes DROP COLUMN limite_credito;
@@ -0,0 +1,6 @@
+-- Limite de crédito (NULL = sem limite) e bloqueio de vendas a prazo
+ALTER TABLE clientes ADD COLUMN limite_credito REAL;
+ALTER TABLE clientes ADD COLUMN bloqueado INTEGER NOT NULL DEFAULT 0;
+
+-- Quem liberou uma venda a prazo acima do limite ou para cliente bloqueado
+ALTER TABLE vendas ADD COLUMN credito_liberado_por TEXT;
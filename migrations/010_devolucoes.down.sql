@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS creditos_clientes;
+DROP TABLE IF EXISTS devolucoes_produtos;
+DROP TABLE IF EXISTS devolucoes;
+ALTER TABLE produtos DROP COLUMN qtd_avariada;
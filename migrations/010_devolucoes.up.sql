@@ -0,0 +1,46 @@
+-- Unidades devolvidas com avaria: ficam fora do estoque disponível para venda
+ALTER TABLE produtos ADD COLUMN qtd_avariada INTEGER NOT NULL DEFAULT 0;
+
+-- Devoluções de clientes. valor = abatido (reduz o saldo da venda, lançado
+-- também em pagamentos com forma DEVOLUCAO) + reembolso + credito
+CREATE TABLE IF NOT EXISTS devolucoes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    venda_id INTEGER NOT NULL,
+    data DATETIME NOT NULL,
+    motivo TEXT,
+    destino TEXT NOT NULL,
+    forma_reembolso TEXT,
+    valor REAL NOT NULL,
+    abatido REAL NOT NULL,
+    reembolso REAL NOT NULL,
+    credito REAL NOT NULL,
+    usuario TEXT,
+    FOREIGN KEY(venda_id) REFERENCES vendas(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devolucoes_venda ON devolucoes(venda_id);
+
+CREATE TABLE IF NOT EXISTS devolucoes_produtos (
+    devolucao_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    quantidade INTEGER NOT NULL,
+    quantidade_avariada INTEGER NOT NULL DEFAULT 0,
+    preco_unitario REAL NOT NULL,
+    PRIMARY KEY(devolucao_id, produto_id),
+    FOREIGN KEY(devolucao_id) REFERENCES devolucoes(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
+
+-- Conta de crédito do cliente na loja: entradas positivas geradas por
+-- devoluções, negativas quando o crédito paga uma venda
+CREATE TABLE IF NOT EXISTS creditos_clientes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cliente_id INTEGER NOT NULL,
+    valor REAL NOT NULL,
+    documento TEXT NOT NULL,
+    usuario TEXT,
+    criado_em DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(cliente_id) REFERENCES clientes(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_creditos_clientes_cliente ON creditos_clientes(cliente_id);
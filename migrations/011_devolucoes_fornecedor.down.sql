@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS devolucoes_fornecedor_produtos;
+DROP TABLE IF EXISTS devolucoes_fornecedor;
+ALTER TABLE compras DROP COLUMN motivo_cancelamento;
+ALTER TABLE compras DROP COLUMN cancelada_em;
@@ -0,0 +1,33 @@
+-- Pedidos de compra podem ser cancelados enquanto não recebidos
+ALTER TABLE compras ADD COLUMN cancelada_em DATETIME;
+ALTER TABLE compras ADD COLUMN motivo_cancelamento TEXT;
+
+-- Mercadoria devolvida ao fornecedor a partir de uma compra recebida.
+-- credito é o valor que o fornecedor deve abater ou restituir;
+-- credito_recebido_em fica nulo enquanto o crédito está pendente.
+CREATE TABLE IF NOT EXISTS devolucoes_fornecedor (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    compra_id INTEGER NOT NULL,
+    fornecedor_id INTEGER NOT NULL,
+    data DATETIME NOT NULL,
+    motivo TEXT,
+    credito REAL NOT NULL,
+    credito_recebido_em DATETIME,
+    usuario TEXT,
+    FOREIGN KEY(compra_id) REFERENCES compras(id),
+    FOREIGN KEY(fornecedor_id) REFERENCES fornecedores(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devolucoes_fornecedor_compra ON devolucoes_fornecedor(compra_id);
+CREATE INDEX IF NOT EXISTS idx_devolucoes_fornecedor_fornecedor ON devolucoes_fornecedor(fornecedor_id);
+
+CREATE TABLE IF NOT EXISTS devolucoes_fornecedor_produtos (
+    devolucao_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    quantidade INTEGER NOT NULL,
+    quantidade_avariada INTEGER NOT NULL DEFAULT 0,
+    custo_unitario REAL NOT NULL,
+    PRIMARY KEY(devolucao_id, produto_id),
+    FOREIGN KEY(devolucao_id) REFERENCES devolucoes_fornecedor(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS orcamentos_produtos;
+DROP TABLE IF EXISTS orcamentos;
@@ -0,0 +1,29 @@
+-- Orçamentos: propostas de venda que não mexem no estoque até serem convertidas
+CREATE TABLE IF NOT EXISTS orcamentos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cliente_id INTEGER NOT NULL,
+    data DATETIME NOT NULL,
+    valido_ate DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'ABERTO',
+    total REAL NOT NULL,
+    aprovado_em DATETIME,
+    venda_id INTEGER,
+    usuario TEXT,
+    FOREIGN KEY(cliente_id) REFERENCES clientes(id),
+    FOREIGN KEY(venda_id) REFERENCES vendas(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_orcamentos_status ON orcamentos(status, valido_ate);
+
+CREATE TABLE IF NOT EXISTS orcamentos_produtos (
+    orcamento_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    quantidade INTEGER NOT NULL,
+    preco_tabela REAL NOT NULL,
+    preco_unitario REAL NOT NULL,
+    total REAL NOT NULL,
+    autorizado_por TEXT,
+    PRIMARY KEY(orcamento_id, produto_id),
+    FOREIGN KEY(orcamento_id) REFERENCES orcamentos(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS limites_desconto;
+
+ALTER TABLE devolucoes_produtos DROP COLUMN total;
+
+ALTER TABLE orcamentos_produtos DROP COLUMN desconto_valor;
+ALTER TABLE orcamentos_produtos DROP COLUMN desconto_tipo;
+ALTER TABLE orcamentos_produtos DROP COLUMN valor_desconto;
+ALTER TABLE orcamentos_produtos DROP COLUMN bruto;
+
+ALTER TABLE orcamentos DROP COLUMN desconto_valor;
+ALTER TABLE orcamentos DROP COLUMN desconto_tipo;
+ALTER TABLE orcamentos DROP COLUMN valor_desconto;
+ALTER TABLE orcamentos DROP COLUMN bruto;
+
+ALTER TABLE vendas_produtos DROP COLUMN desconto_valor;
+ALTER TABLE vendas_produtos DROP COLUMN desconto_tipo;
+ALTER TABLE vendas_produtos DROP COLUMN valor_desconto;
+ALTER TABLE vendas_produtos DROP COLUMN bruto;
+
+ALTER TABLE vendas DROP COLUMN desconto_valor;
+ALTER TABLE vendas DROP COLUMN desconto_tipo;
+ALTER TABLE vendas DROP COLUMN valor_desconto;
+ALTER TABLE vendas DROP COLUMN bruto;
@@ -0,0 +1,39 @@
+-- Descontos por item e por venda. bruto é preço x quantidade, valor_desconto
+-- o desconto efetivo (no item inclui sua parte do desconto da venda) e total
+-- continua sendo o líquido. desconto_tipo/desconto_valor guardam o desconto
+-- como foi concedido (PERCENTUAL ou VALOR).
+ALTER TABLE vendas ADD COLUMN bruto REAL NOT NULL DEFAULT 0;
+ALTER TABLE vendas ADD COLUMN valor_desconto REAL NOT NULL DEFAULT 0;
+ALTER TABLE vendas ADD COLUMN desconto_tipo TEXT;
+ALTER TABLE vendas ADD COLUMN desconto_valor REAL;
+UPDATE vendas SET bruto = total;
+
+ALTER TABLE vendas_produtos ADD COLUMN bruto REAL NOT NULL DEFAULT 0;
+ALTER TABLE vendas_produtos ADD COLUMN valor_desconto REAL NOT NULL DEFAULT 0;
+ALTER TABLE vendas_produtos ADD COLUMN desconto_tipo TEXT;
+ALTER TABLE vendas_produtos ADD COLUMN desconto_valor REAL;
+UPDATE vendas_produtos SET bruto = total;
+
+ALTER TABLE orcamentos ADD COLUMN bruto REAL NOT NULL DEFAULT 0;
+ALTER TABLE orcamentos ADD COLUMN valor_desconto REAL NOT NULL DEFAULT 0;
+ALTER TABLE orcamentos ADD COLUMN desconto_tipo TEXT;
+ALTER TABLE orcamentos ADD COLUMN desconto_valor REAL;
+UPDATE orcamentos SET bruto = total;
+
+ALTER TABLE orcamentos_produtos ADD COLUMN bruto REAL NOT NULL DEFAULT 0;
+ALTER TABLE orcamentos_produtos ADD COLUMN valor_desconto REAL NOT NULL DEFAULT 0;
+ALTER TABLE orcamentos_produtos ADD COLUMN desconto_tipo TEXT;
+ALTER TABLE orcamentos_produtos ADD COLUMN desconto_valor REAL;
+UPDATE orcamentos_produtos SET bruto = total;
+
+-- Itens devolvidos passam a guardar o valor líquido devolvido, que com
+-- desconto não é mais preco_unitario x quantidade
+ALTER TABLE devolucoes_produtos ADD COLUMN total REAL NOT NULL DEFAULT 0;
+UPDATE devolucoes_produtos SET total = ROUND(preco_unitario * quantidade, 2);
+
+-- Desconto máximo, em percentual, que cada perfil (cabeçalho X-Perfil) pode
+-- conceder; perfis sem linha não concedem desconto
+CREATE TABLE IF NOT EXISTS limites_desconto (
+    perfil TEXT PRIMARY KEY,
+    percentual_maximo REAL NOT NULL
+);
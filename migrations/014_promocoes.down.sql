@@ -0,0 +1,8 @@
+ALTER TABLE orcamentos_produtos DROP COLUMN valor_promocao;
+ALTER TABLE orcamentos_produtos DROP COLUMN promocao_id;
+
+ALTER TABLE vendas_produtos DROP COLUMN valor_promocao;
+ALTER TABLE vendas_produtos DROP COLUMN promocao_id;
+
+DROP TABLE IF EXISTS promocoes_faixas;
+DROP TABLE IF EXISTS promocoes;
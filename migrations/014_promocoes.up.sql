@@ -0,0 +1,36 @@
+-- Promoções aplicadas automaticamente na precificação de vendas e orçamentos.
+-- Os campos usados dependem do tipo: PERCENTUAL_FORNECEDOR usa fornecedor_id
+-- e percentual, LEVE_PAGUE usa produto_id, leve e pague, FAIXA_QUANTIDADE usa
+-- produto_id e as faixas de promocoes_faixas.
+CREATE TABLE IF NOT EXISTS promocoes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nome TEXT NOT NULL,
+    tipo TEXT NOT NULL,
+    fornecedor_id INTEGER,
+    produto_id INTEGER,
+    percentual REAL,
+    leve INTEGER,
+    pague INTEGER,
+    inicio DATE NOT NULL,
+    fim DATE,
+    criada_em DATETIME NOT NULL,
+    FOREIGN KEY(fornecedor_id) REFERENCES fornecedores(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promocoes_vigencia ON promocoes(inicio, fim);
+
+CREATE TABLE IF NOT EXISTS promocoes_faixas (
+    promocao_id INTEGER NOT NULL,
+    quantidade_minima INTEGER NOT NULL,
+    preco REAL NOT NULL,
+    PRIMARY KEY(promocao_id, quantidade_minima),
+    FOREIGN KEY(promocao_id) REFERENCES promocoes(id) ON DELETE CASCADE
+);
+
+-- Promoção aplicada a cada item e quanto ela tirou do bruto da linha
+ALTER TABLE vendas_produtos ADD COLUMN promocao_id INTEGER REFERENCES promocoes(id);
+ALTER TABLE vendas_produtos ADD COLUMN valor_promocao REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE orcamentos_produtos ADD COLUMN promocao_id INTEGER REFERENCES promocoes(id);
+ALTER TABLE orcamentos_produtos ADD COLUMN valor_promocao REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+ALTER TABLE vendas_produtos DROP COLUMN lucro_bruto;
+ALTER TABLE vendas_produtos DROP COLUMN custo_unitario;
+
+ALTER TABLE produtos DROP COLUMN custo;
@@ -0,0 +1,17 @@
+-- Custo médio ponderado dos produtos, atualizado a cada recebimento de
+-- compra. O valor inicial é a média das compras já recebidas.
+ALTER TABLE produtos ADD COLUMN custo REAL NOT NULL DEFAULT 0;
+UPDATE produtos SET custo = COALESCE((
+    SELECT ROUND(SUM(cp.preco_unitario * cp.quantidade) / SUM(cp.quantidade), 2)
+      FROM compras_produtos cp
+      JOIN compras c ON c.id = cp.compra_id
+     WHERE cp.produto_id = produtos.id AND c.status = 'RECEBIDA'
+), 0);
+
+-- Custo do produto no momento da venda e lucro bruto do item (total líquido
+-- menos custo), para que os relatórios não mudem quando o custo mudar
+ALTER TABLE vendas_produtos ADD COLUMN custo_unitario REAL NOT NULL DEFAULT 0;
+ALTER TABLE vendas_produtos ADD COLUMN lucro_bruto REAL NOT NULL DEFAULT 0;
+UPDATE vendas_produtos SET
+    custo_unitario = (SELECT custo FROM produtos WHERE produtos.id = vendas_produtos.produto_id),
+    lucro_bruto = ROUND(total - quantidade * (SELECT custo FROM produtos WHERE produtos.id = vendas_produtos.produto_id), 2);
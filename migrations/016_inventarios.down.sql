@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS inventarios_produtos;
+DROP TABLE IF EXISTS inventarios;
@@ -0,0 +1,25 @@
+-- Sessões de contagem física. Na abertura o estoque de cada produto é
+-- congelado em esperada; contada fica nula até o produto ser contado.
+CREATE TABLE IF NOT EXISTS inventarios (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    descricao TEXT,
+    status TEXT NOT NULL DEFAULT 'ABERTO',
+    aberto_em DATETIME NOT NULL,
+    usuario TEXT,
+    aprovado_em DATETIME,
+    aprovado_por TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventarios_status ON inventarios(status);
+
+CREATE TABLE IF NOT EXISTS inventarios_produtos (
+    inventario_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    esperada INTEGER NOT NULL,
+    contada INTEGER,
+    contado_em DATETIME,
+    contado_por TEXT,
+    PRIMARY KEY(inventario_id, produto_id),
+    FOREIGN KEY(inventario_id) REFERENCES inventarios(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
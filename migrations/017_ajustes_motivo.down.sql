@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_movimentacoes_ajuste;
+
+ALTER TABLE movimentacoes_estoque DROP COLUMN motivo_ajuste;
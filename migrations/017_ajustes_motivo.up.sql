@@ -0,0 +1,5 @@
+-- Código do motivo dos ajustes manuais de estoque (PERDA, QUEBRA, FURTO,
+-- VENCIMENTO, CORRECAO). Ajustes anteriores ficam sem código.
+ALTER TABLE movimentacoes_estoque ADD COLUMN motivo_ajuste TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_movimentacoes_ajuste ON movimentacoes_estoque(motivo_ajuste, criado_em);
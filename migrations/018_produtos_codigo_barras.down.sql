@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_produtos_codigo_barras;
@@ -0,0 +1,23 @@
+-- Código de barras único por produto; vazio passa a ser NULL, que não conflita
+UPDATE produtos SET codigo_barras = NULL WHERE TRIM(codigo_barras) = '';
+
+-- Códigos repetidos entre produtos param a migração listando códigos e
+-- produtos, para que o operador decida qual produto fica com cada um
+CREATE TEMP TABLE codigos_barras_repetidos (mensagem TEXT);
+CREATE TEMP TRIGGER recusar_codigos_barras_repetidos BEFORE INSERT ON codigos_barras_repetidos
+BEGIN
+    SELECT RAISE(ABORT, NEW.mensagem);
+END;
+INSERT INTO codigos_barras_repetidos
+SELECT 'códigos de barras repetidos, deixe cada um em um só produto: ' ||
+       group_concat(codigo_barras || ' (produtos ' || ids || ')', '; ')
+  FROM (SELECT codigo_barras, group_concat(id, ', ') AS ids
+          FROM (SELECT codigo_barras, id FROM produtos WHERE codigo_barras IS NOT NULL ORDER BY id)
+         GROUP BY codigo_barras
+        HAVING COUNT(*) > 1
+         ORDER BY codigo_barras)
+HAVING COUNT(*) > 0;
+DROP TABLE codigos_barras_repetidos;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_produtos_codigo_barras
+    ON produtos(codigo_barras) WHERE codigo_barras IS NOT NULL;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS transferencias_produtos;
+DROP TABLE IF EXISTS transferencias;
+
+ALTER TABLE inventarios DROP COLUMN deposito_id;
+ALTER TABLE compras DROP COLUMN deposito_id;
+ALTER TABLE vendas DROP COLUMN deposito_id;
+ALTER TABLE movimentacoes_estoque DROP COLUMN saldo_deposito;
+ALTER TABLE movimentacoes_estoque DROP COLUMN deposito_id;
+
+DROP INDEX IF EXISTS idx_estoque_depositos_deposito;
+DROP TABLE IF EXISTS estoque_depositos;
+DROP TABLE IF EXISTS depositos;
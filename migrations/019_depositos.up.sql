@@ -0,0 +1,57 @@
+-- Locais de estoque (loja, depósito dos fundos, filial). O depósito 1 é a
+-- loja principal, usado por documentos que não informam depósito; o estoque
+-- existente começa inteiro nele.
+CREATE TABLE IF NOT EXISTS depositos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nome TEXT NOT NULL UNIQUE,
+    ativo INTEGER NOT NULL DEFAULT 1
+);
+
+INSERT INTO depositos (id, nome) VALUES (1, 'Loja');
+
+-- Saldo de cada produto em cada depósito. produtos.qtd_estoque continua
+-- sendo o total de todos os depósitos.
+CREATE TABLE IF NOT EXISTS estoque_depositos (
+    produto_id INTEGER NOT NULL,
+    deposito_id INTEGER NOT NULL,
+    quantidade INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY(produto_id, deposito_id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id),
+    FOREIGN KEY(deposito_id) REFERENCES depositos(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_estoque_depositos_deposito ON estoque_depositos(deposito_id, quantidade);
+
+INSERT INTO estoque_depositos (produto_id, deposito_id, quantidade)
+SELECT id, 1, qtd_estoque FROM produtos WHERE qtd_estoque <> 0;
+
+-- saldo_deposito é o estoque do produto no depósito após o movimento
+ALTER TABLE movimentacoes_estoque ADD COLUMN deposito_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE movimentacoes_estoque ADD COLUMN saldo_deposito INTEGER;
+UPDATE movimentacoes_estoque SET saldo_deposito = saldo;
+
+ALTER TABLE vendas ADD COLUMN deposito_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE compras ADD COLUMN deposito_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE inventarios ADD COLUMN deposito_id INTEGER NOT NULL DEFAULT 1;
+
+-- Transferência de mercadoria entre depósitos: cada item gera uma saída na
+-- origem e uma entrada no destino, sem mudar o estoque total
+CREATE TABLE IF NOT EXISTS transferencias (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    origem_id INTEGER NOT NULL,
+    destino_id INTEGER NOT NULL,
+    data DATETIME NOT NULL,
+    observacao TEXT,
+    usuario TEXT,
+    FOREIGN KEY(origem_id) REFERENCES depositos(id),
+    FOREIGN KEY(destino_id) REFERENCES depositos(id)
+);
+
+CREATE TABLE IF NOT EXISTS transferencias_produtos (
+    transferencia_id INTEGER NOT NULL,
+    produto_id INTEGER NOT NULL,
+    quantidade INTEGER NOT NULL,
+    PRIMARY KEY(transferencia_id, produto_id),
+    FOREIGN KEY(transferencia_id) REFERENCES transferencias(id),
+    FOREIGN KEY(produto_id) REFERENCES produtos(id)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_lotes_movimentos_movimento;
+DROP INDEX IF EXISTS idx_lotes_movimentos_origem;
+DROP TABLE IF EXISTS lotes_movimentos;
+
+DROP INDEX IF EXISTS idx_lotes_validade;
+DROP INDEX IF EXISTS idx_lotes_identificacao;
+DROP TABLE IF EXISTS lotes;
@@ -0,0 +1,35 @@
+-- Lotes de produto por depósito, com número do fabricante e/ou validade.
+-- O controle é opcional: a soma dos lotes de um produto num depósito fica
+-- sempre menor ou igual ao saldo em estoque_depositos, e a diferença são
+-- unidades sem lote.
+CREATE TABLE IF NOT EXISTS lotes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    produto_id INTEGER NOT NULL,
+    deposito_id INTEGER NOT NULL,
+    numero TEXT NOT NULL DEFAULT '',
+    validade DATE,
+    quantidade INTEGER NOT NULL DEFAULT 0 CHECK (quantidade >= 0),
+    criado_em DATETIME NOT NULL,
+    FOREIGN KEY(produto_id) REFERENCES produtos(id),
+    FOREIGN KEY(deposito_id) REFERENCES depositos(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lotes_identificacao
+    ON lotes(produto_id, deposito_id, numero, COALESCE(validade, ''));
+CREATE INDEX IF NOT EXISTS idx_lotes_validade ON lotes(validade) WHERE quantidade > 0;
+
+-- Quanto cada movimento do razão tirou ou pôs em cada lote. origem é o
+-- documento que consumiu o lote; estornos (cancelamento, devolução) gravam a
+-- origem da saída estornada para devolver as unidades aos mesmos lotes.
+CREATE TABLE IF NOT EXISTS lotes_movimentos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    lote_id INTEGER NOT NULL,
+    movimento_id INTEGER NOT NULL,
+    origem TEXT NOT NULL,
+    quantidade INTEGER NOT NULL,
+    FOREIGN KEY(lote_id) REFERENCES lotes(id),
+    FOREIGN KEY(movimento_id) REFERENCES movimentacoes_estoque(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lotes_movimentos_origem ON lotes_movimentos(origem);
+CREATE INDEX IF NOT EXISTS idx_lotes_movimentos_movimento ON lotes_movimentos(movimento_id);
@@ -0,0 +1,12 @@
+-- Usuários do sistema. A requisição se identifica com o token em
+-- "Authorization: Bearer <token>"; só o hash SHA-256 do token é guardado.
+-- O perfil define o limite de desconto (limites_desconto.perfil) e se o
+-- usuário pode autorizar liberações.
+CREATE TABLE IF NOT EXISTS usuarios (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nome TEXT NOT NULL UNIQUE,
+    perfil TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    ativo INTEGER NOT NULL DEFAULT 1,
+    criado_em DATETIME NOT NULL
+);
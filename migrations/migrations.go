@@ -0,0 +1,12 @@
+// Package migrations embute os scripts SQL versionados do banco.
+//
+// Cada versão tem um par de arquivos NNN_descricao.up.sql e
+// NNN_descricao.down.sql; um script já aplicado não deve ser editado, pois o
+// checksum gravado em schema_migrations deixa de conferir. Mudanças no
+// esquema entram sempre como uma nova versão.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS